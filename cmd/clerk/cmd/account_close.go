@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/account"
 )
 
 // closeCmd represents the close command
@@ -26,7 +27,7 @@ var closeCmd = &cobra.Command{
 	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
-			accounts := factory.NewFactory().NewAccountProjection(cmd.Context()).Accounts()
+			accounts := factory.NewFactory().NewAccountProjection(cmd.Context()).For(cmd.Context()).AccountsWithStatus(account.AccountStatusOpen)
 
 			ids := make([]string, len(accounts))
 			for i, account := range accounts {
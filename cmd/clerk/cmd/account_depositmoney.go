@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/account"
 )
 
 // depositMoneyCmd represents the addMoney command
@@ -33,7 +34,7 @@ var depositMoneyCmd = &cobra.Command{
 	Args: cobra.MinimumNArgs(2),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
-			accounts := factory.NewFactory().NewAccountProjection(cmd.Context()).Accounts()
+			accounts := factory.NewFactory().NewAccountProjection(cmd.Context()).For(cmd.Context()).AccountsWithStatus(account.AccountStatusOpen)
 
 			ids := make([]string, len(accounts))
 			for i, account := range accounts {
@@ -4,6 +4,7 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -17,11 +18,24 @@ var listCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "Lists the accounts created",
 	Run: func(cmd *cobra.Command, _ []string) {
-		accounts := factory.NewFactory().NewAccountProjection(cmd.Context()).Accounts()
+		accountProjection := factory.NewFactory().NewAccountProjection(cmd.Context()).For(cmd.Context())
+		var accounts []account.ProjectedAccount
+		status, _ := cmd.Flags().GetString("status")
+		switch status {
+		case string(account.AccountStatusOpen), string(account.AccountStatusClosed):
+			accounts = accountProjection.AccountsWithStatus(account.AccountStatus(status))
+		case "all":
+			accounts = accountProjection.Accounts()
+		default:
+			cmd.PrintErrln("status must be open, closed or all")
+			os.Exit(1)
+		}
 
 		for _, account := range accounts {
 			account := account
-			cmd.Printf("Account ID: %s\nBalance: %d\n", account.AccountID, account.Balance)
+			cmd.Printf("Account ID: %s\nStatus: %s\nBalance: %d\n", account.AccountID, account.Status, account.Balance)
+			printDate(cmd, "Opened on", account.OpenedOn)
+			printDate(cmd, "Closed on", account.ClosedOn)
 			if len(account.Movements) != 0 {
 				printMovements(cmd, account.Movements)
 			}
@@ -29,18 +43,30 @@ var listCmd = &cobra.Command{
 	},
 }
 
+func printDate(cmd *cobra.Command, label string, date time.Time) {
+	if date.IsZero() {
+		return
+	}
+	// nolint:gosmopolitan // Since this is the presentation layer, we want to present it in the local timezone for the user.
+	cmd.Printf("%s: %s\n", label, date.Local().Format(time.RFC1123Z))
+}
+
 func printMovements(cmd *cobra.Command, movements []account.ProjectedMovement) {
 	cmd.Println("Movements:")
 	for _, movement := range movements {
 		movement := movement
 		cmd.Printf(
-			"  - [%s]: %s of %d, resulting in %d\n",
+			"  - [%s]: %s of %d, resulting in %d",
 			// nolint:gosmopolitan // Since this is the presentation layer, we want to present it in the local timezone for the user.
 			movement.Timestamp.Local().Format(time.RFC1123Z),
 			movement.Type,
 			movement.Amount,
 			movement.ResultingBalance,
 		)
+		if movement.TransferID != "" {
+			cmd.Printf(" (transfer %s with %s)", movement.TransferID, movement.CounterpartyAccount)
+		}
+		cmd.Printf("\n")
 	}
 	cmd.Printf("\n")
 }
@@ -56,5 +82,5 @@ func init() {
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
-	// listCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	listCmd.Flags().String("status", string(account.AccountStatusOpen), "Only the accounts in this status: open, closed or all")
 }
@@ -0,0 +1,56 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	appgrpc "github.com/tembleking/myBankSourcing/pkg/application/grpc"
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+// adminCmd represents the admin command
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administration operations",
+}
+
+// addAdminAPIFlags adds the flags to reach the admin gRPC API of the server to the command and its subcommands.
+func addAdminAPIFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("address", "localhost:8082", "Address of the admin gRPC API of the server")
+	cmd.PersistentFlags().String("api-key", "", "Admin API key, required if the server has admin API keys")
+}
+
+// newAdminClient connects to the admin gRPC API of the running server, since the projections live in its process.
+func newAdminClient(cmd *cobra.Command) (proto.ClerkAdminAPIServiceClient, func(), error) {
+	address, _ := cmd.Flags().GetString("address")
+	apiKey, _ := cmd.Flags().GetString("api-key")
+
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(appgrpc.BearerToken(apiKey)),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to the admin API at %s: %w", address, err)
+	}
+	return proto.NewClerkAdminAPIServiceClient(conn), func() { _ = conn.Close() }, nil
+}
+
+func init() {
+	rootCmd.AddCommand(adminCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// adminCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// adminCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+// exportCmd represents the admin export command
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Exports the events to a JSON Lines file",
+	Long: `Writes the events of the event store to a file, or to the standard output if no file is given,
+one JSON object per line with the metadata and the payload of the event, followed by a footer
+used to detect modified, removed or reordered lines when importing it.
+
+Example:
+  clerk admin export backup.jsonl --stream some-account --from 2024-01-01T00:00:00Z`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := eventFilterFromFlags(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		output := cmd.OutOrStdout()
+		if len(args) == 1 {
+			file, err := os.Create(args[0])
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			output = file
+		}
+
+		exported, err := factory.NewFactory().NewExporter().WithFilter(filter).Export(cmd.Context(), output)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		cmd.PrintErrf("Exported events: %d\n", exported)
+	},
+	Args: cobra.MaximumNArgs(1),
+}
+
+func addEventFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("stream", nil, "Only the events of these streams")
+	cmd.Flags().StringSlice("event", nil, "Only the events with these names")
+	cmd.Flags().String("from", "", "Only the events that happened from this RFC 3339 time, inclusive")
+	cmd.Flags().String("to", "", "Only the events that happened before this RFC 3339 time")
+}
+
+func eventFilterFromFlags(cmd *cobra.Command) (persistence.EventFilter, error) {
+	streams, _ := cmd.Flags().GetStringSlice("stream")
+	events, _ := cmd.Flags().GetStringSlice("event")
+	filter := persistence.EventFilter{StreamNames: streams, EventNames: events}
+
+	for flag, instant := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value, _ := cmd.Flags().GetString(flag)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return persistence.EventFilter{}, fmt.Errorf("--%s must be an RFC 3339 time: %w", flag, err)
+		}
+		*instant = parsed
+	}
+	return filter, nil
+}
+
+func init() {
+	adminCmd.AddCommand(exportCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// exportCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	addEventFilterFlags(exportCmd)
+}
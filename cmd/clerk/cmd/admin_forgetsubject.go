@@ -0,0 +1,50 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+// forgetSubjectCmd represents the forget-subject command
+var forgetSubjectCmd = &cobra.Command{
+	Use:   "forget-subject [subject id]",
+	Short: "Deletes the key of the personal data of a subject, e.g. an account, so it is read as redacted from then on",
+	Long: `Deletes the key of the personal data of a subject, e.g. an account, so it is read as redacted from then on.
+The projections keep the personal data encrypted with the same key, so it is redacted in them and in their snapshots too.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient, err := newAdminClient(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer closeClient()
+
+		_, err = client.ForgetSubject(cmd.Context(), &proto.ForgetSubjectRequest{SubjectId: args[0]})
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		cmd.Printf("Forgot the personal data of the subject: %s\n", args[0])
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	adminCmd.AddCommand(forgetSubjectCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	addAdminAPIFlags(forgetSubjectCmd)
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// forgetSubjectCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+// importCmd represents the admin import command
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Imports the events of a JSON Lines file written by export",
+	Long: `Appends to the event store the events of a file written by "clerk admin export",
+or of the standard input if no file is given. The whole file is verified before importing
+any event, so nothing is imported if a line was modified, removed or reordered.
+The events are imported in batches; if a batch fails, the previous ones are kept and the
+import can be resumed after the last imported event with --resume-after.
+
+Example:
+  clerk admin import backup.jsonl --event AccountOpened`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := eventFilterFromFlags(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		var input *os.File
+		if len(args) == 1 {
+			input, err = os.Open(args[0])
+		} else {
+			input, err = spoolStdin(cmd)
+		}
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer input.Close()
+
+		importer := factory.NewFactory().NewImporter().WithFilter(filter)
+		if skip, _ := cmd.Flags().GetBool("skip-integrity-checks"); skip {
+			importer.WithoutIntegrityChecks()
+		}
+		if resumeAfter, _ := cmd.Flags().GetString("resume-after"); resumeAfter != "" {
+			importer.WithResumeAfter(domain.EventID(resumeAfter))
+		}
+
+		imported, err := importer.Import(cmd.Context(), input)
+		if err != nil {
+			cmd.PrintErrln(err)
+			if errors.Is(err, persistence.ErrPartialImport) {
+				cmd.PrintErrf("Imported events: %d, import the same file again with --resume-after to import the rest\n", imported)
+			}
+			os.Exit(1)
+		}
+		cmd.Printf("Imported events: %d\n", imported)
+	},
+	Args: cobra.MaximumNArgs(1),
+}
+
+// spoolStdin copies the standard input to a temporary file, since the import reads its input twice.
+func spoolStdin(cmd *cobra.Command) (*os.File, error) {
+	file, err := os.CreateTemp("", "clerk-import-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	// The file is removed once closed, and is only reachable through the descriptor meanwhile.
+	_ = os.Remove(file.Name())
+
+	if _, err := io.Copy(file, cmd.InOrStdin()); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading the standard input: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func init() {
+	adminCmd.AddCommand(importCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// importCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	addEventFilterFlags(importCmd)
+	importCmd.Flags().Bool("skip-integrity-checks", false, "Import the files edited by hand, whose checksums do not match")
+	importCmd.Flags().String("resume-after", "", "Skip the events of the file up to this event ID, inclusive, to resume a partial import")
+}
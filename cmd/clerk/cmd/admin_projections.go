@@ -0,0 +1,72 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+// projectionsCmd represents the projections command
+var projectionsCmd = &cobra.Command{
+	Use:   "projections",
+	Short: "Projection operations",
+}
+
+func printProjectionStatus(cmd *cobra.Command, status *proto.ProjectionStatus) {
+	cmd.Printf("Projection: %s\nVersion: %d\nState: %s\nError policy: %s\n", status.GetName(), status.GetVersion(), status.GetState(), status.GetErrorPolicy())
+	cmd.Printf("Last event ID: %s\n", status.GetLastEventId())
+	if status.GetLastProcessedOn() != nil {
+		// nolint:gosmopolitan // Since this is the presentation layer, we want to present it in the local timezone for the user.
+		cmd.Printf("Last processed on: %s\n", status.GetLastProcessedOn().AsTime().Local().Format(time.RFC1123Z))
+	}
+	cmd.Printf("Lag: %d events\nProcessed events: %d\nSkipped events: %d\n", status.GetLagEvents(), status.GetProcessedEvents(), status.GetSkippedEvents())
+	if status.GetLastError() != "" {
+		cmd.Printf("Last error: %s\n", status.GetLastError())
+	}
+	cmd.Printf("\n")
+}
+
+func completeProjectionNames(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		client, closeClient, err := newAdminClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		defer closeClient()
+
+		response, err := client.ListProjections(cmd.Context(), &emptypb.Empty{})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		names := make([]string, len(response.GetProjections()))
+		for i, status := range response.GetProjections() {
+			names[i] = status.GetName()
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	adminCmd.AddCommand(projectionsCmd)
+
+	addAdminAPIFlags(projectionsCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// projectionsCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// projectionsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
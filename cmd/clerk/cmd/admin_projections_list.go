@@ -0,0 +1,49 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// projectionsListCmd represents the projections list command
+var projectionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the projections",
+	Run: func(cmd *cobra.Command, _ []string) {
+		client, closeClient, err := newAdminClient(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer closeClient()
+
+		response, err := client.ListProjections(cmd.Context(), &emptypb.Empty{})
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		for _, status := range response.GetProjections() {
+			cmd.Printf("%s (version %d): %s\n", status.GetName(), status.GetVersion(), status.GetState())
+		}
+	},
+}
+
+func init() {
+	projectionsCmd.AddCommand(projectionsListCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// projectionsListCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// projectionsListCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+// projectionsResetCmd represents the projections reset command
+var projectionsResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Discards the state of a projection and replays it from the beginning of the event store",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient, err := newAdminClient(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer closeClient()
+
+		status, err := client.ResetProjection(cmd.Context(), &proto.ResetProjectionRequest{Name: args[0]})
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		cmd.Printf("Reset projection: %s\n", status.GetName())
+		printProjectionStatus(cmd, status)
+	},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectionNames,
+}
+
+func init() {
+	projectionsCmd.AddCommand(projectionsResetCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// projectionsResetCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// projectionsResetCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+// projectionsRestartCmd represents the projections restart command
+var projectionsRestartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Resumes a halted projection from the last event it processed, once the cause of the failure is fixed",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient, err := newAdminClient(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer closeClient()
+
+		status, err := client.RestartProjection(cmd.Context(), &proto.RestartProjectionRequest{Name: args[0]})
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		cmd.Printf("Restarted projection: %s\n", status.GetName())
+		printProjectionStatus(cmd, status)
+	},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectionNames,
+}
+
+func init() {
+	projectionsCmd.AddCommand(projectionsRestartCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// projectionsRestartCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// projectionsRestartCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
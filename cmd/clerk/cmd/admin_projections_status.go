@@ -0,0 +1,62 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+// projectionsStatusCmd represents the projections status command
+var projectionsStatusCmd = &cobra.Command{
+	Use:   "status [name]",
+	Short: "Shows the status of the projections, or of the given one",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient, err := newAdminClient(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		defer closeClient()
+
+		if len(args) == 0 {
+			response, err := client.ListProjections(cmd.Context(), &emptypb.Empty{})
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			for _, status := range response.GetProjections() {
+				printProjectionStatus(cmd, status)
+			}
+			return
+		}
+
+		status, err := client.GetProjectionStatus(cmd.Context(), &proto.GetProjectionStatusRequest{Name: args[0]})
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		printProjectionStatus(cmd, status)
+	},
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeProjectionNames,
+}
+
+func init() {
+	projectionsCmd.AddCommand(projectionsStatusCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// projectionsStatusCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// projectionsStatusCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/internal/factory"
+)
+
+// reencodeCmd represents the admin reencode command
+var reencodeCmd = &cobra.Command{
+	Use:   "reencode <target>",
+	Short: "Copies the event store to a new database re-serializing the events",
+	Long: `Copies every event of the event store to a new, empty sqlite database, re-serialized
+with another format, keeping their IDs, versions and order. The copy is verified by comparing
+the aggregates rehydrated from both databases. The server must be stopped while it runs.
+
+Example:
+  clerk admin reencode file:///tmp/mybankdb-msgpack.sqlite --format msgpack`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+
+		result, err := factory.NewFactory().ReencodeStore(cmd.Context(), args[0], format)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		cmd.Printf("Re-encoded events: %d\n", result.Events)
+		cmd.Printf("Verified aggregates: %d\n", result.VerifiedAggregates)
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	adminCmd.AddCommand(reencodeCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// reencodeCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	reencodeCmd.Flags().String("format", "json", "Format of the re-encoded events: json, msgpack, protobuf or gob")
+}
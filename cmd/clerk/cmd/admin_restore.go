@@ -0,0 +1,61 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+// restoreCmd represents the admin restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup> <target>",
+	Short: "Rebuilds the event store of a backup in a new database, up to a position or time",
+	Long: `Copies the events of a backup file taken by clerkd to a new, empty sqlite database,
+from the beginning of the log up to the given number of events or up to the first event that
+happened after the given time. Without any of them the whole backup is restored.
+
+Example:
+  clerk admin restore /tmp/mybankdb-backups/backup-20240101T000000.000000000Z.sqlite file:///tmp/restored.sqlite --until 2024-01-01T00:00:00Z`,
+	Run: func(cmd *cobra.Command, args []string) {
+		position, _ := cmd.Flags().GetInt("position")
+		point := persistence.RestorePoint{Position: position}
+		if until, _ := cmd.Flags().GetString("until"); until != "" {
+			var err error
+			point.Until, err = time.Parse(time.RFC3339, until)
+			if err != nil {
+				cmd.PrintErrln("--until must be an RFC 3339 time:", err)
+				os.Exit(1)
+			}
+		}
+
+		restored, err := factory.NewFactory().RestoreStore(cmd.Context(), args[0], args[1], point)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		cmd.Printf("Restored events: %d\n", restored)
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+func init() {
+	adminCmd.AddCommand(restoreCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// restoreCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	restoreCmd.Flags().Int("position", 0, "Restore only this number of events from the beginning of the log")
+	restoreCmd.Flags().String("until", "", "Restore only the events that happened up to this RFC 3339 time")
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/internal/factory"
+)
+
+// verifyCmd represents the admin verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies that no event has been modified, removed or reordered",
+	Long: `Walks the event log recomputing the hash that links every event to the previous one
+in its stream and in the whole log, and reports the first broken link.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		verification, err := factory.NewFactory().NewChainVerifier().VerifyChain(cmd.Context())
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		cmd.Printf("Verified events: %d\n", verification.VerifiedEvents)
+		if verification.UnchainedEvents > 0 {
+			cmd.Printf("Unchained events: %d\n", verification.UnchainedEvents)
+		}
+		if verification.BrokenLink != nil {
+			cmd.PrintErrf("Broken link at event %s (stream %s, version %d): %s\n",
+				verification.BrokenLink.EventID,
+				verification.BrokenLink.ID.StreamName,
+				verification.BrokenLink.ID.StreamVersion,
+				verification.BrokenLink.Reason,
+			)
+			os.Exit(1)
+		}
+		cmd.Println("The event log is intact")
+	},
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	adminCmd.AddCommand(verifyCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// verifyCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// verifyCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
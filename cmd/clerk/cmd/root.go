@@ -7,6 +7,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -14,6 +16,10 @@ var rootCmd = &cobra.Command{
 	Use:   "clerk",
 	Short: "Contact with the banker",
 	Long:  `Little contact with the banker :)`,
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		tenant, _ := cmd.Flags().GetString("tenant")
+		cmd.SetContext(domain.WithTenant(cmd.Context(), domain.TenantID(tenant)))
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -34,6 +40,7 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.clerk.yaml)")
+	rootCmd.PersistentFlags().String("tenant", string(domain.DefaultTenant), "Tenant of the accounts and transfers, the default tenant if empty")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
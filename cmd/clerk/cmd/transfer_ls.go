@@ -0,0 +1,99 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/account"
+)
+
+// transferListCmd represents the transfer ls command
+var transferListCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists the transfers",
+	Run: func(cmd *cobra.Command, _ []string) {
+		filter, err := transferFilterFromFlags(cmd)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		transfers, err := factory.NewFactory().NewTransferProjection(cmd.Context()).For(cmd.Context()).Transfers(cmd.Context(), filter)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		for _, transfer := range transfers {
+			printTransfer(cmd, transfer)
+		}
+	},
+}
+
+func transferFilterFromFlags(cmd *cobra.Command) (account.TransferFilter, error) {
+	accountID, _ := cmd.Flags().GetString("account")
+	status, _ := cmd.Flags().GetString("status")
+	filter := account.TransferFilter{
+		AccountID: accountID,
+		Status:    account.TransferStatus(status),
+	}
+
+	var err error
+	from, _ := cmd.Flags().GetString("from")
+	if filter.RequestedFrom, err = parseDate(from); err != nil {
+		return account.TransferFilter{}, fmt.Errorf("invalid --from date: %w", err)
+	}
+	to, _ := cmd.Flags().GetString("to")
+	if filter.RequestedTo, err = parseDate(to); err != nil {
+		return account.TransferFilter{}, fmt.Errorf("invalid --to date: %w", err)
+	}
+	return filter, nil
+}
+
+// parseDate parses a date in RFC 3339 or YYYY-MM-DD format in the local timezone, an empty date is the zero time.
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, date); err == nil {
+		return parsed, nil
+	}
+	// nolint:gosmopolitan // Since this is the presentation layer, we want to parse it in the local timezone for the user.
+	return time.ParseInLocation(time.DateOnly, date, time.Local)
+}
+
+func printTransfer(cmd *cobra.Command, transfer account.ProjectedTransfer) {
+	cmd.Printf("Transfer ID: %s\nFrom: %s\nTo: %s\nAmount: %d\nStatus: %s\n", transfer.TransferID, transfer.FromAccount, transfer.ToAccount, transfer.Amount, transfer.Status)
+	if transfer.FailureReason != "" {
+		cmd.Printf("Failure reason: %s\n", transfer.FailureReason)
+	}
+	printDate(cmd, "Requested on", transfer.RequestedOn)
+	printDate(cmd, "Sent on", transfer.SentOn)
+	printDate(cmd, "Received on", transfer.ReceivedOn)
+	printDate(cmd, "Completed on", transfer.CompletedOn)
+	printDate(cmd, "Rolled back on", transfer.RolledBackOn)
+	cmd.Printf("\n")
+}
+
+func init() {
+	transferCmd.AddCommand(transferListCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// transferListCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	transferListCmd.Flags().String("account", "", "Only the transfers sent or received by this account")
+	transferListCmd.Flags().String("status", "", "Only the transfers in this status: requested, sent, received, completed or rolled-back")
+	transferListCmd.Flags().String("from", "", "Only the transfers requested from this date, in RFC 3339 or YYYY-MM-DD format")
+	transferListCmd.Flags().String("to", "", "Only the transfers requested before this date, in RFC 3339 or YYYY-MM-DD format")
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,7 +13,10 @@ import (
 	"sync"
 	"time"
 
+	"google.golang.org/grpc"
+
 	"github.com/tembleking/myBankSourcing/internal/factory"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
 func main() {
@@ -19,30 +24,134 @@ func main() {
 }
 
 func run() {
+	httpAddress := flag.String("http-address", ":8080", "address to serve HTTP on")
+	grpcAddress := flag.String("grpc-address", ":8081", "address to serve GRPC on")
+	adminGRPCAddress := flag.String("admin-grpc-address", ":8082", "address to serve the admin and replication GRPC services on")
+	databaseFile := flag.String("database", "/tmp/mybankdb.sqlite", "sqlite database of the event store")
+	keyDatabaseFile := flag.String("key-database", "/tmp/mybankkeys.sqlite", "sqlite database of the keys of the personal data, apart from the event store")
+	leaderAddress := flag.String("leader", "", "admin GRPC address of the leader to replicate as a read-only follower")
+	leaderAPIKey := flag.String("leader-api-key", "", "admin API key to replicate the leader with")
+	apiKeysFile := flag.String("api-keys", "", "JSON file with the tenant of every API key, the API is served without authentication if empty")
+	adminAPIKeysFile := flag.String("admin-api-keys", "", "JSON file with the list of admin API keys, required with -api-keys")
+	flag.Parse()
+
+	tenantCredentials, err := loadTenantCredentials(*apiKeysFile)
+	if err != nil {
+		fmt.Println("error loading the API keys:", err)
+		os.Exit(1)
+	}
+	adminAPIKeys, err := loadAdminAPIKeys(*adminAPIKeysFile)
+	if err != nil {
+		fmt.Println("error loading the admin API keys:", err)
+		os.Exit(1)
+	}
+	if len(tenantCredentials) > 0 && len(adminAPIKeys) == 0 {
+		fmt.Println("the admin API keys are required with the API keys, or the data of every tenant would be served without authentication")
+		os.Exit(1)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	defer cancel()
 	wg := &sync.WaitGroup{}
-	factory := factory.NewFactory()
+	factory := factory.NewFactory().
+		WithDatabaseFile(*databaseFile).
+		WithKeyDatabaseFile(*keyDatabaseFile).
+		WithLeader(*leaderAddress).
+		WithTenantCredentials(tenantCredentials).
+		WithAdminAPIKeys(adminAPIKeys).
+		WithLeaderAPIKey(*leaderAPIKey)
+
+	wg.Add(1)
+	go serveHTTP(ctx, wg, factory, *httpAddress)
 
 	wg.Add(1)
-	go serveHTTP(ctx, wg, factory)
+	go serveGRPC(ctx, wg, factory.NewGRPCServer(ctx), *grpcAddress)
 
 	wg.Add(1)
-	go serveGRPC(ctx, wg, factory)
+	go serveGRPC(ctx, wg, factory.NewAdminGRPCServer(ctx), *adminGRPCAddress)
+
+	// A follower only replicates the leader, which relays the events and takes the backups of the event log.
+	if *leaderAddress != "" {
+		wg.Add(1)
+		go followLeader(ctx, wg, factory)
+	} else {
+		wg.Add(1)
+		go relayOutbox(ctx, wg, factory)
+
+		wg.Add(1)
+		go takeBackups(ctx, wg, factory)
+	}
 
 	wg.Wait()
 }
 
-func serveGRPC(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory) {
+// loadTenantCredentials reads the tenant of every API key from a JSON object with the keys as names.
+func loadTenantCredentials(path string) (map[string]domain.TenantID, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	credentials := map[string]domain.TenantID{}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %w", path, err)
+	}
+	return credentials, nil
+}
+
+// loadAdminAPIKeys reads the admin API keys from a JSON array.
+func loadAdminAPIKeys(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var apiKeys []string
+	if err := json.Unmarshal(data, &apiKeys); err != nil {
+		return nil, fmt.Errorf("invalid admin API keys file %s: %w", path, err)
+	}
+	return apiKeys, nil
+}
+
+func takeBackups(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory) {
+	defer wg.Done()
+
+	fmt.Println("taking scheduled backups of the event store")
+	factory.NewBackups().Run(ctx)
+	fmt.Println("stopped taking scheduled backups")
+}
+
+func followLeader(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory) {
+	defer wg.Done()
+
+	fmt.Println("replicating the event store of the leader", factory.NewFollower().LeaderAddress())
+	factory.NewFollower().Run(ctx)
+	fmt.Println("stopped replicating the event store of the leader")
+}
+
+func relayOutbox(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory) {
+	defer wg.Done()
+
+	fmt.Println("relaying outbox events to the event bus")
+	factory.NewOutboxRelay().Run(ctx)
+	fmt.Println("stopped relaying outbox events")
+}
+
+func serveGRPC(ctx context.Context, wg *sync.WaitGroup, server *grpc.Server, address string) {
 	defer wg.Done()
 
-	server := factory.NewGRPCServer(ctx)
-	listener, err := net.Listen("tcp", ":8081") // #nosec G102 -- We actually want to bind to all interfaces
+	listener, err := net.Listen("tcp", address) // #nosec G102 -- We actually want to bind to all interfaces
 	if err != nil {
-		panic(fmt.Errorf("error listening GRPC on port 8081: %w", err))
+		panic(fmt.Errorf("error listening GRPC on %s: %w", address, err))
 	}
 	defer listener.Close()
-	fmt.Println("grpc listening on port 8081")
+	fmt.Println("grpc listening on", address)
 
 	go func() {
 		<-ctx.Done()
@@ -56,7 +165,7 @@ func serveGRPC(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory
 	}
 }
 
-func serveHTTP(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory) {
+func serveHTTP(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory, address string) {
 	defer wg.Done()
 
 	server := &http.Server{
@@ -64,13 +173,13 @@ func serveHTTP(ctx context.Context, wg *sync.WaitGroup, factory *factory.Factory
 		ReadHeaderTimeout: 30 * time.Second,
 	}
 
-	listener, err := net.Listen("tcp", ":8080") // #nosec G102 -- We actually want to bind to all interfaces
+	listener, err := net.Listen("tcp", address) // #nosec G102 -- We actually want to bind to all interfaces
 	if err != nil {
-		panic(fmt.Errorf("error listening HTTP on port 8080: %w", err))
+		panic(fmt.Errorf("error listening HTTP on %s: %w", address, err))
 	}
 	defer listener.Close()
 
-	fmt.Println("http listening on port 8080")
+	fmt.Println("http listening on", address)
 	go func() {
 		<-ctx.Done()
 		fmt.Println("shutting down HTTP server")
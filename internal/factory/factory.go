@@ -2,23 +2,28 @@ package factory
 
 import (
 	"context"
+	"fmt"
 	gohttp "net/http"
+	"os"
+	"strings"
 	"time"
 
-	"github.com/tembleking/myBankSourcing/pkg/account"
-	"github.com/tembleking/myBankSourcing/pkg/transfer"
-
 	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/tembleking/myBankSourcing/internal/lazy"
+	"github.com/tembleking/myBankSourcing/pkg/account"
 	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
 	"github.com/tembleking/myBankSourcing/pkg/application/http"
 	pb "github.com/tembleking/myBankSourcing/pkg/application/proto"
 	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/eventbus"
 	"github.com/tembleking/myBankSourcing/pkg/persistence"
 	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
 	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
 )
 
 type Factory struct {
@@ -27,18 +32,77 @@ type Factory struct {
 	appendOnlyStoreField    lazy.Lazy[persistence.AppendOnlyStore]
 	httpHandlerField        lazy.Lazy[gohttp.Handler]
 	grpcServerField         lazy.Lazy[*gogrpc.Server]
-	accountProjectionField  lazy.Lazy[*account.Projection]
+	adminGRPCServerField    lazy.Lazy[*gogrpc.Server]
+	accountProjectionField  lazy.Lazy[*projection.PerTenant[*account.Projection]]
+	transferProjectionField lazy.Lazy[*projection.PerTenant[*account.TransferProjection]]
 	accountRepositoryField  lazy.Lazy[domain.Repository[*account.Account]]
 	transferRepositoryField lazy.Lazy[domain.Repository[*transfer.Transfer]]
+	sqliteInstanceField     lazy.Lazy[*sqlite.AppendOnlyStore]
+	keyStoreField           lazy.Lazy[persistence.KeyStore]
+	eventRegistryField      lazy.Lazy[*serializer.EventRegistry]
+	eventBusField           lazy.Lazy[domain.EventBus]
+	outboxRelayField        lazy.Lazy[*sqlite.OutboxRelay]
+	projectionRuntimeField  lazy.Lazy[*projection.Runtime]
+	backupsField            lazy.Lazy[*sqlite.Backups]
+	followerField           lazy.Lazy[*grpc.Follower]
+
+	databaseFile      string
+	keyDatabaseFile   string
+	leaderAddress     string
+	tenantCredentials map[string]domain.TenantID
+	adminAPIKeys      []string
+	leaderAPIKey      string
 }
 
 func NewFactory() *Factory {
-	return &Factory{}
+	return &Factory{
+		databaseFile:    "/tmp/mybankdb.sqlite",
+		keyDatabaseFile: "/tmp/mybankkeys.sqlite",
+	}
+}
+
+// WithDatabaseFile sets the sqlite database of the append only store, next to which the backups are kept.
+func (f *Factory) WithDatabaseFile(databaseFile string) *Factory {
+	f.databaseFile = databaseFile
+	return f
+}
+
+// WithKeyDatabaseFile sets the sqlite database of the keys of the personal data.
+// It must not be the database of the events, so the backups of the events do not contain the keys.
+func (f *Factory) WithKeyDatabaseFile(keyDatabaseFile string) *Factory {
+	f.keyDatabaseFile = keyDatabaseFile
+	return f
+}
+
+// WithLeader makes this a follower replica of the leader listening for gRPC at the given address.
+func (f *Factory) WithLeader(leaderAddress string) *Factory {
+	f.leaderAddress = leaderAddress
+	return f
+}
+
+// WithTenantCredentials sets the tenants of the API keys accepted by the ClerkAPIService.
+// Without credentials, the API is served without authentication in the DefaultTenant.
+func (f *Factory) WithTenantCredentials(credentials map[string]domain.TenantID) *Factory {
+	f.tenantCredentials = credentials
+	return f
+}
+
+// WithAdminAPIKeys sets the API keys accepted by the ClerkAdminAPIService and the ClerkReplicationService.
+// Without keys, these services are served without authentication.
+func (f *Factory) WithAdminAPIKeys(apiKeys []string) *Factory {
+	f.adminAPIKeys = apiKeys
+	return f
+}
+
+// WithLeaderAPIKey sets the admin API key a follower replica streams the event log of the leader with.
+func (f *Factory) WithLeaderAPIKey(apiKey string) *Factory {
+	f.leaderAPIKey = apiKey
+	return f
 }
 
 func (f *Factory) NewAccountService() *account.Service {
 	return f.accountServiceField.GetOrInit(func() *account.Service {
-		return account.NewAccountService(f.accountRepository(), f.transferRepository())
+		return account.NewAccountService(f.accountRepository(), f.transferRepository(), f.eventStore())
 	})
 }
 
@@ -54,26 +118,202 @@ func (f *Factory) transferRepository() domain.Repository[*transfer.Transfer] {
 	})
 }
 
-func (f *Factory) NewAccountProjection(ctx context.Context) *account.Projection {
-	return f.accountProjectionField.GetOrInit(func() *account.Projection {
-		accountProjection, err := account.NewAccountProjection(ctx, f.eventStore().ReadOnlyEventStore, time.Second)
+func (f *Factory) NewAccountProjection(ctx context.Context) *projection.PerTenant[*account.Projection] {
+	f.NewProjectionRuntime(ctx)
+	return f.accountProjection()
+}
+
+func (f *Factory) accountProjection() *projection.PerTenant[*account.Projection] {
+	return f.accountProjectionField.GetOrInit(func() *projection.PerTenant[*account.Projection] {
+		return projection.NewPerTenant(account.NewProjection)
+	})
+}
+
+func (f *Factory) NewTransferProjection(ctx context.Context) *projection.PerTenant[*account.TransferProjection] {
+	f.NewProjectionRuntime(ctx)
+	return f.transferProjection()
+}
+
+func (f *Factory) transferProjection() *projection.PerTenant[*account.TransferProjection] {
+	return f.transferProjectionField.GetOrInit(func() *projection.PerTenant[*account.TransferProjection] {
+		return projection.NewPerTenant(func() *account.TransferProjection {
+			return account.NewTransferProjection().WithPersonalDataCipher(persistence.NewPersonalDataCipher(f.keyStore()))
+		})
+	})
+}
+
+// NewProjectionRuntime returns the runtime with all the projections registered, already caught up with the event store.
+func (f *Factory) NewProjectionRuntime(ctx context.Context) *projection.Runtime {
+	return f.projectionRuntimeField.GetOrInit(func() *projection.Runtime {
+		runtime := projection.NewRuntime(f.eventStore().ReadOnlyEventStore, f.projectionStore())
+
+		err := runtime.Register(f.accountProjection(), projection.ErrorPolicyHalt)
+		if err != nil {
+			panic(err)
+		}
+
+		err = runtime.Register(f.transferProjection(), projection.ErrorPolicyHalt)
+		if err != nil {
+			panic(err)
+		}
+
+		err = runtime.Start(ctx)
 		if err != nil {
 			panic(err)
 		}
-		return accountProjection
+		return runtime
 	})
 }
 
 func (f *Factory) eventStore() *persistence.EventStore {
 	return f.eventStoreField.GetOrInit(func() *persistence.EventStore {
-		eventSerializer := &serializer.JSON{}
+		eventSerializer := f.eventSerializer()
 		return persistence.NewEventStoreBuilder(f.appendOnlyStore()).
 			WithSerializer(eventSerializer).
-			WithDeserializer(eventSerializer).
+			WithDeserializerRegistry(f.deserializerRegistry()).
 			Build()
 	})
 }
 
+func (f *Factory) projectionStore() persistence.ProjectionStore {
+	return sqlite.NewProjectionStore(f.sqliteInstance())
+}
+
+func (f *Factory) eventSerializer() *persistence.CryptoShreddingSerializer {
+	return f.cryptoSerializers()["json"]
+}
+
+// cryptoSerializers returns the serializers of the events by format, all of them encrypting the personal data.
+func (f *Factory) cryptoSerializers() map[string]*persistence.CryptoShreddingSerializer {
+	serializers := map[string]interface {
+		persistence.DomainEventSerializer
+		persistence.DomainEventDeserializer
+	}{
+		"json":     serializer.NewJSON(f.eventRegistry()),
+		"msgpack":  serializer.NewMsgpack(f.eventRegistry()),
+		"protobuf": serializer.NewProtobuf(f.eventRegistry()),
+		"gob":      serializer.NewGoBinarySerializer(f.eventRegistry()),
+	}
+
+	cryptoSerializers := make(map[string]*persistence.CryptoShreddingSerializer, len(serializers))
+	for format, eventSerializer := range serializers {
+		cryptoSerializers[format] = persistence.NewCryptoShreddingSerializer(eventSerializer, eventSerializer, f.keyStore())
+	}
+	return cryptoSerializers
+}
+
+// deserializerRegistry deserializes the events stored with any of the serializers, decrypting their personal data.
+func (f *Factory) deserializerRegistry() *persistence.DeserializerRegistry {
+	registry := persistence.NewDeserializerRegistryForEvents(f.eventRegistry()).WithDefaultDeserializer(f.eventSerializer())
+	for _, cryptoSerializer := range f.cryptoSerializers() {
+		registry.Register(cryptoSerializer.ContentType(), cryptoSerializer)
+	}
+	return registry
+}
+
+func (f *Factory) eventRegistry() *serializer.EventRegistry {
+	return f.eventRegistryField.GetOrInit(func() *serializer.EventRegistry {
+		registry := serializer.NewEventRegistry()
+		account.RegisterEvents(registry)
+		transfer.RegisterEvents(registry)
+		return registry
+	})
+}
+
+// keyStore keeps the keys of the personal data in a different database than the events,
+// so the keys are not in the copies of the events.
+func (f *Factory) keyStore() persistence.KeyStore {
+	return f.keyStoreField.GetOrInit(func() persistence.KeyStore {
+		keyStore, err := sqlite.OpenKeyStore("file://" + f.keyDatabaseFile)
+		if err != nil {
+			panic(err)
+		}
+		return keyStore
+	})
+}
+
+// NewEventBus returns the bus the outbox relay publishes the events to,
+// with the processes that move the transfers forward subscribed to it.
+func (f *Factory) NewEventBus() domain.EventBus {
+	return f.eventBusField.GetOrInit(func() domain.EventBus {
+		eventBus := eventbus.NewInMemory()
+		err := eventBus.Subscribe(context.Background(), account.NewTransferProcess(f.NewAccountService()))
+		if err != nil {
+			panic(err)
+		}
+		return eventBus
+	})
+}
+
+func (f *Factory) NewOutboxRelay() *sqlite.OutboxRelay {
+	return f.outboxRelayField.GetOrInit(func() *sqlite.OutboxRelay {
+		return sqlite.NewOutboxRelay(f.sqliteInstance(), f.deserializerRegistry(), f.NewEventBus())
+	})
+}
+
+// NewFollower returns the replication of the event store of the leader, or nil if this is the leader.
+func (f *Factory) NewFollower() *grpc.Follower {
+	if f.leaderAddress == "" {
+		return nil
+	}
+	return f.followerField.GetOrInit(func() *grpc.Follower {
+		conn, err := gogrpc.NewClient(f.leaderAddress,
+			gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+			gogrpc.WithPerRPCCredentials(grpc.BearerToken(f.leaderAPIKey)),
+		)
+		if err != nil {
+			panic(err)
+		}
+		// The leader already relays its events, so the replicated ones are not relayed again.
+		return grpc.NewFollower(f.leaderAddress, pb.NewClerkReplicationServiceClient(conn), f.sqliteInstance().WithoutOutbox())
+	})
+}
+
+// NewBackups returns the backups of the append only store, taken every hour and kept for a day.
+func (f *Factory) NewBackups() *sqlite.Backups {
+	return f.backupsField.GetOrInit(func() *sqlite.Backups {
+		return sqlite.NewBackups(f.sqliteInstance(), strings.TrimSuffix(f.databaseFile, ".sqlite")+"-backups").
+			WithInterval(time.Hour).
+			WithRetention(24)
+	})
+}
+
+// RestoreStore rebuilds in a new sqlite database in target the event store of the backup,
+// up to the restore point.
+// Only the events still pending in the outbox of the backup are kept pending in the new database.
+func (f *Factory) RestoreStore(ctx context.Context, backup string, target string, point persistence.RestorePoint) (int, error) {
+	if _, err := os.Stat(backup); err != nil {
+		return 0, fmt.Errorf("unable to read the backup: %w", err)
+	}
+	backupStore, err := sqlite.New("file:" + backup + "?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer backupStore.Close()
+
+	targetStore, err := sqlite.New(target)
+	if err != nil {
+		return 0, err
+	}
+	defer targetStore.Close()
+
+	err = targetStore.MigrateDB()
+	if err != nil {
+		return 0, err
+	}
+
+	restored, err := persistence.Restore(ctx, backupStore, targetStore, point)
+	if err != nil {
+		return restored, err
+	}
+	return restored, targetStore.ReplaceOutboxWith(ctx, backupStore)
+}
+
+// NewChainVerifier returns the verifier of the hash chain of the events in the append only store.
+func (f *Factory) NewChainVerifier() persistence.ChainVerifier {
+	return f.sqliteInstance()
+}
+
 func (f *Factory) appendOnlyStore() persistence.AppendOnlyStore {
 	return f.appendOnlyStoreField.GetOrInit(func() persistence.AppendOnlyStore {
 		return f.sqliteInstance()
@@ -81,32 +321,118 @@ func (f *Factory) appendOnlyStore() persistence.AppendOnlyStore {
 }
 
 func (f *Factory) sqliteInstance() *sqlite.AppendOnlyStore {
-	appendOnlyStore, err := sqlite.New("file:///tmp/mybankdb.sqlite")
+	return f.sqliteInstanceField.GetOrInit(func() *sqlite.AppendOnlyStore {
+		appendOnlyStore, err := sqlite.New("file://" + f.databaseFile)
+		if err != nil {
+			panic(err)
+		}
+
+		err = appendOnlyStore.MigrateDB()
+		if err != nil {
+			panic(err)
+		}
+
+		return appendOnlyStore
+	})
+}
+
+// ReencodeStore copies the events to a new sqlite database in target, re-serialized in the given format,
+// which is one of json, msgpack, protobuf or gob.
+// Only the events still pending in the outbox are kept pending in the new database.
+func (f *Factory) ReencodeStore(ctx context.Context, target string, format string) (persistence.ReencodeResult, error) {
+	targetSerializer, ok := f.cryptoSerializers()[format]
+	if !ok {
+		return persistence.ReencodeResult{}, fmt.Errorf("unknown format '%s'", format)
+	}
+
+	targetStore, err := sqlite.New(target)
+	if err != nil {
+		return persistence.ReencodeResult{}, err
+	}
+	defer targetStore.Close()
+
+	err = targetStore.MigrateDB()
 	if err != nil {
-		panic(err)
+		return persistence.ReencodeResult{}, err
 	}
 
-	err = appendOnlyStore.MigrateDB()
+	result, err := persistence.NewReencoder(f.sqliteInstance(), targetStore, targetSerializer, newAggregateFor).
+		WithDeserializerRegistry(f.deserializerRegistry()).
+		Run(ctx)
 	if err != nil {
-		panic(err)
+		return result, err
 	}
 
-	return appendOnlyStore
+	return result, targetStore.ReplaceOutboxWith(ctx, f.sqliteInstance())
+}
+
+// NewExporter returns an exporter of the event store.
+// The personal data is exported encrypted, so it can still be shredded after exporting it.
+func (f *Factory) NewExporter() *persistence.Exporter {
+	return persistence.NewExporter(f.sqliteInstance()).WithEventRegistry(f.eventRegistry())
+}
+
+// NewImporter returns an importer to the event store of the events exported by NewExporter.
+func (f *Factory) NewImporter() *persistence.Importer {
+	return persistence.NewImporter(f.appendOnlyStore()).
+		WithEventRegistry(f.eventRegistry()).
+		WithSerializer(serializer.NewJSON(f.eventRegistry()))
+}
+
+// newAggregateFor returns the aggregate of the streams starting with the event.
+func newAggregateFor(firstEvent domain.Event) (domain.Aggregate, error) {
+	switch firstEvent.(type) {
+	case *account.AccountOpened:
+		return account.NewAccount(), nil
+	case *transfer.TransferRequested:
+		return transfer.NewTransfer(), nil
+	default:
+		return nil, fmt.Errorf("no aggregate starts with the event %s", firstEvent.EventName())
+	}
 }
 
 func (f *Factory) NewHTTPHandler(ctx context.Context) gohttp.Handler {
 	return f.httpHandlerField.GetOrInit(func() gohttp.Handler {
-		return http.NewHTTPServer(ctx, f.NewAccountService(), f.NewAccountProjection(ctx))
+		return http.NewHTTPServer(ctx, f.NewAccountService(), f.NewAccountProjection(ctx), f.NewTransferProjection(ctx), f.NewFollower(), f.newTenantResolver())
 	})
 }
 
+// NewGRPCServer returns the server of the public ClerkAPIService, authenticated with the API keys of the tenants.
 func (f *Factory) NewGRPCServer(ctx context.Context) *gogrpc.Server {
 	return f.grpcServerField.GetOrInit(func() *gogrpc.Server {
-		accountGRPCServer := grpc.NewAccountGRPCServer(f.NewAccountService(), f.NewAccountProjection(ctx))
-		grpcServer := gogrpc.NewServer()
+		accountGRPCServer := grpc.NewAccountGRPCServer(f.NewAccountService(), f.NewAccountProjection(ctx), f.NewTransferProjection(ctx))
+		if f.leaderAddress != "" {
+			accountGRPCServer.WithLeaderAddress(f.leaderAddress)
+		}
+		grpcServer := gogrpc.NewServer(gogrpc.UnaryInterceptor(f.newTenantResolver().UnaryInterceptor()))
 		reflection.Register(grpcServer)
 
 		pb.RegisterClerkAPIServiceServer(grpcServer, accountGRPCServer)
 		return grpcServer
 	})
 }
+
+// NewAdminGRPCServer returns the server of the ClerkAdminAPIService and the ClerkReplicationService,
+// which work on the data of every tenant, so they are served on their own listener with the admin API keys.
+func (f *Factory) NewAdminGRPCServer(ctx context.Context) *gogrpc.Server {
+	return f.adminGRPCServerField.GetOrInit(func() *gogrpc.Server {
+		adminAuthenticator := f.newAdminAuthenticator()
+		grpcServer := gogrpc.NewServer(
+			gogrpc.UnaryInterceptor(adminAuthenticator.UnaryInterceptor()),
+			gogrpc.StreamInterceptor(adminAuthenticator.StreamInterceptor()),
+		)
+		reflection.Register(grpcServer)
+
+		pb.RegisterClerkAdminAPIServiceServer(grpcServer, grpc.NewAdminGRPCServer(f.NewProjectionRuntime(ctx), f.NewChainVerifier(), f.NewBackups(), f.keyStore(), f.NewFollower()))
+		pb.RegisterClerkReplicationServiceServer(grpcServer, grpc.NewReplicationGRPCServer(f.sqliteInstance()))
+		return grpcServer
+	})
+}
+
+func (f *Factory) newTenantResolver() *grpc.TenantResolver {
+	return grpc.NewTenantResolver(f.tenantCredentials)
+}
+
+func (f *Factory) newAdminAuthenticator() *grpc.AdminAuthenticator {
+	return grpc.NewAdminAuthenticator(f.adminAPIKeys)
+}
@@ -175,7 +175,8 @@ func (a *Account) ReceiveTransfer(transfer *transfer.Transfer) error {
 	return nil
 }
 
-func (a *Account) RollbackSentTransfer(transfer *transfer.Transfer) error {
+// RollbackSentTransfer returns the money of a sent transfer that could not be received, for the given reason.
+func (a *Account) RollbackSentTransfer(transfer *transfer.Transfer, reason string) error {
 	if !a.IsOpen() {
 		return ErrAccountIsClosed
 	}
@@ -194,6 +195,7 @@ func (a *Account) RollbackSentTransfer(transfer *transfer.Transfer) error {
 		AccountID:          a.ID(),
 		AccountOrigin:      transfer.FromAccount(),
 		AccountDestination: transfer.ToAccount(),
+		Reason:             reason,
 		Amount:             transfer.Amount(),
 		AccountVersion:     a.NextVersion(),
 		Timestamp:          a.Now(),
@@ -261,14 +261,14 @@ var _ = Describe("Account", func() {
 				It("rolls back the transaction", func() {
 					Expect(origin.SendTransfer(transfer)).To(Succeed())
 
-					err := origin.RollbackSentTransfer(transfer)
+					err := origin.RollbackSentTransfer(transfer, "destination account is closed")
 					Expect(err).ToNot(HaveOccurred())
 					Expect(origin.Balance()).To(Equal(100))
 				})
 
 				It("can be closed again", func() {
 					Expect(origin.SendTransfer(transfer)).To(Succeed())
-					Expect(origin.RollbackSentTransfer(transfer)).To(Succeed())
+					Expect(origin.RollbackSentTransfer(transfer, "destination account is closed")).To(Succeed())
 					Expect(origin.WithdrawMoney(origin.Balance())).To(Succeed())
 
 					err := origin.CloseAccount()
@@ -277,7 +277,7 @@ var _ = Describe("Account", func() {
 
 				When("the account was not sent previously", func() {
 					It("fails, and doesn't roll back anything", func() {
-						err := origin.RollbackSentTransfer(transfer)
+						err := origin.RollbackSentTransfer(transfer, "destination account is closed")
 						Expect(err).To(MatchError(account.ErrCannotRollbackTransferNotPreviouslySent))
 						Expect(origin.Balance()).To(Equal(100))
 					})
@@ -286,9 +286,9 @@ var _ = Describe("Account", func() {
 				When("the transfer is already rolled back", func() {
 					It("doesn't roll it back again", func() {
 						Expect(origin.SendTransfer(transfer)).To(Succeed())
-						Expect(origin.RollbackSentTransfer(transfer)).To(Succeed())
+						Expect(origin.RollbackSentTransfer(transfer, "destination account is closed")).To(Succeed())
 
-						err := origin.RollbackSentTransfer(transfer)
+						err := origin.RollbackSentTransfer(transfer, "destination account is closed")
 						Expect(err).ToNot(HaveOccurred())
 						Expect(origin.Balance()).To(Equal(100))
 					})
@@ -11,4 +11,5 @@ var (
 	ErrCannotRollbackTransferNotPreviouslySent        = errors.New("cannot rollback transfer that was not previously sent")
 	ErrAccountCannotBeClosedUntilTransfersAreResolved = errors.New("account cannot be closed until transfers are resolved")
 	ErrCannotCompleteTransferNotPreviouslySent        = errors.New("cannot complete transfer that was not previously sent")
+	ErrInvalidMovementsCursor                         = errors.New("invalid movements cursor")
 )
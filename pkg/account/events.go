@@ -8,10 +8,27 @@ import (
 )
 
 func init() {
-	serializer.RegisterSerializableEvent(&AccountOpened{})
-	serializer.RegisterSerializableEvent(&AmountDeposited{})
-	serializer.RegisterSerializableEvent(&AmountWithdrawn{})
-	serializer.RegisterSerializableEvent(&AccountClosed{})
+	for _, event := range serializableEvents() {
+		serializer.RegisterSerializableEvent(event)
+	}
+}
+
+// RegisterEvents registers the events of the account aggregate in the registry, so a store using it can deserialize them.
+func RegisterEvents(registry *serializer.EventRegistry) {
+	registry.Register(serializableEvents()...)
+}
+
+func serializableEvents() []domain.Event {
+	return []domain.Event{
+		&AccountOpened{},
+		&AmountDeposited{},
+		&AmountWithdrawn{},
+		&AccountClosed{},
+		&TransferSent{},
+		&TransferReceived{},
+		&TransferSentRolledBack{},
+		&TransferCompleted{},
+	}
 }
 
 // nolint:revive
@@ -190,6 +207,8 @@ func (t *TransferReceived) Version() uint64 {
 	return t.AccountVersion
 }
 
+// TransferSentRolledBack is stored when the money of a sent transfer is returned to the origin account.
+// The reason may be written by the holder of the account, so it is personal data of the account.
 type TransferSentRolledBack struct {
 	Timestamp          time.Time
 	ID                 domain.EventID
@@ -197,6 +216,7 @@ type TransferSentRolledBack struct {
 	TransferID         string
 	AccountOrigin      string
 	AccountDestination string
+	Reason             string `personal:"AccountID"`
 	Amount             int
 	AccountVersion     uint64
 }
@@ -2,68 +2,356 @@ package account
 
 import (
 	"context"
-	"log/slog"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/tembleking/myBankSourcing/pkg/domain"
-	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
 )
 
+const (
+	// ProjectionName is the name the accounts projection snapshots are saved under.
+	ProjectionName = "accounts"
+	// ProjectionVersion must be increased every time the handling of the events changes,
+	// so the snapshots saved by the previous logic are discarded and the projection is rebuilt.
+	ProjectionVersion = 4
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusOpen   AccountStatus = "open"
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+type MovementType string
+
+const (
+	MovementTypeDeposit            MovementType = "Deposit"
+	MovementTypeWithdrawal         MovementType = "Withdrawal"
+	MovementTypeTransferSent       MovementType = "TransferSent"
+	MovementTypeTransferReceived   MovementType = "TransferReceived"
+	MovementTypeTransferRolledBack MovementType = "TransferRolledBack"
+	MovementTypeTransferCompleted  MovementType = "TransferCompleted"
+)
+
+// ProjectedAccount is an account with all its movements. The closed accounts are kept,
+// so their last statement is still available.
 type ProjectedAccount struct {
+	OpenedOn  time.Time
+	ClosedOn  time.Time
 	AccountID string
+	Status    AccountStatus
 	Movements []ProjectedMovement
 	Balance   int
 }
 
+// ProjectedMovement is a change of an account. The transfer movements also have the ID of the transfer
+// and the account on the other side of it.
 type ProjectedMovement struct {
-	Timestamp        time.Time
-	Type             string
-	Amount           int
-	ResultingBalance int
+	Timestamp           time.Time
+	Type                MovementType
+	TransferID          string
+	CounterpartyAccount string
+	Amount              int
+	ResultingBalance    int
+}
+
+// MovementFilter selects the movements returned by Projection.Movements.
+// The zero value of every field matches all the movements, and the date range applies to their timestamp.
+type MovementFilter struct {
+	From  time.Time
+	To    time.Time
+	Types []MovementType
+}
+
+func (f MovementFilter) matches(movement ProjectedMovement) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, movement.Type) {
+		return false
+	}
+	if !f.From.IsZero() && movement.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !movement.Timestamp.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// MovementsPage is a page of the movements of an account.
+type MovementsPage struct {
+	Movements []ProjectedMovement
+	// NextCursor returns the next page of the movements with the same filter, and is empty in the last page.
+	NextCursor string
 }
 
+// Projection is the read model of the accounts. It implements projection.Projector, projection.Snapshotter
+// and projection.Rebuildable, so it is kept up to date, snapshotted and rebuilt by a projection.Runtime.
 type Projection struct {
 	accounts              map[string]*ProjectedAccount
-	eventStore            *persistence.ReadOnlyEventStore
-	lastProcessedEventID  domain.EventID
 	precalculatedAccounts []ProjectedAccount
+	isDirty               bool
 	mutex                 sync.RWMutex
 }
 
+func NewProjection() *Projection {
+	return &Projection{accounts: map[string]*ProjectedAccount{}}
+}
+
+// Accounts returns all the accounts, both open and closed, sorted by ID.
 func (a *Projection) Accounts() []ProjectedAccount {
 	a.mutex.RLock()
-	defer a.mutex.RUnlock()
+	if !a.isDirty {
+		defer a.mutex.RUnlock()
+		return a.precalculatedAccounts
+	}
+	a.mutex.RUnlock()
 
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	// We duplicate the check because another reader may have precalculated them while we waited for the lock.
+	if a.isDirty {
+		a.precalculateAccounts()
+	}
 	return a.precalculatedAccounts
 }
 
-func (a *Projection) handleEvent(event domain.Event) {
+// AccountsWithStatus returns the accounts with the given status, sorted by ID.
+func (a *Projection) AccountsWithStatus(status AccountStatus) []ProjectedAccount {
+	accounts := []ProjectedAccount{}
+	for _, account := range a.Accounts() {
+		if account.Status == status {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+func (a *Projection) Account(accountID string) (ProjectedAccount, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	account, exists := a.accounts[accountID]
+	if !exists {
+		return ProjectedAccount{}, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	return *account, nil
+}
+
+// Movements returns a page of at most limit movements of the account matching the filter, or all of them if limit is not positive,
+// in the order they happened, starting at the cursor of the previous page, or at the first movement if it is empty.
+// The movements are never removed, so the cursors stay valid while new movements are added to the last page.
+func (a *Projection) Movements(accountID string, filter MovementFilter, cursor string, limit int) (MovementsPage, error) {
+	position, err := decodeMovementsCursor(cursor)
+	if err != nil {
+		return MovementsPage{}, err
+	}
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	account, exists := a.accounts[accountID]
+	if !exists {
+		return MovementsPage{}, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	if position > len(account.Movements) {
+		return MovementsPage{}, fmt.Errorf("%w: position %d is after the last movement", ErrInvalidMovementsCursor, position)
+	}
+
+	page := MovementsPage{Movements: []ProjectedMovement{}}
+	for i, movement := range account.Movements[position:] {
+		if !filter.matches(movement) {
+			continue
+		}
+		if limit > 0 && len(page.Movements) == limit {
+			page.NextCursor = encodeMovementsCursor(position + i)
+			break
+		}
+		page.Movements = append(page.Movements, movement)
+	}
+	return page, nil
+}
+
+// encodeMovementsCursor returns the opaque cursor of the movement at the position of the movements of an account.
+func encodeMovementsCursor(position int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(position)))
+}
+
+func decodeMovementsCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidMovementsCursor, err)
+	}
+	position, err := strconv.Atoi(string(decoded))
+	if err != nil || position < 0 {
+		return 0, fmt.Errorf("%w: '%s'", ErrInvalidMovementsCursor, cursor)
+	}
+	return position, nil
+}
+
+func (a *Projection) Name() string {
+	return ProjectionName
+}
+
+func (a *Projection) Version() uint64 {
+	return ProjectionVersion
+}
+
+func (a *Projection) HandledEvents() []string {
+	return []string{
+		(&AccountOpened{}).EventName(),
+		(&AccountClosed{}).EventName(),
+		(&AmountDeposited{}).EventName(),
+		(&AmountWithdrawn{}).EventName(),
+		(&TransferSent{}).EventName(),
+		(&TransferReceived{}).EventName(),
+		(&TransferSentRolledBack{}).EventName(),
+		(&TransferCompleted{}).EventName(),
+	}
+}
+
+func (a *Projection) Apply(_ context.Context, event domain.Event) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err := a.handleEvent(event); err != nil {
+		return err
+	}
+
+	a.isDirty = true
+	return nil
+}
+
+func (a *Projection) Snapshot() ([]byte, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return json.Marshal(a.accounts)
+}
+
+func (a *Projection) Restore(state []byte) error {
+	accounts := map[string]*ProjectedAccount{}
+	if err := json.Unmarshal(state, &accounts); err != nil {
+		return fmt.Errorf("error deserializing the accounts: %w", err)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.accounts = accounts
+	a.isDirty = true
+	return nil
+}
+
+func (a *Projection) NewShadow() projection.Projector {
+	return NewProjection()
+}
+
+func (a *Projection) Swap(shadow projection.Projector) error {
+	rebuilt, ok := shadow.(*Projection)
+	if !ok {
+		return fmt.Errorf("unexpected shadow projection type %T", shadow)
+	}
+
+	rebuilt.mutex.RLock()
+	accounts := rebuilt.accounts
+	rebuilt.mutex.RUnlock()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.accounts = accounts
+	a.isDirty = true
+	return nil
+}
+
+func (a *Projection) handleEvent(event domain.Event) error {
+	if _, isOpening := event.(*AccountOpened); !isOpening {
+		if _, exists := a.accounts[event.AggregateID()]; !exists {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, event.AggregateID())
+		}
+	}
+
 	switch e := event.(type) {
 	case *AccountOpened:
-		a.accounts[e.AggregateID()] = &ProjectedAccount{AccountID: e.AggregateID()}
+		a.accounts[e.AggregateID()] = &ProjectedAccount{
+			AccountID: e.AggregateID(),
+			Status:    AccountStatusOpen,
+			OpenedOn:  e.HappenedOn(),
+		}
 	case *AccountClosed:
-		delete(a.accounts, e.AccountID)
+		a.accounts[e.AccountID].Status = AccountStatusClosed
+		a.accounts[e.AccountID].ClosedOn = e.HappenedOn()
 	case *AmountDeposited:
-		a.accounts[e.AggregateID()].Balance += e.Quantity
-		a.accounts[e.AggregateID()].Movements = append(a.accounts[e.AggregateID()].Movements, ProjectedMovement{
-			Type:             "Deposit",
+		a.addMovement(e.AggregateID(), ProjectedMovement{
+			Type:             MovementTypeDeposit,
 			Amount:           e.Quantity,
 			ResultingBalance: e.Balance,
 			Timestamp:        e.HappenedOn(),
 		})
 	case *AmountWithdrawn:
-		a.accounts[e.AggregateID()].Balance -= e.Quantity
-		a.accounts[e.AggregateID()].Movements = append(a.accounts[e.AggregateID()].Movements, ProjectedMovement{
-			Type:             "Withdrawal",
+		a.addMovement(e.AggregateID(), ProjectedMovement{
+			Type:             MovementTypeWithdrawal,
 			Amount:           e.Quantity,
 			ResultingBalance: e.Balance,
 			Timestamp:        e.HappenedOn(),
 		})
+	case *TransferSent:
+		a.addMovement(e.AggregateID(), ProjectedMovement{
+			Type:                MovementTypeTransferSent,
+			TransferID:          e.TransferID,
+			CounterpartyAccount: e.AccountDestination,
+			Amount:              e.Amount,
+			ResultingBalance:    a.accounts[e.AggregateID()].Balance - e.Amount,
+			Timestamp:           e.HappenedOn(),
+		})
+	case *TransferReceived:
+		a.addMovement(e.AggregateID(), ProjectedMovement{
+			Type:                MovementTypeTransferReceived,
+			TransferID:          e.TransferID,
+			CounterpartyAccount: e.AccountOrigin,
+			Amount:              e.Amount,
+			ResultingBalance:    a.accounts[e.AggregateID()].Balance + e.Amount,
+			Timestamp:           e.HappenedOn(),
+		})
+	case *TransferSentRolledBack:
+		a.addMovement(e.AggregateID(), ProjectedMovement{
+			Type:                MovementTypeTransferRolledBack,
+			TransferID:          e.TransferID,
+			CounterpartyAccount: e.AccountDestination,
+			Amount:              e.Amount,
+			ResultingBalance:    a.accounts[e.AggregateID()].Balance + e.Amount,
+			Timestamp:           e.HappenedOn(),
+		})
+	case *TransferCompleted:
+		// The money already left the account when the transfer was sent, so the balance does not change.
+		a.addMovement(e.AggregateID(), ProjectedMovement{
+			Type:                MovementTypeTransferCompleted,
+			TransferID:          e.TransferID,
+			CounterpartyAccount: e.AccountDestination,
+			Amount:              e.Amount,
+			ResultingBalance:    a.accounts[e.AggregateID()].Balance,
+			Timestamp:           e.HappenedOn(),
+		})
 	}
 
-	a.lastProcessedEventID = event.EventID()
+	return nil
+}
+
+// addMovement adds the movement to the account and updates its balance to the resulting one.
+func (a *Projection) addMovement(accountID string, movement ProjectedMovement) {
+	account := a.accounts[accountID]
+	account.Balance = movement.ResultingBalance
+	account.Movements = append(account.Movements, movement)
 }
 
 func (a *Projection) precalculateAccounts() {
@@ -78,49 +366,5 @@ func (a *Projection) precalculateAccounts() {
 	})
 
 	a.precalculatedAccounts = accounts
-}
-
-func (a *Projection) refreshProjection(ctx context.Context) {
-	a.mutex.Lock()
-	defer a.mutex.Unlock()
-
-	store := a.eventStore
-	if a.lastProcessedEventID != "" {
-		store = store.AfterEventID(a.lastProcessedEventID)
-	}
-
-	events, err := store.LoadAllEvents(ctx)
-	if err != nil {
-		slog.Default().ErrorContext(ctx, "error loading events from store", "error", err.Error())
-		return
-	}
-
-	for _, event := range events {
-		a.handleEvent(event)
-	}
-
-	if len(events) > 0 {
-		a.precalculateAccounts()
-	}
-}
-
-func (a *Projection) startPeriodicRefresh(ctx context.Context, refreshInterval time.Duration) {
-	ticker := time.NewTicker(refreshInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			a.refreshProjection(ctx)
-		}
-	}
-}
-
-func NewAccountProjection(ctx context.Context, eventStore *persistence.ReadOnlyEventStore, refreshInterval time.Duration) (*Projection, error) {
-	p := &Projection{accounts: map[string]*ProjectedAccount{}, eventStore: eventStore}
-	p.refreshProjection(ctx)
-	go p.startPeriodicRefresh(ctx, refreshInterval)
-	return p, nil
+	a.isDirty = false
 }
@@ -9,25 +9,29 @@ import (
 	. "github.com/onsi/gomega/gstruct"
 
 	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
 	"github.com/tembleking/myBankSourcing/pkg/persistence"
-	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
 	"github.com/tembleking/myBankSourcing/test/mother"
 )
 
 var _ = Describe("Accounts", func() {
-	var eventStore *persistence.EventStore
+	var (
+		eventStore      *persistence.EventStore
+		projectionStore *inmemory.ProjectionStore
+	)
 
 	BeforeEach(func() {
-		eventStore = persistence.NewEventStoreBuilder(sqlite.InMemory()).Build()
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		projectionStore = inmemory.NewProjectionStore()
 		Expect(eventStore.AppendToStream(context.Background(), mother.AccountOpenWithMovements())).To(Succeed())
 	})
 
 	When("there are multiple AccountOpened events saved", func() {
 		It("returns the accounts found", func(ctx context.Context) {
-			accountsProjection, err := account.NewAccountProjection(ctx, eventStore.ReadOnlyEventStore, time.Second)
-			Expect(err).ToNot(HaveOccurred())
-
-			accounts := accountsProjection.Accounts()
+			accounts := startAccountProjection(eventStore, projectionStore).Accounts()
 
 			Expect(accounts).To(HaveLen(1))
 			Expect(accounts[0]).To(MatchFields(IgnoreExtras, Fields{
@@ -35,18 +39,18 @@ var _ = Describe("Accounts", func() {
 				"Balance":   Equal(5),
 				"Movements": ConsistOf(
 					MatchFields(IgnoreExtras, Fields{
-						"Type":             Equal("Deposit"),
+						"Type":             Equal(account.MovementTypeDeposit),
 						"Amount":           Equal(50),
 						"ResultingBalance": Equal(50),
 						"Timestamp":        BeTemporally("~", time.Now(), 2*time.Second),
 					}),
 					MatchFields(IgnoreExtras, Fields{
-						"Type":             Equal("Withdrawal"),
+						"Type":             Equal(account.MovementTypeWithdrawal),
 						"Amount":           Equal(30),
 						"ResultingBalance": Equal(20),
 					}),
 					MatchFields(IgnoreExtras, Fields{
-						"Type":             Equal("Withdrawal"),
+						"Type":             Equal(account.MovementTypeWithdrawal),
 						"Amount":           Equal(15),
 						"ResultingBalance": Equal(5),
 						"Timestamp":        BeTemporally("~", time.Now(), 2*time.Second),
@@ -55,12 +59,10 @@ var _ = Describe("Accounts", func() {
 			}))
 		})
 
-		When("updating an account between refreshes", func() {
+		When("updating an account after the projection is created", func() {
 			var accountsProjection *account.Projection
 			BeforeEach(func(ctx context.Context) {
-				var err error
-				accountsProjection, err = account.NewAccountProjection(context.Background(), eventStore.ReadOnlyEventStore, 100*time.Millisecond)
-				Expect(err).ToNot(HaveOccurred())
+				accountsProjection = startAccountProjection(eventStore, projectionStore)
 
 				accounts := accountsProjection.Accounts()
 				Expect(accounts).To(HaveLen(1))
@@ -76,16 +78,258 @@ var _ = Describe("Accounts", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			It("refreshes the projection after some time", func(ctx context.Context) {
-				accountsBeforeRefresh := accountsProjection.Accounts()
-				Expect(accountsBeforeRefresh).To(HaveLen(1))
-				Expect(accountsBeforeRefresh[0].Balance).To(Equal(5))
-
+			It("refreshes the projection as soon as the events are appended", func(ctx context.Context) {
 				Eventually(func() int {
 					accountsAfterRefresh := accountsProjection.Accounts()
 					return accountsAfterRefresh[0].Balance
-				}).Should(Equal(105))
+				}).WithTimeout(500 * time.Millisecond).Should(Equal(105))
 			})
 		})
 	})
+
+	When("an account is closed", func() {
+		BeforeEach(func(ctx context.Context) {
+			events, err := eventStore.LoadEventStream(ctx, "some-account")
+			Expect(err).ToNot(HaveOccurred())
+			accountToClose := account.NewAccount()
+			accountToClose.LoadFromHistory(events...)
+			Expect(accountToClose.WithdrawMoney(5)).To(Succeed())
+			Expect(accountToClose.CloseAccount()).To(Succeed())
+			Expect(eventStore.AppendToStream(ctx, accountToClose)).To(Succeed())
+
+			otherAccount, err := account.OpenAccount("other-account")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(eventStore.AppendToStream(ctx, otherAccount)).To(Succeed())
+		})
+
+		It("keeps it with its last statement", func() {
+			accountsProjection := startAccountProjection(eventStore, projectionStore)
+
+			Expect(accountsProjection.Account("some-account")).To(MatchFields(IgnoreExtras, Fields{
+				"Status":    Equal(account.AccountStatusClosed),
+				"Balance":   Equal(0),
+				"Movements": HaveLen(4),
+				"OpenedOn":  BeTemporally("~", time.Now(), 2*time.Second),
+				"ClosedOn":  BeTemporally("~", time.Now(), 2*time.Second),
+			}))
+		})
+
+		It("filters the accounts by status", func() {
+			accountsProjection := startAccountProjection(eventStore, projectionStore)
+
+			Expect(accountsProjection.Accounts()).To(HaveLen(2))
+			Expect(accountsProjection.AccountsWithStatus(account.AccountStatusOpen)).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("other-account"),
+				"Status":    Equal(account.AccountStatusOpen),
+				"ClosedOn":  BeZero(),
+			})))
+			Expect(accountsProjection.AccountsWithStatus(account.AccountStatusClosed)).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("some-account"),
+			})))
+		})
+
+		It("fails to return an account that does not exist", func() {
+			_, err := startAccountProjection(eventStore, projectionStore).Account("nonexistent")
+
+			Expect(err).To(MatchError(account.ErrAccountNotFound))
+		})
+	})
+
+	When("there are transfers between the accounts", func() {
+		var (
+			origin      *account.Account
+			destination *account.Account
+			sent        *transfer.Transfer
+			rolledBack  *transfer.Transfer
+		)
+
+		BeforeEach(func(ctx context.Context) {
+			var err error
+			origin, err = account.OpenAccount("origin-account")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(origin.DepositMoney(100)).To(Succeed())
+			destination, err = account.OpenAccount("destination-account")
+			Expect(err).ToNot(HaveOccurred())
+
+			sent, err = origin.TransferMoney(30, destination)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(origin.SendTransfer(sent)).To(Succeed())
+			Expect(destination.ReceiveTransfer(sent)).To(Succeed())
+			Expect(origin.MarkTransferAsCompleted(sent)).To(Succeed())
+
+			rolledBack, err = origin.TransferMoney(20, destination)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(origin.SendTransfer(rolledBack)).To(Succeed())
+			Expect(origin.RollbackSentTransfer(rolledBack, "destination account is closed")).To(Succeed())
+
+			Expect(eventStore.AppendToStream(ctx, origin)).To(Succeed())
+			Expect(eventStore.AppendToStream(ctx, destination)).To(Succeed())
+		})
+
+		It("shows the transfers as movements with the counterparty", func() {
+			accounts := startAccountProjection(eventStore, projectionStore).Accounts()
+
+			Expect(accounts).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("origin-account"),
+				"Balance":   Equal(70),
+				"Movements": HaveExactElements(
+					MatchFields(IgnoreExtras, Fields{"Type": Equal(account.MovementTypeDeposit), "ResultingBalance": Equal(100)}),
+					MatchFields(IgnoreExtras, Fields{
+						"Type":                Equal(account.MovementTypeTransferSent),
+						"TransferID":          Equal(sent.ID()),
+						"CounterpartyAccount": Equal("destination-account"),
+						"Amount":              Equal(30),
+						"ResultingBalance":    Equal(70),
+					}),
+					MatchFields(IgnoreExtras, Fields{"Type": Equal(account.MovementTypeTransferCompleted), "TransferID": Equal(sent.ID()), "ResultingBalance": Equal(70)}),
+					MatchFields(IgnoreExtras, Fields{"Type": Equal(account.MovementTypeTransferSent), "TransferID": Equal(rolledBack.ID()), "ResultingBalance": Equal(50)}),
+					MatchFields(IgnoreExtras, Fields{
+						"Type":                Equal(account.MovementTypeTransferRolledBack),
+						"TransferID":          Equal(rolledBack.ID()),
+						"CounterpartyAccount": Equal("destination-account"),
+						"Amount":              Equal(20),
+						"ResultingBalance":    Equal(70),
+					}),
+				),
+			})))
+			Expect(accounts).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("destination-account"),
+				"Balance":   Equal(30),
+				"Movements": HaveExactElements(MatchFields(IgnoreExtras, Fields{
+					"Type":                Equal(account.MovementTypeTransferReceived),
+					"TransferID":          Equal(sent.ID()),
+					"CounterpartyAccount": Equal("origin-account"),
+					"Amount":              Equal(30),
+					"ResultingBalance":    Equal(30),
+				})),
+			})))
+		})
+	})
+
+	When("listing the movements of an account", func() {
+		var accountsProjection *account.Projection
+
+		BeforeEach(func() {
+			accountsProjection = startAccountProjection(eventStore, projectionStore)
+		})
+
+		It("returns them in pages, following the cursor of every page", func() {
+			first, err := accountsProjection.Movements("some-account", account.MovementFilter{}, "", 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first.Movements).To(HaveExactElements(HaveField("Amount", 50), HaveField("Amount", 30)))
+			Expect(first.NextCursor).ToNot(BeEmpty())
+
+			last, err := accountsProjection.Movements("some-account", account.MovementFilter{}, first.NextCursor, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(last.Movements).To(HaveExactElements(HaveField("Amount", 15)))
+			Expect(last.NextCursor).To(BeEmpty())
+		})
+
+		It("filters them by type and date", func() {
+			page, err := accountsProjection.Movements("some-account", account.MovementFilter{Types: []account.MovementType{account.MovementTypeWithdrawal}}, "", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.Movements).To(HaveExactElements(HaveField("Amount", 30), HaveField("Amount", 15)))
+
+			page, err = accountsProjection.Movements("some-account", account.MovementFilter{To: time.Now().Add(-time.Hour)}, "", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.Movements).To(BeEmpty())
+		})
+
+		It("rejects an invalid cursor", func() {
+			_, err := accountsProjection.Movements("some-account", account.MovementFilter{}, "not-a-cursor", 2)
+
+			Expect(err).To(MatchError(account.ErrInvalidMovementsCursor))
+		})
+
+		It("fails for an account that does not exist", func() {
+			_, err := accountsProjection.Movements("nonexistent", account.MovementFilter{}, "", 2)
+
+			Expect(err).To(MatchError(account.ErrAccountNotFound))
+		})
+	})
+
+	When("the projection has been processed before", func() {
+		var lastEventID domain.EventID
+
+		BeforeEach(func(ctx context.Context) {
+			events, err := eventStore.LoadAllEvents(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			lastEventID = events[len(events)-1].EventID()
+		})
+
+		It("saves a snapshot with the state and the last processed event", func(ctx context.Context) {
+			startAccountProjection(eventStore, projectionStore)
+
+			snapshot, err := projectionStore.LoadSnapshot(ctx, account.ProjectionName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.LastEventID).To(Equal(lastEventID))
+			Expect(snapshot.Version).ToNot(BeZero())
+			Expect(snapshot.State).To(ContainSubstring("some-account"))
+		})
+
+		It("resumes from the saved snapshot instead of replaying the events", func(ctx context.Context) {
+			Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{
+				Name:        account.ProjectionName,
+				Version:     account.ProjectionVersion,
+				LastEventID: lastEventID,
+				State:       []byte(`{"restored-account":{"AccountID":"restored-account","Balance":42}}`),
+			})).To(Succeed())
+
+			accountsProjection := startAccountProjection(eventStore, projectionStore)
+
+			Expect(accountsProjection.Accounts()).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("restored-account"),
+				"Balance":   Equal(42),
+			})))
+		})
+
+		It("replaces the restored state when the projection is rebuilt", func(ctx context.Context) {
+			Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{
+				Name:        account.ProjectionName,
+				Version:     account.ProjectionVersion,
+				LastEventID: lastEventID,
+				State:       []byte(`{"restored-account":{"AccountID":"restored-account","Balance":42}}`),
+			})).To(Succeed())
+			accountsProjection, runtime := startAccountProjectionRuntime(eventStore, projectionStore)
+
+			Expect(runtime.Rebuild(ctx, account.ProjectionName)).To(Succeed())
+
+			Expect(accountsProjection.Accounts()).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("some-account"),
+				"Balance":   Equal(5),
+			})))
+		})
+
+		It("rebuilds the projection if the snapshot was saved by another version", func(ctx context.Context) {
+			Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{
+				Name:        account.ProjectionName,
+				Version:     account.ProjectionVersion + 1,
+				LastEventID: lastEventID,
+				State:       []byte(`{"restored-account":{"AccountID":"restored-account","Balance":42}}`),
+			})).To(Succeed())
+
+			accountsProjection := startAccountProjection(eventStore, projectionStore)
+
+			Expect(accountsProjection.Accounts()).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"AccountID": Equal("some-account"),
+				"Balance":   Equal(5),
+			})))
+		})
+	})
 })
+
+func startAccountProjection(eventStore *persistence.EventStore, projectionStore persistence.ProjectionStore) *account.Projection {
+	accountsProjection, _ := startAccountProjectionRuntime(eventStore, projectionStore)
+	return accountsProjection
+}
+
+func startAccountProjectionRuntime(eventStore *persistence.EventStore, projectionStore persistence.ProjectionStore) (*account.Projection, *projection.Runtime) {
+	ctx, cancel := context.WithCancel(context.Background())
+	DeferCleanup(cancel)
+
+	accountsProjection := account.NewProjection()
+	runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, projectionStore)
+	Expect(runtime.Register(accountsProjection, projection.ErrorPolicyHalt)).To(Succeed())
+	Expect(runtime.Start(ctx)).To(Succeed())
+	return accountsProjection, runtime
+}
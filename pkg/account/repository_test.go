@@ -10,7 +10,7 @@ import (
 	"github.com/tembleking/myBankSourcing/pkg/account"
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 	"github.com/tembleking/myBankSourcing/pkg/persistence"
-	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
 	"github.com/tembleking/myBankSourcing/test/matchers"
 	"github.com/tembleking/myBankSourcing/test/mother"
 )
@@ -20,7 +20,7 @@ var _ = Describe("Repository", func() {
 
 	BeforeEach(func() {
 		log.SetOutput(GinkgoWriter)
-		repository = account.NewRepository(persistence.NewEventStoreBuilder(sqlite.InMemory()).Build())
+		repository = account.NewRepository(persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build())
 	})
 
 	It("saves an account and retrieves it", func(ctx context.Context) {
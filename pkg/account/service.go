@@ -11,6 +11,7 @@ import (
 type Service struct {
 	accountRepository  domain.Repository[*Account]
 	transferRepository domain.Repository[*transfer.Transfer]
+	unitOfWork         domain.UnitOfWorkFactory
 }
 
 func (a *Service) OnCommand(ctx context.Context, command domain.Command) error {
@@ -87,54 +88,39 @@ func (a *Service) CloseAccount(ctx context.Context, accountID string) (*Account,
 	return account, err
 }
 
-func (a *Service) TransferMoney(ctx context.Context, originAccountID string, destinationAccountID string, amount int) (*transfer.Transfer, error) {
+// TransferMoney requests a transfer and sends its money from the origin account, returning both of them.
+func (a *Service) TransferMoney(ctx context.Context, originAccountID string, destinationAccountID string, amount int) (*transfer.Transfer, *Account, error) {
 	origin, err := a.accountRepository.GetByID(ctx, originAccountID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting origin account: %w", err)
+		return nil, nil, fmt.Errorf("error getting origin account: %w", err)
 	}
 
 	destination, err := a.accountRepository.GetByID(ctx, destinationAccountID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting destination account: %w", err)
+		return nil, nil, fmt.Errorf("error getting destination account: %w", err)
 	}
 
 	transfer, err := origin.TransferMoney(amount, destination)
 	if err != nil {
-		return nil, fmt.Errorf("error creating transfer: %w", err)
+		return nil, nil, fmt.Errorf("error creating transfer: %w", err)
 	}
 
-	err = a.transferRepository.Save(ctx, transfer)
+	// The transfer is requested and the money leaves the origin account atomically,
+	// so the balance is never spent twice by concurrent transfers, and the money never leaves without a transfer.
+	err = origin.SendTransfer(transfer)
 	if err != nil {
-		return nil, fmt.Errorf("error saving transfer: %w", err)
+		return nil, nil, fmt.Errorf("error sending the transfer: %w", err)
 	}
 
-	return transfer, nil
-}
-
-func (a *Service) SendTransfer(ctx context.Context, transferID string) error {
-	transfer, err := a.transferRepository.GetByID(ctx, transferID)
-	if err != nil {
-		return fmt.Errorf("error getting the transfer: %w", err)
-	}
-
-	originAccount, err := a.accountRepository.GetByID(ctx, transfer.FromAccount())
-	if err != nil {
-		return fmt.Errorf("error getting the origin account: %w", err)
-	}
-
-	err = originAccount.SendTransfer(transfer)
-	if err != nil {
-		return fmt.Errorf("error sending the transfer: %w", err)
-	}
-
-	err = a.accountRepository.Save(ctx, originAccount)
-	if err != nil {
-		return fmt.Errorf("error saving the account: %w", err)
+	if err := a.commit(ctx, transfer, origin); err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	return transfer, origin, nil
 }
 
+// ReceiveTransfer credits the money of a sent transfer to the destination account, unless it was cancelled.
+// The transfer is delivered in the same unit of work, so it conflicts with a concurrent CancelTransfer.
 func (a *Service) ReceiveTransfer(ctx context.Context, transferID string) error {
 	transfer, err := a.transferRepository.GetByID(ctx, transferID)
 	if err != nil {
@@ -146,20 +132,20 @@ func (a *Service) ReceiveTransfer(ctx context.Context, transferID string) error
 		return fmt.Errorf("error getting the destination account: %w", err)
 	}
 
-	err = destinationAccount.ReceiveTransfer(transfer)
-	if err != nil {
-		return fmt.Errorf("error sending the transfer: %w", err)
+	if err := transfer.Deliver(); err != nil {
+		return fmt.Errorf("error delivering the transfer: %w", err)
 	}
-
-	err = a.accountRepository.Save(ctx, destinationAccount)
-	if err != nil {
-		return fmt.Errorf("error saving the account: %w", err)
+	if err := destinationAccount.ReceiveTransfer(transfer); err != nil {
+		return fmt.Errorf("error receiving the transfer: %w", err)
 	}
 
-	return nil
+	return a.commit(ctx, transfer, destinationAccount)
 }
 
-func (a *Service) RollbackTransfer(ctx context.Context, transferID string) error {
+// CancelTransfer returns the money of a sent transfer to the origin account, unless it was delivered.
+// A cancelled transfer can no longer be received.
+// The transfer is cancelled in the same unit of work, so it conflicts with a concurrent ReceiveTransfer.
+func (a *Service) CancelTransfer(ctx context.Context, transferID string, reason string) error {
 	transfer, err := a.transferRepository.GetByID(ctx, transferID)
 	if err != nil {
 		return fmt.Errorf("error getting the transfer: %w", err)
@@ -170,16 +156,25 @@ func (a *Service) RollbackTransfer(ctx context.Context, transferID string) error
 		return fmt.Errorf("error getting the origin account: %w", err)
 	}
 
-	err = originAccount.RollbackSentTransfer(transfer)
-	if err != nil {
-		return fmt.Errorf("error sending the transfer: %w", err)
+	if err := transfer.Cancel(reason); err != nil {
+		return fmt.Errorf("error cancelling the transfer: %w", err)
 	}
-
-	err = a.accountRepository.Save(ctx, originAccount)
-	if err != nil {
-		return fmt.Errorf("error saving the account: %w", err)
+	if err := originAccount.RollbackSentTransfer(transfer, reason); err != nil {
+		return fmt.Errorf("error rolling back the transfer: %w", err)
 	}
 
+	return a.commit(ctx, transfer, originAccount)
+}
+
+// commit saves the changes of the transfer and of the account it resolves atomically.
+func (a *Service) commit(ctx context.Context, transfer *transfer.Transfer, account *Account) error {
+	unitOfWork := a.unitOfWork.NewUnitOfWork()
+	if err := unitOfWork.Register(transfer, account); err != nil {
+		return fmt.Errorf("error registering the transfer: %w", err)
+	}
+	if err := unitOfWork.Commit(ctx); err != nil {
+		return fmt.Errorf("error saving the transfer: %w", err)
+	}
 	return nil
 }
 
@@ -207,9 +202,12 @@ func (a *Service) CompleteTransfer(ctx context.Context, transferID string) error
 	return nil
 }
 
-func NewAccountService(accountRepository domain.Repository[*Account], transferRepository domain.Repository[*transfer.Transfer]) *Service {
+// NewAccountService returns a service changing the aggregates in the repositories,
+// and in the units of work of the unitOfWork factory when a command changes several of them.
+func NewAccountService(accountRepository domain.Repository[*Account], transferRepository domain.Repository[*transfer.Transfer], unitOfWork domain.UnitOfWorkFactory) *Service {
 	return &Service{
 		accountRepository:  accountRepository,
 		transferRepository: transferRepository,
+		unitOfWork:         unitOfWork,
 	}
 }
@@ -2,11 +2,13 @@ package account_test
 
 import (
 	"context"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
 	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
 	"github.com/tembleking/myBankSourcing/pkg/transfer"
 	. "github.com/tembleking/myBankSourcing/test/matchers"
@@ -15,14 +17,15 @@ import (
 var _ = Describe("Account Service", func() {
 	var (
 		accountService     *account.Service
-		accountRepository  *inmemory.Repository[*account.Account]
-		transferRepository *inmemory.Repository[*transfer.Transfer]
+		accountRepository  *account.Repository
+		transferRepository *transfer.Repository
 	)
 
 	BeforeEach(func(ctx context.Context) {
-		accountRepository = inmemory.NewRepository[*account.Account]()
-		transferRepository = inmemory.NewRepository[*transfer.Transfer]()
-		accountService = account.NewAccountService(accountRepository, transferRepository)
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		accountRepository = account.NewRepository(eventStore)
+		transferRepository = transfer.NewRepository(eventStore)
+		accountService = account.NewAccountService(accountRepository, transferRepository, eventStore)
 	})
 
 	It("opens the account", func(ctx context.Context) {
@@ -32,7 +35,7 @@ var _ = Describe("Account Service", func() {
 		Expect(accountCreated).ToNot(BeNil())
 		Expect(accountCreated.Balance()).To(Equal(0))
 		Expect(accountCreated.IsOpen()).To(BeTrue())
-		Expect(accountRepository.GetByID(ctx, accountCreated.ID())).To(Equal(accountCreated))
+		Expect(accountRepository.GetByID(ctx, accountCreated.ID())).To(BeAnEntityEqualTo(accountCreated))
 	})
 
 	It("opens the account", func(ctx context.Context) {
@@ -103,7 +106,7 @@ var _ = Describe("Account Service", func() {
 
 		It("creates a transfer request", func(ctx context.Context) {
 			amountToTransfer := 50
-			transfer, err := accountService.TransferMoney(ctx, origin.ID(), destination.ID(), amountToTransfer)
+			transfer, _, err := accountService.TransferMoney(ctx, origin.ID(), destination.ID(), amountToTransfer)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(transfer.ID()).ToNot(BeEmpty())
@@ -114,22 +117,23 @@ var _ = Describe("Account Service", func() {
 			Expect(transferRepository.GetByID(ctx, transfer.ID())).To(BeAnEntityEqualTo(transfer))
 		})
 
+		It("sends the money of the transfer from the origin account as it is requested", func(ctx context.Context) {
+			_, originSent, err := accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 50)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(originSent.Balance()).To(Equal(50))
+
+			originModified, err := accountRepository.GetByID(ctx, origin.ID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(originModified.Balance()).To(Equal(50))
+		})
+
 		When("the transfer has been created", func() {
 			var transferRequested *transfer.Transfer
 
 			BeforeEach(func(ctx context.Context) {
 				var err error
-				transferRequested, err = accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 50)
-				Expect(err).ToNot(HaveOccurred())
-			})
-
-			It("sends the transfer", func(ctx context.Context) {
-				err := accountService.SendTransfer(ctx, transferRequested.ID())
-				Expect(err).ToNot(HaveOccurred())
-
-				originModified, err := accountRepository.GetByID(ctx, origin.ID())
+				transferRequested, _, err = accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 50)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(originModified.Balance()).To(Equal(50))
 			})
 
 			It("receives the transfer", func(ctx context.Context) {
@@ -141,25 +145,86 @@ var _ = Describe("Account Service", func() {
 				Expect(destinationModified.Balance()).To(Equal(50))
 			})
 
-			When("the transfer has already been sent", func() {
-				BeforeEach(func(ctx context.Context) {
-					Expect(accountService.SendTransfer(ctx, transferRequested.ID())).To(Succeed())
-				})
-
-				It("rollsback the transfer", func(ctx context.Context) {
-					err := accountService.RollbackTransfer(ctx, transferRequested.ID())
-					Expect(err).ToNot(HaveOccurred())
+			It("rollsback the transfer", func(ctx context.Context) {
+				err := accountService.CancelTransfer(ctx, transferRequested.ID(), "destination account is closed")
+				Expect(err).ToNot(HaveOccurred())
 
-					originModified, err := accountRepository.GetByID(ctx, origin.ID())
-					Expect(err).ToNot(HaveOccurred())
-					Expect(originModified.Balance()).To(Equal(100))
-				})
+				originModified, err := accountRepository.GetByID(ctx, origin.ID())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(originModified.Balance()).To(Equal(100))
+			})
 
-				It("completes the transfer", func(ctx context.Context) {
-					err := accountService.CompleteTransfer(ctx, transferRequested.ID())
-					Expect(err).ToNot(HaveOccurred())
-				})
+			It("completes the transfer", func(ctx context.Context) {
+				err := accountService.CompleteTransfer(ctx, transferRequested.ID())
+				Expect(err).ToNot(HaveOccurred())
 			})
 		})
 	})
 })
+
+var _ = Describe("Account Service resolving a transfer concurrently", func() {
+	var (
+		accountService    *account.Service
+		accountRepository *account.Repository
+		transferSent      *transfer.Transfer
+		barrier           *sync.WaitGroup
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		accountRepository = account.NewRepository(eventStore)
+		barrier = &sync.WaitGroup{}
+		transferRepository := &barrierTransferRepository{Repository: transfer.NewRepository(eventStore)}
+		accountService = account.NewAccountService(accountRepository, transferRepository, eventStore)
+
+		origin, err := accountService.OpenAccount(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		destination, err := accountService.OpenAccount(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = accountService.DepositMoneyIntoAccount(ctx, origin.ID(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		transferSent, _, err = accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 50)
+		Expect(err).ToNot(HaveOccurred())
+
+		transferRepository.barrier = barrier
+	})
+
+	It("lets only one of a cancel and a receive of the same transfer succeed", func(ctx context.Context) {
+		barrier.Add(2)
+		errs := make(chan error, 2)
+		go func() {
+			defer GinkgoRecover()
+			errs <- accountService.CancelTransfer(ctx, transferSent.ID(), "cancelled by the customer")
+		}()
+		go func() {
+			defer GinkgoRecover()
+			errs <- accountService.ReceiveTransfer(ctx, transferSent.ID())
+		}()
+
+		results := []error{<-errs, <-errs}
+		Expect(results).To(ContainElement(BeNil()))
+		Expect(results).To(ContainElement(MatchError(persistence.ErrUnexpectedVersion)))
+
+		origin, err := accountRepository.GetByID(ctx, transferSent.FromAccount())
+		Expect(err).ToNot(HaveOccurred())
+		destination, err := accountRepository.GetByID(ctx, transferSent.ToAccount())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(origin.Balance() + destination.Balance()).To(Equal(100))
+	})
+})
+
+// barrierTransferRepository makes the commands loading a transfer wait for each other once they have loaded it,
+// so both decide on the same version of the transfer.
+type barrierTransferRepository struct {
+	*transfer.Repository
+	barrier *sync.WaitGroup
+}
+
+func (r *barrierTransferRepository) GetByID(ctx context.Context, id string) (*transfer.Transfer, error) {
+	loaded, err := r.Repository.GetByID(ctx, id)
+	if r.barrier != nil {
+		r.barrier.Done()
+		r.barrier.Wait()
+	}
+	return loaded, err
+}
@@ -0,0 +1,50 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+// TransferProcess moves the transfers forward as the events of the accounts are published:
+// a sent transfer is received by the destination account, or rolled back to the origin account if it cannot be,
+// and a received transfer is completed in the origin account.
+// The events are delivered at least once, and every step is idempotent, so they can be redelivered.
+type TransferProcess struct {
+	service *Service
+}
+
+func NewTransferProcess(service *Service) *TransferProcess {
+	return &TransferProcess{service: service}
+}
+
+func (p *TransferProcess) OnEvent(ctx context.Context, event domain.Event) error {
+	switch e := event.(type) {
+	case *TransferSent:
+		return p.receive(ctx, e.TransferID)
+	case *TransferReceived:
+		if err := p.service.CompleteTransfer(ctx, e.TransferID); err != nil {
+			return fmt.Errorf("error completing the transfer %s: %w", e.TransferID, err)
+		}
+	}
+	return nil
+}
+
+func (p *TransferProcess) receive(ctx context.Context, transferID string) error {
+	err := p.service.ReceiveTransfer(ctx, transferID)
+	switch {
+	case err == nil, errors.Is(err, transfer.ErrTransferCancelled):
+		// A transfer cancelled before it was received is already resolved.
+		return nil
+	case errors.Is(err, ErrAccountIsClosed):
+		if err := p.service.CancelTransfer(ctx, transferID, "destination account is closed"); err != nil {
+			return fmt.Errorf("error rolling back the transfer %s: %w", transferID, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("error receiving the transfer %s: %w", transferID, err)
+	}
+}
@@ -0,0 +1,93 @@
+package account_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/eventbus"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+var _ = Describe("TransferProcess", func() {
+	var (
+		eventStore     *persistence.EventStore
+		accountService *account.Service
+		relay          *sqlite.OutboxRelay
+		origin         *account.Account
+		destination    *account.Account
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		store := sqlite.InMemory()
+		DeferCleanup(store.Close)
+		eventStore = persistence.NewEventStoreBuilder(store).Build()
+		accountService = account.NewAccountService(account.NewRepository(eventStore), transfer.NewRepository(eventStore), eventStore)
+
+		eventBus := eventbus.NewInMemory()
+		Expect(eventBus.Subscribe(ctx, account.NewTransferProcess(accountService))).To(Succeed())
+		relay = sqlite.NewOutboxRelay(store, persistence.NewDeserializerRegistry(), eventBus).WithRetryBackoff(0)
+
+		var err error
+		origin, err = accountService.OpenAccount(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		origin, err = accountService.DepositMoneyIntoAccount(ctx, origin.ID(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		destination, err = accountService.OpenAccount(ctx)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	relayEverything := func(ctx context.Context) {
+		GinkgoHelper()
+		Eventually(func() (int, error) { return relay.RelayPending(ctx) }).Should(BeZero())
+	}
+
+	lastEventOf := func(ctx context.Context, accountID string) any {
+		GinkgoHelper()
+		events, err := eventStore.LoadEventStream(ctx, accountID)
+		Expect(err).ToNot(HaveOccurred())
+		return events[len(events)-1]
+	}
+
+	It("receives a sent transfer in the destination account and completes it", func(ctx context.Context) {
+		_, _, err := accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 30)
+		Expect(err).ToNot(HaveOccurred())
+
+		relayEverything(ctx)
+
+		repository := account.NewRepository(eventStore)
+		Expect(repository.GetByID(ctx, destination.ID())).To(HaveField("Balance()", 30))
+		Expect(repository.GetByID(ctx, origin.ID())).To(HaveField("Balance()", 70))
+		Expect(lastEventOf(ctx, origin.ID())).To(BeAssignableToTypeOf(&account.TransferCompleted{}))
+	})
+
+	It("rolls back a sent transfer when the destination account was closed before receiving it", func(ctx context.Context) {
+		_, _, err := accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 30)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = accountService.CloseAccount(ctx, destination.ID())
+		Expect(err).ToNot(HaveOccurred())
+
+		relayEverything(ctx)
+
+		Expect(account.NewRepository(eventStore).GetByID(ctx, origin.ID())).To(HaveField("Balance()", 100))
+		Expect(lastEventOf(ctx, origin.ID())).To(BeAssignableToTypeOf(&account.TransferSentRolledBack{}))
+	})
+
+	It("ignores the events delivered again", func(ctx context.Context) {
+		transferSent, _, err := accountService.TransferMoney(ctx, origin.ID(), destination.ID(), 30)
+		Expect(err).ToNot(HaveOccurred())
+		relayEverything(ctx)
+		eventsBefore, err := eventStore.LoadAllEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		process := account.NewTransferProcess(accountService)
+		Expect(process.OnEvent(ctx, &account.TransferSent{TransferID: transferSent.ID()})).To(Succeed())
+		Expect(process.OnEvent(ctx, &account.TransferReceived{TransferID: transferSent.ID()})).To(Succeed())
+
+		Expect(eventStore.LoadAllEvents(ctx)).To(HaveLen(len(eventsBefore)))
+	})
+})
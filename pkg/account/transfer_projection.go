@@ -0,0 +1,270 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+const (
+	// TransferProjectionName is the name the transfers projection snapshots are saved under.
+	TransferProjectionName = "transfers"
+	// TransferProjectionVersion must be increased every time the handling of the events changes,
+	// so the snapshots saved by the previous logic are discarded and the projection is rebuilt.
+	TransferProjectionVersion = 3
+)
+
+var ErrTransferNotFound = errors.New("transfer not found")
+
+type TransferStatus string
+
+const (
+	TransferStatusRequested  TransferStatus = "requested"
+	TransferStatusSent       TransferStatus = "sent"
+	TransferStatusReceived   TransferStatus = "received"
+	TransferStatusCompleted  TransferStatus = "completed"
+	TransferStatusRolledBack TransferStatus = "rolled-back"
+)
+
+type ProjectedTransfer struct {
+	RequestedOn   time.Time
+	SentOn        time.Time
+	ReceivedOn    time.Time
+	CompletedOn   time.Time
+	RolledBackOn  time.Time
+	TransferID    string
+	FromAccount   string
+	ToAccount     string
+	Status        TransferStatus
+	FailureReason string
+	Amount        int
+}
+
+// TransferFilter selects the transfers returned by TransferProjection.Transfers.
+// The zero value of every field matches all the transfers, and the date range applies to the request date.
+type TransferFilter struct {
+	RequestedFrom time.Time
+	RequestedTo   time.Time
+	AccountID     string
+	Status        TransferStatus
+}
+
+func (f TransferFilter) matches(transfer *ProjectedTransfer) bool {
+	if f.AccountID != "" && transfer.FromAccount != f.AccountID && transfer.ToAccount != f.AccountID {
+		return false
+	}
+	if f.Status != "" && transfer.Status != f.Status {
+		return false
+	}
+	if !f.RequestedFrom.IsZero() && transfer.RequestedOn.Before(f.RequestedFrom) {
+		return false
+	}
+	if !f.RequestedTo.IsZero() && !transfer.RequestedOn.Before(f.RequestedTo) {
+		return false
+	}
+	return true
+}
+
+// failureReasonField is the field the failure reasons are encrypted for.
+const failureReasonField = "FailureReason"
+
+// TransferProjection is the history of the transfers, built from the transfer requests
+// and the events of the accounts taking part in them.
+type TransferProjection struct {
+	transfers map[string]*ProjectedTransfer
+	// cipher is nil when the personal data is not encrypted.
+	cipher *persistence.PersonalDataCipher
+	mutex  sync.RWMutex
+}
+
+func NewTransferProjection() *TransferProjection {
+	return &TransferProjection{transfers: map[string]*ProjectedTransfer{}}
+}
+
+// WithPersonalDataCipher keeps the failure reasons encrypted with the key of the origin account,
+// in memory and in the snapshots, and decrypts them when they are read.
+// Once the key is deleted they are read as persistence.RedactedValue, without rebuilding the projection.
+func (t *TransferProjection) WithPersonalDataCipher(cipher *persistence.PersonalDataCipher) *TransferProjection {
+	t.cipher = cipher
+	return t
+}
+
+// Transfers returns the transfers matching the filter, sorted by request date.
+func (t *TransferProjection) Transfers(ctx context.Context, filter TransferFilter) ([]ProjectedTransfer, error) {
+	t.mutex.RLock()
+	transfers := []ProjectedTransfer{}
+	for _, transfer := range t.transfers {
+		if filter.matches(transfer) {
+			transfers = append(transfers, *transfer)
+		}
+	}
+	t.mutex.RUnlock()
+
+	for i := range transfers {
+		if err := t.decrypt(ctx, &transfers[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].RequestedOn.Equal(transfers[j].RequestedOn) {
+			return transfers[i].TransferID < transfers[j].TransferID
+		}
+		return transfers[i].RequestedOn.Before(transfers[j].RequestedOn)
+	})
+	return transfers, nil
+}
+
+func (t *TransferProjection) Transfer(ctx context.Context, transferID string) (ProjectedTransfer, error) {
+	t.mutex.RLock()
+	transfer, exists := t.transfers[transferID]
+	if !exists {
+		t.mutex.RUnlock()
+		return ProjectedTransfer{}, fmt.Errorf("%w: %s", ErrTransferNotFound, transferID)
+	}
+	projected := *transfer
+	t.mutex.RUnlock()
+
+	if err := t.decrypt(ctx, &projected); err != nil {
+		return ProjectedTransfer{}, err
+	}
+	return projected, nil
+}
+
+// decrypt replaces the encrypted failure reason of the transfer with the plaintext.
+func (t *TransferProjection) decrypt(ctx context.Context, transfer *ProjectedTransfer) error {
+	if t.cipher == nil {
+		return nil
+	}
+
+	reason, err := t.cipher.Decrypt(ctx, transfer.FromAccount, failureReasonField, transfer.FailureReason)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt the failure reason of the transfer %s: %w", transfer.TransferID, err)
+	}
+	transfer.FailureReason = reason
+	return nil
+}
+
+func (t *TransferProjection) Name() string {
+	return TransferProjectionName
+}
+
+func (t *TransferProjection) Version() uint64 {
+	return TransferProjectionVersion
+}
+
+func (t *TransferProjection) HandledEvents() []string {
+	return []string{
+		(&transfer.TransferRequested{}).EventName(),
+		(&TransferSent{}).EventName(),
+		(&TransferReceived{}).EventName(),
+		(&TransferSentRolledBack{}).EventName(),
+		(&TransferCompleted{}).EventName(),
+	}
+}
+
+func (t *TransferProjection) Apply(ctx context.Context, event domain.Event) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch e := event.(type) {
+	case *transfer.TransferRequested:
+		projected := t.transfer(e.TransferID, e.FromAccount, e.ToAccount, e.Amount)
+		projected.RequestedOn = e.HappenedOn()
+	case *TransferSent:
+		projected := t.transfer(e.TransferID, e.AccountOrigin, e.AccountDestination, e.Amount)
+		projected.SentOn = e.HappenedOn()
+		projected.Status = TransferStatusSent
+	case *TransferReceived:
+		projected := t.transfer(e.TransferID, e.AccountOrigin, e.AccountDestination, e.Amount)
+		projected.ReceivedOn = e.HappenedOn()
+		projected.Status = TransferStatusReceived
+	case *TransferSentRolledBack:
+		projected := t.transfer(e.TransferID, e.AccountOrigin, e.AccountDestination, e.Amount)
+		projected.RolledBackOn = e.HappenedOn()
+		projected.Status = TransferStatusRolledBack
+		reason, err := t.encrypt(ctx, projected.FromAccount, e.Reason)
+		if err != nil {
+			return fmt.Errorf("unable to encrypt the failure reason of the transfer %s: %w", e.TransferID, err)
+		}
+		projected.FailureReason = reason
+	case *TransferCompleted:
+		projected := t.transfer(e.TransferID, e.AccountOrigin, e.AccountDestination, e.Amount)
+		projected.CompletedOn = e.HappenedOn()
+		projected.Status = TransferStatusCompleted
+	}
+	return nil
+}
+
+// transfer returns the projected transfer with the given ID, creating it if the request has not been seen yet.
+func (t *TransferProjection) transfer(transferID, fromAccount, toAccount string, amount int) *ProjectedTransfer {
+	projected, exists := t.transfers[transferID]
+	if !exists {
+		projected = &ProjectedTransfer{
+			TransferID:  transferID,
+			FromAccount: fromAccount,
+			ToAccount:   toAccount,
+			Amount:      amount,
+			Status:      TransferStatusRequested,
+		}
+		t.transfers[transferID] = projected
+	}
+	return projected
+}
+
+func (t *TransferProjection) encrypt(ctx context.Context, subjectID, reason string) (string, error) {
+	if t.cipher == nil {
+		return reason, nil
+	}
+	return t.cipher.Encrypt(ctx, subjectID, failureReasonField, reason)
+}
+
+func (t *TransferProjection) Snapshot() ([]byte, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return json.Marshal(t.transfers)
+}
+
+func (t *TransferProjection) Restore(state []byte) error {
+	transfers := map[string]*ProjectedTransfer{}
+	if err := json.Unmarshal(state, &transfers); err != nil {
+		return fmt.Errorf("error deserializing the transfers: %w", err)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.transfers = transfers
+	return nil
+}
+
+func (t *TransferProjection) NewShadow() projection.Projector {
+	return NewTransferProjection().WithPersonalDataCipher(t.cipher)
+}
+
+func (t *TransferProjection) Swap(shadow projection.Projector) error {
+	rebuilt, ok := shadow.(*TransferProjection)
+	if !ok {
+		return fmt.Errorf("unexpected shadow projection type %T", shadow)
+	}
+
+	rebuilt.mutex.RLock()
+	transfers := rebuilt.transfers
+	rebuilt.mutex.RUnlock()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.transfers = transfers
+	return nil
+}
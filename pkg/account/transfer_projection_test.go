@@ -0,0 +1,240 @@
+package account_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+var _ = Describe("Transfers", func() {
+	var (
+		transferProjection *account.TransferProjection
+		origin             *account.Account
+		destination        *account.Account
+		transferRequested  *transfer.Transfer
+		appliedEvents      map[domain.Aggregate]int
+	)
+
+	// apply applies the events of the aggregates that have not been applied yet.
+	apply := func(ctx context.Context, aggregates ...domain.Aggregate) {
+		for _, aggregate := range aggregates {
+			for _, event := range aggregate.UncommittedEvents()[appliedEvents[aggregate]:] {
+				Expect(transferProjection.Apply(ctx, event)).To(Succeed())
+			}
+			appliedEvents[aggregate] = len(aggregate.UncommittedEvents())
+		}
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		transferProjection = account.NewTransferProjection()
+		appliedEvents = map[domain.Aggregate]int{}
+
+		var err error
+		origin, err = account.OpenAccount("origin-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(origin.DepositMoney(100)).To(Succeed())
+		destination, err = account.OpenAccount("destination-account")
+		Expect(err).ToNot(HaveOccurred())
+
+		transferRequested, err = origin.TransferMoney(30, destination)
+		Expect(err).ToNot(HaveOccurred())
+		apply(ctx, origin, destination, transferRequested)
+	})
+
+	It("returns the requested transfer", func(ctx context.Context) {
+		Expect(transferProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+			"TransferID":  Equal(transferRequested.ID()),
+			"FromAccount": Equal("origin-account"),
+			"ToAccount":   Equal("destination-account"),
+			"Amount":      Equal(30),
+			"Status":      Equal(account.TransferStatusRequested),
+			"RequestedOn": BeTemporally("~", time.Now(), 2*time.Second),
+			"SentOn":      BeZero(),
+		}))
+	})
+
+	It("fails if the transfer does not exist", func(ctx context.Context) {
+		_, err := transferProjection.Transfer(ctx, "nonexistent")
+
+		Expect(err).To(MatchError(account.ErrTransferNotFound))
+	})
+
+	It("follows the transfer until it is completed", func(ctx context.Context) {
+		Expect(origin.SendTransfer(transferRequested)).To(Succeed())
+		apply(ctx, origin)
+		Expect(destination.ReceiveTransfer(transferRequested)).To(Succeed())
+		apply(ctx, destination)
+		Expect(origin.MarkTransferAsCompleted(transferRequested)).To(Succeed())
+		apply(ctx, origin)
+
+		Expect(transferProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+			"Status":      Equal(account.TransferStatusCompleted),
+			"SentOn":      Not(BeZero()),
+			"ReceivedOn":  Not(BeZero()),
+			"CompletedOn": Not(BeZero()),
+		}))
+	})
+
+	It("records why the transfer was rolled back", func(ctx context.Context) {
+		Expect(origin.SendTransfer(transferRequested)).To(Succeed())
+		Expect(origin.RollbackSentTransfer(transferRequested, "destination account is closed")).To(Succeed())
+		apply(ctx, origin)
+
+		Expect(transferProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+			"Status":        Equal(account.TransferStatusRolledBack),
+			"RolledBackOn":  Not(BeZero()),
+			"FailureReason": Equal("destination account is closed"),
+		}))
+	})
+
+	When("the personal data is encrypted", func() {
+		var keyStore *inmemory.KeyStore
+
+		BeforeEach(func(ctx context.Context) {
+			keyStore = inmemory.NewKeyStore()
+			transferProjection = account.NewTransferProjection().WithPersonalDataCipher(persistence.NewPersonalDataCipher(keyStore))
+			appliedEvents = map[domain.Aggregate]int{}
+			Expect(origin.SendTransfer(transferRequested)).To(Succeed())
+			Expect(origin.RollbackSentTransfer(transferRequested, "sent to my neighbour Jane by mistake")).To(Succeed())
+			apply(ctx, origin, destination, transferRequested)
+		})
+
+		It("returns the reason decrypted", func(ctx context.Context) {
+			Expect(transferProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+				"FailureReason": Equal("sent to my neighbour Jane by mistake"),
+			}))
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{})).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"FailureReason": Equal("sent to my neighbour Jane by mistake")}),
+			))
+		})
+
+		It("does not save the reason in clear in its snapshots", func() {
+			snapshot, err := transferProjection.Snapshot()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(snapshot)).ToNot(ContainSubstring("Jane"))
+		})
+
+		It("redacts the reason once the origin account is forgotten, also in its snapshots", func(ctx context.Context) {
+			snapshot, err := transferProjection.Snapshot()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(keyStore.DeleteKey(ctx, "origin-account")).To(Succeed())
+
+			Expect(transferProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+				"FailureReason": Equal(persistence.RedactedValue),
+			}))
+			restored := account.NewTransferProjection().WithPersonalDataCipher(persistence.NewPersonalDataCipher(keyStore))
+			Expect(restored.Restore(snapshot)).To(Succeed())
+			Expect(restored.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+				"Status":        Equal(account.TransferStatusRolledBack),
+				"FailureReason": Equal(persistence.RedactedValue),
+			}))
+		})
+	})
+
+	When("there are multiple transfers", func() {
+		var otherTransfer *transfer.Transfer
+
+		BeforeEach(func(ctx context.Context) {
+			other, err := account.OpenAccount("other-account")
+			Expect(err).ToNot(HaveOccurred())
+			otherTransfer, err = destination.TransferMoney(0, other)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(origin.SendTransfer(transferRequested)).To(Succeed())
+			apply(ctx, other, otherTransfer, origin)
+		})
+
+		It("returns all of them sorted by request date", func(ctx context.Context) {
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{})).To(HaveExactElements(
+				MatchFields(IgnoreExtras, Fields{"TransferID": Equal(transferRequested.ID())}),
+				MatchFields(IgnoreExtras, Fields{"TransferID": Equal(otherTransfer.ID())}),
+			))
+		})
+
+		It("filters them by account", func(ctx context.Context) {
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{AccountID: "other-account"})).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"TransferID": Equal(otherTransfer.ID())}),
+			))
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{AccountID: "destination-account"})).To(HaveLen(2))
+		})
+
+		It("filters them by status", func(ctx context.Context) {
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{Status: account.TransferStatusSent})).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"TransferID": Equal(transferRequested.ID())}),
+			))
+		})
+
+		It("filters them by request date", func(ctx context.Context) {
+			requested, err := transferProjection.Transfer(ctx, otherTransfer.ID())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{RequestedFrom: requested.RequestedOn})).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"TransferID": Equal(otherTransfer.ID())}),
+			))
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{RequestedTo: requested.RequestedOn})).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"TransferID": Equal(transferRequested.ID())}),
+			))
+			Expect(transferProjection.Transfers(ctx, account.TransferFilter{RequestedTo: time.Now().Add(-time.Hour)})).To(BeEmpty())
+		})
+	})
+
+	It("is built from the events of the event store, even if the accounts are stored before the request", func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		Expect(origin.SendTransfer(transferRequested)).To(Succeed())
+		Expect(origin.RollbackSentTransfer(transferRequested, "destination account is closed")).To(Succeed())
+		Expect(eventStore.AppendToStream(ctx, origin)).To(Succeed())
+		Expect(eventStore.AppendToStream(ctx, transferRequested)).To(Succeed())
+		storedProjection := account.NewTransferProjection()
+		runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, inmemory.NewProjectionStore())
+		Expect(runtime.Register(storedProjection, projection.ErrorPolicyHalt)).To(Succeed())
+
+		Expect(runtime.Start(ctx)).To(Succeed())
+
+		Expect(storedProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+			"Status":        Equal(account.TransferStatusRolledBack),
+			"FailureReason": Equal("destination account is closed"),
+		}))
+	})
+	It("is built with the redacted reason once the origin account is forgotten", func(ctx context.Context) {
+		appendOnlyStore := inmemory.NewAppendOnlyStore()
+		keyStore := inmemory.NewKeyStore()
+		cryptoSerializer := persistence.NewCryptoShreddingSerializer(&serializer.JSON{}, &serializer.JSON{}, keyStore)
+		eventStore := persistence.NewEventStoreBuilder(appendOnlyStore).WithSerializer(cryptoSerializer).WithDeserializer(cryptoSerializer).Build()
+		Expect(origin.SendTransfer(transferRequested)).To(Succeed())
+		Expect(origin.RollbackSentTransfer(transferRequested, "sent to my neighbour Jane by mistake")).To(Succeed())
+		Expect(transferRequested.Cancel("sent to my neighbour Jane by mistake")).To(Succeed())
+		Expect(eventStore.AppendToStream(ctx, origin)).To(Succeed())
+		Expect(eventStore.AppendToStream(ctx, transferRequested)).To(Succeed())
+
+		records, err := appendOnlyStore.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		for _, record := range records {
+			Expect(string(record.EventData)).ToNot(ContainSubstring("Jane"))
+		}
+
+		Expect(keyStore.DeleteKey(ctx, "origin-account")).To(Succeed())
+		storedProjection := account.NewTransferProjection()
+		runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, inmemory.NewProjectionStore())
+		Expect(runtime.Register(storedProjection, projection.ErrorPolicyHalt)).To(Succeed())
+
+		Expect(runtime.Start(ctx)).To(Succeed())
+
+		Expect(storedProjection.Transfer(ctx, transferRequested.ID())).To(MatchFields(IgnoreExtras, Fields{
+			"Status":        Equal(account.TransferStatusRolledBack),
+			"Amount":        Equal(30),
+			"FailureReason": Equal(persistence.RedactedValue),
+		}))
+	})
+})
@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+)
+
+var ErrInvalidAdminAPIKey = errors.New("missing or invalid admin API key")
+
+// AdminAuthenticator authenticates the calls to the ClerkAdminAPIService and the ClerkReplicationService
+// with the admin API keys. These services work on the data of every tenant, so the keys of the tenants are never valid.
+// Without keys, every call is accepted, like the TenantResolver does without credentials.
+type AdminAuthenticator struct {
+	apiKeys map[string]struct{}
+}
+
+// NewAdminAuthenticator returns an authenticator accepting the given admin API keys.
+func NewAdminAuthenticator(apiKeys []string) *AdminAuthenticator {
+	keys := make(map[string]struct{}, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		keys[apiKey] = struct{}{}
+	}
+	return &AdminAuthenticator{apiKeys: keys}
+}
+
+// Authenticate returns ErrInvalidAdminAPIKey if the value of the authorization header is not an admin API key as a bearer token.
+func (a *AdminAuthenticator) Authenticate(authorization string) error {
+	if len(a.apiKeys) == 0 {
+		return nil
+	}
+
+	apiKey, isBearer := strings.CutPrefix(authorization, "Bearer ")
+	if !isBearer {
+		return ErrInvalidAdminAPIKey
+	}
+	if _, exists := a.apiKeys[apiKey]; !exists {
+		return ErrInvalidAdminAPIKey
+	}
+	return nil
+}
+
+// UnaryInterceptor rejects the unary calls to the admin services without an admin API key as Unauthenticated.
+func (a *AdminAuthenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.authenticateCall(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// StreamInterceptor rejects the streaming calls to the admin services without an admin API key as Unauthenticated.
+func (a *AdminAuthenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authenticateCall(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(server, stream)
+	}
+}
+
+func (a *AdminAuthenticator) authenticateCall(ctx context.Context, fullMethod string) error {
+	if !isAdminMethod(fullMethod) {
+		return nil
+	}
+
+	var authorization string
+	if values := metadata.ValueFromIncomingContext(ctx, authorizationHeader); len(values) > 0 {
+		authorization = values[0]
+	}
+	if err := a.Authenticate(authorization); err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return nil
+}
+
+func isAdminMethod(fullMethod string) bool {
+	for _, service := range []string{proto.ClerkAdminAPIService_ServiceDesc.ServiceName, proto.ClerkReplicationService_ServiceDesc.ServiceName} {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// BearerToken sends an API key as a bearer token in the authorization header of every call of a client.
+type BearerToken string
+
+func (t BearerToken) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	if t == "" {
+		return nil, nil
+	}
+	return map[string]string{authorizationHeader: "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity is false, so the keys can be sent to the servers without TLS in the internal network.
+func (t BearerToken) RequireTransportSecurity() bool {
+	return false
+}
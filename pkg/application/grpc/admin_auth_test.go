@@ -0,0 +1,97 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+)
+
+var _ = Describe("AdminAuthenticator", func() {
+	var (
+		adminClient       proto.ClerkAdminAPIServiceClient
+		replicationClient proto.ClerkReplicationServiceClient
+	)
+
+	BeforeEach(func() {
+		store := sqlite.InMemory()
+		DeferCleanup(store.Close)
+		eventStore := persistence.NewEventStoreBuilder(store).Build()
+		runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, inmemory.NewProjectionStore())
+
+		tenantResolver := grpc.NewTenantResolver(map[string]domain.TenantID{"tenant-key": "tenant-a"})
+		adminAuthenticator := grpc.NewAdminAuthenticator([]string{"admin-key"})
+		listener := bufconn.Listen(1024 * 1024)
+		server := gogrpc.NewServer(
+			gogrpc.ChainUnaryInterceptor(tenantResolver.UnaryInterceptor(), adminAuthenticator.UnaryInterceptor()),
+			gogrpc.StreamInterceptor(adminAuthenticator.StreamInterceptor()),
+		)
+		proto.RegisterClerkAdminAPIServiceServer(server, grpc.NewAdminGRPCServer(runtime, store, nil, nil, nil))
+		proto.RegisterClerkReplicationServiceServer(server, grpc.NewReplicationGRPCServer(store))
+		go server.Serve(listener)
+		DeferCleanup(server.Stop)
+
+		conn, err := gogrpc.NewClient("passthrough:///clerk",
+			gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		adminClient = proto.NewClerkAdminAPIServiceClient(conn)
+		replicationClient = proto.NewClerkReplicationServiceClient(conn)
+	})
+
+	withAPIKey := func(ctx context.Context, apiKey string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+apiKey)
+	}
+
+	streamEvents := func(ctx context.Context) error {
+		stream, err := replicationClient.StreamEvents(ctx, &proto.StreamEventsRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}
+
+	DescribeTable("rejects the calls without an admin API key",
+		func(ctx context.Context, apiKey string) {
+			if apiKey != "" {
+				ctx = withAPIKey(ctx, apiKey)
+			}
+
+			_, err := adminClient.ListProjections(ctx, &emptypb.Empty{})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+			_, err = adminClient.VerifyEventLog(ctx, &emptypb.Empty{})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+			Expect(status.Code(streamEvents(ctx))).To(Equal(codes.Unauthenticated))
+		},
+		Entry("without a key", ""),
+		Entry("with the key of a tenant", "tenant-key"),
+		Entry("with an unknown key", "unknown-key"),
+	)
+
+	It("accepts the calls with an admin API key", func(ctx context.Context) {
+		ctx = withAPIKey(ctx, "admin-key")
+
+		_, err := adminClient.ListProjections(ctx, &emptypb.Empty{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(streamEvents(ctx)).To(Succeed())
+	})
+})
@@ -0,0 +1,188 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+)
+
+type AdminGRPCServer struct {
+	projectionRuntime *projection.Runtime
+	chainVerifier     persistence.ChainVerifier
+	backuper          persistence.Backuper
+	keyStore          persistence.KeyStore
+	// follower is nil when this server is the leader.
+	follower *Follower
+}
+
+func NewAdminGRPCServer(projectionRuntime *projection.Runtime, chainVerifier persistence.ChainVerifier, backuper persistence.Backuper, keyStore persistence.KeyStore, follower *Follower) *AdminGRPCServer {
+	return &AdminGRPCServer{
+		projectionRuntime: projectionRuntime,
+		chainVerifier:     chainVerifier,
+		backuper:          backuper,
+		keyStore:          keyStore,
+		follower:          follower,
+	}
+}
+
+func (s *AdminGRPCServer) ListProjections(ctx context.Context, _ *emptypb.Empty) (*proto.ListProjectionsResponse, error) {
+	statuses := s.projectionRuntime.Status(ctx)
+	protoStatuses := make([]*proto.ProjectionStatus, len(statuses))
+	for i, status := range statuses {
+		protoStatuses[i] = projectionStatusToProto(status)
+	}
+	return &proto.ListProjectionsResponse{
+		Projections: protoStatuses,
+	}, nil
+}
+
+func (s *AdminGRPCServer) GetProjectionStatus(ctx context.Context, request *proto.GetProjectionStatusRequest) (*proto.ProjectionStatus, error) {
+	return s.projectionStatus(ctx, request.GetName())
+}
+
+func (s *AdminGRPCServer) RebuildProjection(ctx context.Context, request *proto.RebuildProjectionRequest) (*proto.ProjectionStatus, error) {
+	if err := s.projectionRuntime.Rebuild(ctx, request.GetName()); err != nil {
+		return nil, projectionErrorToHTTPStatus(err)
+	}
+	return s.projectionStatus(ctx, request.GetName())
+}
+
+func (s *AdminGRPCServer) ResetProjection(ctx context.Context, request *proto.ResetProjectionRequest) (*proto.ProjectionStatus, error) {
+	if err := s.projectionRuntime.Reset(ctx, request.GetName()); err != nil {
+		return nil, projectionErrorToHTTPStatus(err)
+	}
+	return s.projectionStatus(ctx, request.GetName())
+}
+
+func (s *AdminGRPCServer) RestartProjection(ctx context.Context, request *proto.RestartProjectionRequest) (*proto.ProjectionStatus, error) {
+	if err := s.projectionRuntime.Restart(ctx, request.GetName()); err != nil {
+		return nil, projectionErrorToHTTPStatus(err)
+	}
+	return s.projectionStatus(ctx, request.GetName())
+}
+
+// ForgetSubject deletes the key of the subject. The projections keep the personal data encrypted with the same key,
+// so it is redacted in them and in their snapshots without rebuilding them.
+func (s *AdminGRPCServer) ForgetSubject(ctx context.Context, request *proto.ForgetSubjectRequest) (*emptypb.Empty, error) {
+	if request.GetSubjectId() == "" {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: errors.New("the subject id is required")}
+	}
+	if err := s.keyStore.DeleteKey(ctx, request.GetSubjectId()); err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdminGRPCServer) VerifyEventLog(ctx context.Context, _ *emptypb.Empty) (*proto.VerifyEventLogResponse, error) {
+	verification, err := s.chainVerifier.VerifyChain(ctx)
+	if err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+	return chainVerificationToProto(verification), nil
+}
+
+func (s *AdminGRPCServer) CreateBackup(ctx context.Context, _ *emptypb.Empty) (*proto.Backup, error) {
+	backup, err := s.backuper.TakeBackup(ctx)
+	if err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+	return backupToProto(backup), nil
+}
+
+func (s *AdminGRPCServer) ListBackups(ctx context.Context, _ *emptypb.Empty) (*proto.ListBackupsResponse, error) {
+	backups, err := s.backuper.ListBackups(ctx)
+	if err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+
+	protoBackups := make([]*proto.Backup, len(backups))
+	for i, backup := range backups {
+		protoBackups[i] = backupToProto(backup)
+	}
+	return &proto.ListBackupsResponse{Backups: protoBackups}, nil
+}
+
+func (s *AdminGRPCServer) GetReplicationStatus(_ context.Context, _ *emptypb.Empty) (*proto.ReplicationStatus, error) {
+	if s.follower == nil {
+		return &proto.ReplicationStatus{Role: "leader", CaughtUp: true}, nil
+	}
+	return replicationStatusToProto(s.follower.Status()), nil
+}
+
+func (s *AdminGRPCServer) projectionStatus(ctx context.Context, name string) (*proto.ProjectionStatus, error) {
+	status, err := s.projectionRuntime.ProjectionStatus(ctx, name)
+	if err != nil {
+		return nil, projectionErrorToHTTPStatus(err)
+	}
+	return projectionStatusToProto(status), nil
+}
+
+func projectionErrorToHTTPStatus(err error) error {
+	switch {
+	case errors.Is(err, projection.ErrProjectionNotFound):
+		return &runtime.HTTPStatusError{HTTPStatus: 404, Err: err}
+	case errors.Is(err, projection.ErrProjectionNotRebuildable), errors.Is(err, projection.ErrProjectionNotHalted):
+		return &runtime.HTTPStatusError{HTTPStatus: 400, Err: err}
+	default:
+		return &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+}
+
+func projectionStatusToProto(status projection.Status) *proto.ProjectionStatus {
+	return &proto.ProjectionStatus{
+		Name:            status.Name,
+		Version:         status.Version,
+		State:           string(status.State),
+		ErrorPolicy:     status.ErrorPolicy,
+		LastEventId:     string(status.LastEventID),
+		LagEvents:       status.Lag,
+		ProcessedEvents: status.ProcessedEvents,
+		SkippedEvents:   status.SkippedEvents,
+		LastError:       status.LastError,
+		LastProcessedOn: timestampToProto(status.LastProcessedOn),
+	}
+}
+
+func chainVerificationToProto(verification persistence.ChainVerification) *proto.VerifyEventLogResponse {
+	response := &proto.VerifyEventLogResponse{
+		Valid:           verification.BrokenLink == nil,
+		VerifiedEvents:  uint64(verification.VerifiedEvents),
+		UnchainedEvents: uint64(verification.UnchainedEvents),
+	}
+	if verification.BrokenLink != nil {
+		response.BrokenLink = &proto.BrokenLink{
+			EventId:       string(verification.BrokenLink.EventID),
+			StreamName:    verification.BrokenLink.ID.StreamName,
+			StreamVersion: verification.BrokenLink.ID.StreamVersion,
+			Reason:        verification.BrokenLink.Reason,
+		}
+	}
+	return response
+}
+
+func backupToProto(backup persistence.Backup) *proto.Backup {
+	return &proto.Backup{
+		Path:      backup.Path,
+		CreatedOn: timestampToProto(backup.CreatedOn),
+		SizeBytes: backup.Size,
+	}
+}
+
+func replicationStatusToProto(status ReplicationStatus) *proto.ReplicationStatus {
+	return &proto.ReplicationStatus{
+		Role:             "follower",
+		LeaderAddress:    status.LeaderAddress,
+		LastEventId:      string(status.LastEventID),
+		ReplicatedEvents: status.ReplicatedEvents,
+		CaughtUp:         status.CaughtUp,
+		LagMilliseconds:  status.Lag.Milliseconds(),
+		LastContactOn:    timestampToProto(status.LastContact),
+		LastError:        status.LastError,
+	}
+}
@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+const (
+	defaultFollowerRetryBackoff = time.Second
+	followerCatchUpBatchSize    = 500
+)
+
+// ReplicationStatus is how far a Follower is behind its leader.
+type ReplicationStatus struct {
+	LeaderAddress    string
+	LastEventID      domain.EventID
+	ReplicatedEvents uint64
+	// CaughtUp is whether the follower had every event of the leader the last time they were in contact.
+	CaughtUp bool
+	// Lag is how long ago the last replicated event happened, or zero if the follower has caught up.
+	Lag         time.Duration
+	LastContact time.Time
+	LastError   string
+}
+
+// Follower replicates the event log of a leader into a local store,
+// so the projections and the queries can be served from the local copy.
+// The events keep the order, the IDs and the encoding they have in the leader.
+type Follower struct {
+	leaderAddress string
+	client        proto.ClerkReplicationServiceClient
+	store         persistence.AppendOnlyStore
+	retryBackoff  time.Duration
+
+	status ReplicationStatus
+	// lastHappenedOn is when the last replicated event happened, to compute the lag.
+	lastHappenedOn time.Time
+	mutex          sync.Mutex
+}
+
+func NewFollower(leaderAddress string, client proto.ClerkReplicationServiceClient, store persistence.AppendOnlyStore) *Follower {
+	return &Follower{
+		leaderAddress: leaderAddress,
+		client:        client,
+		store:         store,
+		retryBackoff:  defaultFollowerRetryBackoff,
+		status:        ReplicationStatus{LeaderAddress: leaderAddress},
+	}
+}
+
+// WithRetryBackoff sets how long to wait before reconnecting to the leader after the stream fails.
+func (f *Follower) WithRetryBackoff(retryBackoff time.Duration) *Follower {
+	f.retryBackoff = retryBackoff
+	return f
+}
+
+func (f *Follower) LeaderAddress() string {
+	return f.leaderAddress
+}
+
+// Status returns how far the follower is behind the leader.
+func (f *Follower) Status() ReplicationStatus {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	status := f.status
+	if !status.CaughtUp && status.LastEventID != "" {
+		status.Lag = time.Since(f.lastHappenedOn)
+	}
+	return status
+}
+
+// Run replicates the events of the leader until the context is cancelled,
+// resuming from the last event of the local store whenever the stream to the leader fails.
+// The events are appended to the tenant they were stored in by the leader.
+func (f *Follower) Run(ctx context.Context) {
+	ctx = domain.WithAllTenants(ctx)
+	for {
+		err := f.replicate(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Default().ErrorContext(ctx, "error replicating events from the leader, retrying", "leader", f.leaderAddress, "error", err.Error())
+			f.updateStatus(func() { f.status.LastError = err.Error() })
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.retryBackoff):
+		}
+	}
+}
+
+func (f *Follower) replicate(ctx context.Context) error {
+	lastEventID, err := f.lastLocalEventID(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading the last local event: %w", err)
+	}
+
+	stream, err := f.client.StreamEvents(ctx, &proto.StreamEventsRequest{AfterEventId: string(lastEventID)})
+	if err != nil {
+		return fmt.Errorf("error connecting to the leader: %w", err)
+	}
+
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("error receiving events from the leader: %w", err)
+		}
+
+		records := eventsFromProto(response.GetEvents())
+		if err := f.store.Append(ctx, records...); err != nil {
+			return fmt.Errorf("error appending the replicated events: %w", err)
+		}
+
+		f.updateStatus(func() {
+			if len(records) > 0 {
+				f.status.LastEventID = records[len(records)-1].EventID
+				f.status.ReplicatedEvents += uint64(len(records))
+				f.lastHappenedOn = records[len(records)-1].HappenedOn
+			}
+			f.status.CaughtUp = response.GetCaughtUp()
+			f.status.LastContact = time.Now()
+			f.status.LastError = ""
+		})
+	}
+}
+
+// lastLocalEventID returns the last event of the local store, which is where the replication resumes.
+func (f *Follower) lastLocalEventID(ctx context.Context) (domain.EventID, error) {
+	f.mutex.Lock()
+	lastEventID := f.status.LastEventID
+	f.mutex.Unlock()
+
+	for {
+		store := persistence.ReadOnlyStore(f.store)
+		if lastEventID != "" {
+			store = store.AfterEventID(lastEventID)
+		}
+		records, err := store.Limit(followerCatchUpBatchSize).ReadAllRecords(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(records) > 0 {
+			lastEventID = records[len(records)-1].EventID
+			f.updateStatus(func() {
+				f.status.LastEventID = lastEventID
+				f.lastHappenedOn = records[len(records)-1].HappenedOn
+			})
+		}
+		if len(records) < followerCatchUpBatchSize {
+			return lastEventID, nil
+		}
+	}
+}
+
+func (f *Follower) updateStatus(update func()) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	update()
+}
+
+func eventsFromProto(events []*proto.ReplicatedEvent) []persistence.StoredStreamEvent {
+	records := make([]persistence.StoredStreamEvent, len(events))
+	for i, event := range events {
+		records[i] = persistence.StoredStreamEvent{
+			ID:          persistence.StreamID{StreamName: event.GetStreamName(), StreamVersion: event.GetStreamVersion()},
+			EventID:     domain.EventID(event.GetEventId()),
+			EventName:   event.GetEventName(),
+			EventData:   event.GetEventData(),
+			HappenedOn:  event.GetHappenedOn().AsTime(),
+			ContentType: event.GetContentType(),
+			TenantID:    domain.TenantID(event.GetTenantId()),
+		}
+	}
+	return records
+}
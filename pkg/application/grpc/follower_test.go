@@ -0,0 +1,145 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("Follower", func() {
+	var (
+		leaderStore   *sqlite.AppendOnlyStore
+		followerStore *sqlite.AppendOnlyStore
+		follower      *grpc.Follower
+		server        *gogrpc.Server
+	)
+
+	BeforeEach(func() {
+		leaderStore = sqlite.InMemory()
+		followerStore = sqlite.InMemory()
+
+		listener := bufconn.Listen(1024 * 1024)
+		server = gogrpc.NewServer()
+		proto.RegisterClerkReplicationServiceServer(server, grpc.NewReplicationGRPCServer(leaderStore).WithPollInterval(10*time.Millisecond))
+		go server.Serve(listener)
+
+		conn, err := gogrpc.NewClient("passthrough:///leader",
+			gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		follower = grpc.NewFollower("leader:8081", proto.NewClerkReplicationServiceClient(conn), followerStore).WithRetryBackoff(10 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		server.Stop()
+		leaderStore.Close()
+		followerStore.Close()
+	})
+
+	openAccount := func(ctx context.Context, id string) {
+		acc, err := account.OpenAccount(id)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acc.DepositMoney(10)).To(Succeed())
+		Expect(persistence.NewEventStoreBuilder(leaderStore).Build().AppendToStream(ctx, acc)).To(Succeed())
+	}
+
+	It("replicates the events of the leader in the same order", func(ctx context.Context) {
+		openAccount(ctx, "account-1")
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go follower.Run(runCtx)
+
+		Eventually(follower.Status).Should(HaveField("CaughtUp", true))
+		openAccount(ctx, "account-2")
+
+		leaderRecords, err := leaderStore.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(followerStore.ReadAllRecords).WithArguments(ctx).Should(HaveLen(4))
+		followerRecords, err := followerStore.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		for i, record := range followerRecords {
+			Expect(record.EventID).To(Equal(leaderRecords[i].EventID))
+			Expect(record.EventData).To(Equal(leaderRecords[i].EventData))
+			Expect(record.ContentType).To(Equal(leaderRecords[i].ContentType))
+		}
+		Eventually(follower.Status).Should(And(
+			HaveField("LastEventID", leaderRecords[3].EventID),
+			HaveField("ReplicatedEvents", uint64(4)),
+			HaveField("Lag", time.Duration(0)),
+		))
+	})
+
+	It("resumes from the last event of the local store", func(ctx context.Context) {
+		openAccount(ctx, "account-1")
+		records, err := leaderStore.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(followerStore.Append(ctx, records...)).To(Succeed())
+		openAccount(ctx, "account-2")
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go follower.Run(runCtx)
+
+		Eventually(follower.Status).Should(HaveField("CaughtUp", true))
+		Expect(follower.Status().ReplicatedEvents).To(Equal(uint64(2)))
+		Expect(followerStore.ReadAllRecords(ctx)).To(HaveLen(4))
+	})
+
+	It("replicates the events in the tenant they were stored in", func(ctx context.Context) {
+		tenantCtx := domain.WithTenant(ctx, "some-tenant")
+		openAccount(tenantCtx, "account-1")
+		openAccount(ctx, "account-1")
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go follower.Run(runCtx)
+
+		Eventually(follower.Status).Should(HaveField("ReplicatedEvents", uint64(4)))
+		Expect(followerStore.ReadRecords(tenantCtx, "account-1")).To(HaveLen(2))
+		Expect(followerStore.ReadRecords(ctx, "account-1")).To(HaveLen(2))
+	})
+
+	It("reports the error while the leader is unreachable", func(ctx context.Context) {
+		server.Stop()
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go follower.Run(runCtx)
+
+		Eventually(follower.Status).Should(HaveField("LastError", Not(BeEmpty())))
+		Expect(follower.Status().CaughtUp).To(BeFalse())
+	})
+})
+
+var _ = Describe("AccountGRPCServer on a follower", func() {
+	It("rejects the writes with the address of the leader", func(ctx context.Context) {
+		store := sqlite.InMemory()
+		defer store.Close()
+		eventStore := persistence.NewEventStoreBuilder(store).Build()
+		accountService := account.NewAccountService(account.NewRepository(eventStore), nil, eventStore)
+		server := grpc.NewAccountGRPCServer(accountService, nil, nil).WithLeaderAddress("leader:8081")
+
+		_, err := server.OpenAccount(ctx, &emptypb.Empty{})
+
+		Expect(err).To(MatchError(ContainSubstring("leader:8081")))
+		Expect(err).To(BeAssignableToTypeOf(&runtime.HTTPStatusError{}))
+		Expect(err.(*runtime.HTTPStatusError).HTTPStatus).To(Equal(http.StatusMisdirectedRequest))
+		Expect(store.ReadAllRecords(ctx)).To(BeEmpty())
+	})
+})
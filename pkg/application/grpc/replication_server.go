@@ -0,0 +1,110 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+const (
+	replicationBatchSize        = 500
+	defaultReplicationPoll      = 100 * time.Millisecond
+	defaultReplicationHeartbeat = time.Second
+)
+
+// ReplicationGRPCServer streams the event log of the leader to the follower replicas.
+type ReplicationGRPCServer struct {
+	store             persistence.ReadOnlyStore
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+}
+
+func NewReplicationGRPCServer(store persistence.ReadOnlyStore) *ReplicationGRPCServer {
+	return &ReplicationGRPCServer{
+		store:             store,
+		pollInterval:      defaultReplicationPoll,
+		heartbeatInterval: defaultReplicationHeartbeat,
+	}
+}
+
+// WithPollInterval sets how often the store is checked for new events once the follower has caught up.
+func (s *ReplicationGRPCServer) WithPollInterval(pollInterval time.Duration) *ReplicationGRPCServer {
+	s.pollInterval = pollInterval
+	return s
+}
+
+// WithHeartbeatInterval sets how often the follower is told it is still caught up while there are no new events.
+func (s *ReplicationGRPCServer) WithHeartbeatInterval(heartbeatInterval time.Duration) *ReplicationGRPCServer {
+	s.heartbeatInterval = heartbeatInterval
+	return s
+}
+
+// StreamEvents streams the events of every tenant, since a follower replicates the whole event log.
+func (s *ReplicationGRPCServer) StreamEvents(request *proto.StreamEventsRequest, stream proto.ClerkReplicationService_StreamEventsServer) error {
+	ctx := domain.WithAllTenants(stream.Context())
+	var lastEventID *domain.EventID
+	if request.GetAfterEventId() != "" {
+		afterEventID := domain.EventID(request.GetAfterEventId())
+		lastEventID = &afterEventID
+	}
+
+	lastSent := time.Time{}
+	for {
+		records, err := s.nextBatch(ctx, lastEventID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "error reading the event log: %s", err)
+		}
+
+		caughtUp := len(records) < replicationBatchSize
+		if len(records) > 0 || time.Since(lastSent) >= s.heartbeatInterval {
+			if err := stream.Send(eventsToProto(records, caughtUp)); err != nil {
+				return err
+			}
+			lastSent = time.Now()
+		}
+		if len(records) > 0 {
+			lastEventID = &records[len(records)-1].EventID
+		}
+		if !caughtUp {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+func (s *ReplicationGRPCServer) nextBatch(ctx context.Context, lastEventID *domain.EventID) ([]persistence.StoredStreamEvent, error) {
+	store := s.store
+	if lastEventID != nil {
+		store = store.AfterEventID(*lastEventID)
+	}
+	return store.Limit(replicationBatchSize).ReadAllRecords(ctx)
+}
+
+func eventsToProto(records []persistence.StoredStreamEvent, caughtUp bool) *proto.StreamEventsResponse {
+	events := make([]*proto.ReplicatedEvent, len(records))
+	for i, record := range records {
+		events[i] = &proto.ReplicatedEvent{
+			EventId:       string(record.EventID),
+			StreamName:    record.ID.StreamName,
+			StreamVersion: record.ID.StreamVersion,
+			EventName:     record.EventName,
+			ContentType:   record.ContentType,
+			HappenedOn:    timestamppb.New(record.HappenedOn),
+			EventData:     record.EventData,
+			TenantId:      string(record.TenantID),
+		}
+	}
+	return &proto.StreamEventsResponse{Events: events, CaughtUp: caughtUp}
+}
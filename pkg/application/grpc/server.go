@@ -3,27 +3,71 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/tembleking/myBankSourcing/pkg/account"
 	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+const (
+	// leaderAddressHeader is the header with the address of the leader in the writes rejected by a follower replica.
+	leaderAddressHeader = "x-leader-address"
+
+	defaultMovementsPageSize = 50
+	maxMovementsPageSize     = 500
 )
 
 type AccountGRPCServer struct {
-	accountService    *account.Service
-	accountProjection *account.Projection
+	accountService     *account.Service
+	accountProjection  *projection.PerTenant[*account.Projection]
+	transferProjection *projection.PerTenant[*account.TransferProjection]
+	// leaderAddress is set when this server is a follower replica, which rejects the writes.
+	leaderAddress string
 }
 
-func NewAccountGRPCServer(accountService *account.Service, accountProjection *account.Projection) *AccountGRPCServer {
+func NewAccountGRPCServer(accountService *account.Service, accountProjection *projection.PerTenant[*account.Projection], transferProjection *projection.PerTenant[*account.TransferProjection]) *AccountGRPCServer {
 	return &AccountGRPCServer{
-		accountService:    accountService,
-		accountProjection: accountProjection,
+		accountService:     accountService,
+		accountProjection:  accountProjection,
+		transferProjection: transferProjection,
+	}
+}
+
+// WithLeaderAddress makes the server reject the writes, pointing the clients to the leader at the given address.
+func (s *AccountGRPCServer) WithLeaderAddress(leaderAddress string) *AccountGRPCServer {
+	s.leaderAddress = leaderAddress
+	return s
+}
+
+// rejectWritesOnFollower returns an error with the address of the leader if this server is a follower replica.
+// The address is also sent in the leaderAddressHeader, so the clients can retry against the leader.
+func (s *AccountGRPCServer) rejectWritesOnFollower(ctx context.Context) error {
+	if s.leaderAddress == "" {
+		return nil
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(leaderAddressHeader, s.leaderAddress))
+	return &runtime.HTTPStatusError{
+		HTTPStatus: http.StatusMisdirectedRequest,
+		Err:        fmt.Errorf("this server is a read-only follower, send the writes to the leader at %s", s.leaderAddress),
 	}
 }
 
 func (s *AccountGRPCServer) OpenAccount(ctx context.Context, _ *emptypb.Empty) (*proto.OpenAccountResponse, error) {
+	if err := s.rejectWritesOnFollower(ctx); err != nil {
+		return nil, err
+	}
 	account, err := s.accountService.OpenAccount(ctx)
 	if err != nil {
 		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
@@ -37,21 +81,119 @@ func (s *AccountGRPCServer) OpenAccount(ctx context.Context, _ *emptypb.Empty) (
 	}, nil
 }
 
-func (s *AccountGRPCServer) ListAccounts(_ context.Context, _ *emptypb.Empty) (*proto.ListAccountsResponse, error) {
-	accounts := s.accountProjection.Accounts()
+func (s *AccountGRPCServer) ListAccounts(ctx context.Context, request *proto.ListAccountsRequest) (*proto.ListAccountsResponse, error) {
+	accountProjection := s.accountProjection.For(ctx)
+	var accounts []account.ProjectedAccount
+	switch request.GetStatus() {
+	case "", string(account.AccountStatusOpen):
+		accounts = accountProjection.AccountsWithStatus(account.AccountStatusOpen)
+	case string(account.AccountStatusClosed):
+		accounts = accountProjection.AccountsWithStatus(account.AccountStatusClosed)
+	case "all":
+		accounts = accountProjection.Accounts()
+	default:
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: errors.New("status must be open, closed or all")}
+	}
+
 	protoAccounts := make([]*proto.Account, len(accounts))
 	for i, account := range accounts {
-		protoAccounts[i] = &proto.Account{
-			Id:      string(account.AccountID),
-			Balance: int64(account.Balance),
-		}
+		protoAccounts[i] = accountToProto(account)
 	}
 	return &proto.ListAccountsResponse{
 		Accounts: protoAccounts,
 	}, nil
 }
 
+func (s *AccountGRPCServer) GetAccount(ctx context.Context, request *proto.GetAccountRequest) (*proto.Account, error) {
+	projected, err := s.accountProjection.For(ctx).Account(request.GetAccountId())
+	if errors.Is(err, account.ErrAccountNotFound) {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 404, Err: err}
+	}
+	if err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+	return accountToProto(projected), nil
+}
+
+func (s *AccountGRPCServer) ListMovements(ctx context.Context, request *proto.ListMovementsRequest) (*proto.ListMovementsResponse, error) {
+	pageSize := int(request.GetPageSize())
+	switch {
+	case pageSize < 0 || pageSize > maxMovementsPageSize:
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: fmt.Errorf("page size must be between 1 and %d", maxMovementsPageSize)}
+	case pageSize == 0:
+		pageSize = defaultMovementsPageSize
+	}
+
+	filter := account.MovementFilter{}
+	for _, movementType := range request.GetTypes() {
+		if !slices.Contains(movementTypes, account.MovementType(movementType)) {
+			return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: fmt.Errorf("unknown movement type '%s'", movementType)}
+		}
+		filter.Types = append(filter.Types, account.MovementType(movementType))
+	}
+	if request.GetFrom() != nil {
+		filter.From = request.GetFrom().AsTime()
+	}
+	if request.GetTo() != nil {
+		filter.To = request.GetTo().AsTime()
+	}
+
+	page, err := s.accountProjection.For(ctx).Movements(request.GetAccountId(), filter, request.GetPageToken(), pageSize)
+	switch {
+	case errors.Is(err, account.ErrAccountNotFound):
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 404, Err: err}
+	case errors.Is(err, account.ErrInvalidMovementsCursor):
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: err}
+	case err != nil:
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+
+	protoMovements := make([]*proto.Movement, len(page.Movements))
+	for i, movement := range page.Movements {
+		protoMovements[i] = &proto.Movement{
+			Type:                  string(movement.Type),
+			Amount:                int64(movement.Amount),
+			ResultingBalance:      int64(movement.ResultingBalance),
+			TransferId:            movement.TransferID,
+			CounterpartyAccountId: movement.CounterpartyAccount,
+			HappenedOn:            timestampToProto(movement.Timestamp),
+		}
+	}
+	return &proto.ListMovementsResponse{
+		Movements:     protoMovements,
+		NextPageToken: page.NextCursor,
+	}, nil
+}
+
+// movementTypes are the types of movement the clients can filter by.
+var movementTypes = []account.MovementType{
+	account.MovementTypeDeposit,
+	account.MovementTypeWithdrawal,
+	account.MovementTypeTransferSent,
+	account.MovementTypeTransferReceived,
+	account.MovementTypeTransferRolledBack,
+	account.MovementTypeTransferCompleted,
+}
+
+func accountToProto(account account.ProjectedAccount) *proto.Account {
+	protoAccount := &proto.Account{
+		Id:            account.AccountID,
+		Balance:       int64(account.Balance),
+		Status:        string(account.Status),
+		OpenedOn:      timestampToProto(account.OpenedOn),
+		ClosedOn:      timestampToProto(account.ClosedOn),
+		MovementCount: uint64(len(account.Movements)),
+	}
+	if len(account.Movements) > 0 {
+		protoAccount.LastMovementOn = timestampToProto(account.Movements[len(account.Movements)-1].Timestamp)
+	}
+	return protoAccount
+}
+
 func (s *AccountGRPCServer) AddMoney(ctx context.Context, request *proto.AddMoneyRequest) (*proto.AddMoneyResponse, error) {
+	if err := s.rejectWritesOnFollower(ctx); err != nil {
+		return nil, err
+	}
 	accountID := request.GetAccountId()
 	if accountID == "" {
 		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: errors.New("account id must be provided")}
@@ -75,6 +217,9 @@ func (s *AccountGRPCServer) AddMoney(ctx context.Context, request *proto.AddMone
 }
 
 func (s *AccountGRPCServer) WithdrawMoney(ctx context.Context, request *proto.WithdrawMoneyRequest) (*proto.WithdrawMoneyResponse, error) {
+	if err := s.rejectWritesOnFollower(ctx); err != nil {
+		return nil, err
+	}
 	accountID := request.GetAccountId()
 	amount := int(request.GetAmount())
 	account, err := s.accountService.WithdrawMoneyFromAccount(ctx, accountID, amount)
@@ -91,6 +236,9 @@ func (s *AccountGRPCServer) WithdrawMoney(ctx context.Context, request *proto.Wi
 }
 
 func (s *AccountGRPCServer) CloseAccount(ctx context.Context, request *proto.CloseAccountRequest) (*emptypb.Empty, error) {
+	if err := s.rejectWritesOnFollower(ctx); err != nil {
+		return nil, err
+	}
 	accountID := request.GetAccountId()
 	_, err := s.accountService.CloseAccount(ctx, accountID)
 	if err != nil {
@@ -99,3 +247,145 @@ func (s *AccountGRPCServer) CloseAccount(ctx context.Context, request *proto.Clo
 
 	return &emptypb.Empty{}, nil
 }
+
+func (s *AccountGRPCServer) TransferMoney(ctx context.Context, request *proto.TransferMoneyRequest) (*proto.TransferMoneyResponse, error) {
+	if err := s.rejectWritesOnFollower(ctx); err != nil {
+		return nil, err
+	}
+	if request.GetFromAccountId() == "" || request.GetToAccountId() == "" {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: errors.New("origin and destination account ids must be provided")}
+	}
+	amount := int(request.GetAmount())
+	if amount <= 0 {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: errors.New("amount must be greater than 0")}
+	}
+
+	transferSent, origin, err := s.accountService.TransferMoney(ctx, request.GetFromAccountId(), request.GetToAccountId(), amount)
+	if err != nil {
+		return nil, transferCommandError(err)
+	}
+
+	return &proto.TransferMoneyResponse{
+		Account: &proto.Account{
+			Id:      origin.ID(),
+			Balance: int64(origin.Balance()),
+		},
+		Transfer: &proto.Transfer{
+			Id:            transferSent.ID(),
+			FromAccountId: transferSent.FromAccount(),
+			ToAccountId:   transferSent.ToAccount(),
+			Amount:        int64(transferSent.Amount()),
+			Status:        string(account.TransferStatusSent),
+		},
+	}, nil
+}
+
+func (s *AccountGRPCServer) CancelTransfer(ctx context.Context, request *proto.CancelTransferRequest) (*emptypb.Empty, error) {
+	if err := s.rejectWritesOnFollower(ctx); err != nil {
+		return nil, err
+	}
+	if request.GetTransferId() == "" {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: errors.New("transfer id must be provided")}
+	}
+	reason := request.GetReason()
+	if reason == "" {
+		reason = "cancelled"
+	}
+
+	if err := s.accountService.CancelTransfer(ctx, request.GetTransferId(), reason); err != nil {
+		return nil, transferCommandError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// transferCommandError maps the errors of the transfer commands to the HTTP status the clients can act on:
+// the accounts or transfers that do not exist, the transfers the state of the accounts does not allow,
+// and the concurrent changes to the same accounts, which can be retried.
+func transferCommandError(err error) error {
+	switch {
+	case errors.Is(err, account.ErrAccountNotFound), errors.Is(err, transfer.ErrTransferNotFound):
+		return &runtime.HTTPStatusError{HTTPStatus: http.StatusNotFound, Err: err}
+	case errors.Is(err, account.ErrCannotTransferToSameAccount), errors.Is(err, account.ErrQuantityCannotBeNegative):
+		return &runtime.HTTPStatusError{HTTPStatus: http.StatusBadRequest, Err: err}
+	case errors.Is(err, account.ErrBalanceIsNotEnough), errors.Is(err, account.ErrAccountIsClosed),
+		errors.Is(err, transfer.ErrTransferAlreadyDelivered), errors.Is(err, account.ErrCannotRollbackTransferNotPreviouslySent),
+		errors.Is(err, persistence.ErrUnexpectedVersion):
+		return &runtime.HTTPStatusError{HTTPStatus: http.StatusConflict, Err: err}
+	default:
+		return &runtime.HTTPStatusError{HTTPStatus: http.StatusInternalServerError, Err: err}
+	}
+}
+
+func (s *AccountGRPCServer) ListTransfers(ctx context.Context, request *proto.ListTransfersRequest) (*proto.ListTransfersResponse, error) {
+	status := account.TransferStatus(request.GetStatus())
+	if status != "" && !slices.Contains(transferStatuses, status) {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 400, Err: fmt.Errorf("unknown transfer status '%s'", status)}
+	}
+
+	filter := account.TransferFilter{
+		AccountID: request.GetAccountId(),
+		Status:    status,
+	}
+	if request.GetRequestedFrom() != nil {
+		filter.RequestedFrom = request.GetRequestedFrom().AsTime()
+	}
+	if request.GetRequestedTo() != nil {
+		filter.RequestedTo = request.GetRequestedTo().AsTime()
+	}
+
+	transfers, err := s.transferProjection.For(ctx).Transfers(ctx, filter)
+	if err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+	protoTransfers := make([]*proto.Transfer, len(transfers))
+	for i, transfer := range transfers {
+		protoTransfers[i] = transferToProto(transfer)
+	}
+	return &proto.ListTransfersResponse{
+		Transfers: protoTransfers,
+	}, nil
+}
+
+// transferStatuses are the statuses of transfer the clients can filter by.
+var transferStatuses = []account.TransferStatus{
+	account.TransferStatusRequested,
+	account.TransferStatusSent,
+	account.TransferStatusReceived,
+	account.TransferStatusCompleted,
+	account.TransferStatusRolledBack,
+}
+
+func (s *AccountGRPCServer) GetTransfer(ctx context.Context, request *proto.GetTransferRequest) (*proto.Transfer, error) {
+	transfer, err := s.transferProjection.For(ctx).Transfer(ctx, request.GetTransferId())
+	if errors.Is(err, account.ErrTransferNotFound) {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 404, Err: err}
+	}
+	if err != nil {
+		return nil, &runtime.HTTPStatusError{HTTPStatus: 500, Err: err}
+	}
+	return transferToProto(transfer), nil
+}
+
+func transferToProto(transfer account.ProjectedTransfer) *proto.Transfer {
+	return &proto.Transfer{
+		Id:            transfer.TransferID,
+		FromAccountId: transfer.FromAccount,
+		ToAccountId:   transfer.ToAccount,
+		Amount:        int64(transfer.Amount),
+		Status:        string(transfer.Status),
+		FailureReason: transfer.FailureReason,
+		RequestedOn:   timestampToProto(transfer.RequestedOn),
+		SentOn:        timestampToProto(transfer.SentOn),
+		ReceivedOn:    timestampToProto(transfer.ReceivedOn),
+		CompletedOn:   timestampToProto(transfer.CompletedOn),
+		RolledBackOn:  timestampToProto(transfer.RolledBackOn),
+	}
+}
+
+// timestampToProto returns nil for the zero time, so the dates that have not happened yet are omitted.
+func timestampToProto(timestamp time.Time) *timestamppb.Timestamp {
+	if timestamp.IsZero() {
+		return nil
+	}
+	return timestamppb.New(timestamp)
+}
@@ -0,0 +1,204 @@
+package grpc_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+var _ = Describe("AccountGRPCServer", func() {
+	var (
+		accountService *account.Service
+		server         *grpc.AccountGRPCServer
+		origin         string
+		destination    string
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		accountProjection := projection.NewPerTenant(account.NewProjection)
+		transferProjection := projection.NewPerTenant(account.NewTransferProjection)
+		runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, inmemory.NewProjectionStore())
+		Expect(runtime.Register(accountProjection, projection.ErrorPolicyHalt)).To(Succeed())
+		Expect(runtime.Register(transferProjection, projection.ErrorPolicyHalt)).To(Succeed())
+		runtimeCtx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		accountService = account.NewAccountService(account.NewRepository(eventStore), transfer.NewRepository(eventStore), eventStore)
+		server = grpc.NewAccountGRPCServer(accountService, accountProjection, transferProjection)
+
+		opened, err := server.OpenAccount(ctx, &emptypb.Empty{})
+		Expect(err).ToNot(HaveOccurred())
+		origin = opened.GetAccount().GetId()
+		_, err = server.AddMoney(ctx, &proto.AddMoneyRequest{AccountId: origin, Amount: 100})
+		Expect(err).ToNot(HaveOccurred())
+		opened, err = server.OpenAccount(ctx, &emptypb.Empty{})
+		Expect(err).ToNot(HaveOccurred())
+		destination = opened.GetAccount().GetId()
+	})
+
+	requestTransfer := func(ctx context.Context, amount int64) *proto.TransferMoneyResponse {
+		GinkgoHelper()
+		response, err := server.TransferMoney(ctx, &proto.TransferMoneyRequest{FromAccountId: origin, ToAccountId: destination, Amount: amount})
+		Expect(err).ToNot(HaveOccurred())
+		return response
+	}
+
+	httpStatusOf := func(err error) int {
+		GinkgoHelper()
+		Expect(err).To(BeAssignableToTypeOf(&runtime.HTTPStatusError{}))
+		return err.(*runtime.HTTPStatusError).HTTPStatus
+	}
+
+	It("returns an account with the summary of its movements", func(ctx context.Context) {
+		Eventually(func() (*proto.Account, error) {
+			return server.GetAccount(ctx, &proto.GetAccountRequest{AccountId: origin})
+		}).Should(And(
+			HaveField("Balance", BeEquivalentTo(100)),
+			HaveField("Status", "open"),
+			HaveField("MovementCount", BeEquivalentTo(1)),
+			HaveField("LastMovementOn", Not(BeNil())),
+		))
+
+		_, err := server.GetAccount(ctx, &proto.GetAccountRequest{AccountId: "unknown"})
+		Expect(httpStatusOf(err)).To(Equal(http.StatusNotFound))
+	})
+
+	It("lists the movements of an account in pages", func(ctx context.Context) {
+		requestTransfer(ctx, 30)
+		requestTransfer(ctx, 20)
+		Eventually(func() (*proto.Account, error) {
+			return server.GetAccount(ctx, &proto.GetAccountRequest{AccountId: origin})
+		}).Should(HaveField("MovementCount", BeEquivalentTo(3)))
+
+		first, err := server.ListMovements(ctx, &proto.ListMovementsRequest{AccountId: origin, PageSize: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first.GetMovements()).To(HaveExactElements(
+			HaveField("Type", "Deposit"),
+			And(HaveField("Type", "TransferSent"), HaveField("CounterpartyAccountId", destination), HaveField("ResultingBalance", BeEquivalentTo(70))),
+		))
+
+		last, err := server.ListMovements(ctx, &proto.ListMovementsRequest{AccountId: origin, PageSize: 2, PageToken: first.GetNextPageToken()})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(last.GetMovements()).To(HaveExactElements(HaveField("ResultingBalance", BeEquivalentTo(50))))
+		Expect(last.GetNextPageToken()).To(BeEmpty())
+
+		deposits, err := server.ListMovements(ctx, &proto.ListMovementsRequest{AccountId: origin, Types: []string{"Deposit"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deposits.GetMovements()).To(HaveExactElements(HaveField("Amount", BeEquivalentTo(100))))
+	})
+
+	It("rejects the invalid movement filters", func(ctx context.Context) {
+		_, err := server.ListMovements(ctx, &proto.ListMovementsRequest{AccountId: origin, Types: []string{"Gift"}})
+		Expect(httpStatusOf(err)).To(Equal(http.StatusBadRequest))
+
+		_, err = server.ListMovements(ctx, &proto.ListMovementsRequest{AccountId: origin, PageSize: 1000})
+		Expect(httpStatusOf(err)).To(Equal(http.StatusBadRequest))
+
+		_, err = server.ListMovements(ctx, &proto.ListMovementsRequest{AccountId: origin, PageToken: "invalid"})
+		Expect(httpStatusOf(err)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("requests a transfer, sending the money from the origin account", func(ctx context.Context) {
+		response := requestTransfer(ctx, 30)
+
+		Expect(response.GetAccount().GetBalance()).To(BeEquivalentTo(70))
+		Expect(response.GetTransfer()).To(And(
+			HaveField("FromAccountId", origin),
+			HaveField("ToAccountId", destination),
+			HaveField("Amount", BeEquivalentTo(30)),
+			HaveField("Status", "sent"),
+		))
+		Eventually(func() (*proto.Transfer, error) {
+			return server.GetTransfer(ctx, &proto.GetTransferRequest{TransferId: response.GetTransfer().GetId()})
+		}).Should(HaveField("Status", "sent"))
+	})
+
+	It("lists the transfers with a status", func(ctx context.Context) {
+		transferID := requestTransfer(ctx, 30).GetTransfer().GetId()
+
+		Eventually(func() ([]*proto.Transfer, error) {
+			response, err := server.ListTransfers(ctx, &proto.ListTransfersRequest{Status: "sent"})
+			return response.GetTransfers(), err
+		}).Should(ConsistOf(HaveField("Id", transferID)))
+		Expect(server.ListTransfers(ctx, &proto.ListTransfersRequest{Status: "completed"})).To(HaveField("Transfers", BeEmpty()))
+	})
+
+	It("rejects the unknown transfer statuses", func(ctx context.Context) {
+		_, err := server.ListTransfers(ctx, &proto.ListTransfersRequest{Status: "pending"})
+
+		Expect(httpStatusOf(err)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("rejects a transfer without enough balance", func(ctx context.Context) {
+		_, err := server.TransferMoney(ctx, &proto.TransferMoneyRequest{FromAccountId: origin, ToAccountId: destination, Amount: 200})
+
+		Expect(httpStatusOf(err)).To(Equal(http.StatusConflict))
+	})
+
+	It("rejects a transfer from an account that does not exist", func(ctx context.Context) {
+		_, err := server.TransferMoney(ctx, &proto.TransferMoneyRequest{FromAccountId: "unknown", ToAccountId: destination, Amount: 10})
+
+		Expect(httpStatusOf(err)).To(Equal(http.StatusNotFound))
+	})
+
+	It("cancels a transfer, returning the money to the origin account", func(ctx context.Context) {
+		transferID := requestTransfer(ctx, 30).GetTransfer().GetId()
+
+		_, err := server.CancelTransfer(ctx, &proto.CancelTransferRequest{TransferId: transferID, Reason: "wrong destination"})
+
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() (*proto.Transfer, error) {
+			return server.GetTransfer(ctx, &proto.GetTransferRequest{TransferId: transferID})
+		}).Should(And(HaveField("Status", "rolled-back"), HaveField("FailureReason", "wrong destination")))
+		Eventually(func() ([]*proto.Account, error) {
+			response, err := server.ListAccounts(ctx, &proto.ListAccountsRequest{})
+			return response.GetAccounts(), err
+		}).Should(ContainElement(And(HaveField("Id", origin), HaveField("Balance", BeEquivalentTo(100)))))
+	})
+
+	It("does not cancel a transfer already received", func(ctx context.Context) {
+		transferID := requestTransfer(ctx, 30).GetTransfer().GetId()
+		Expect(accountService.ReceiveTransfer(ctx, transferID)).To(Succeed())
+
+		_, err := server.CancelTransfer(ctx, &proto.CancelTransferRequest{TransferId: transferID})
+
+		Expect(httpStatusOf(err)).To(Equal(http.StatusConflict))
+	})
+
+	It("does not let a cancelled transfer be received", func(ctx context.Context) {
+		transferID := requestTransfer(ctx, 30).GetTransfer().GetId()
+		_, err := server.CancelTransfer(ctx, &proto.CancelTransferRequest{TransferId: transferID})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(accountService.ReceiveTransfer(ctx, transferID)).To(MatchError(transfer.ErrTransferCancelled))
+	})
+
+	It("does not cancel a transfer that does not exist", func(ctx context.Context) {
+		_, err := server.CancelTransfer(ctx, &proto.CancelTransferRequest{TransferId: "unknown"})
+
+		Expect(httpStatusOf(err)).To(Equal(http.StatusNotFound))
+	})
+
+	It("rejects the transfers on a follower", func(ctx context.Context) {
+		server.WithLeaderAddress("leader:8081")
+
+		_, err := server.TransferMoney(ctx, &proto.TransferMoneyRequest{FromAccountId: origin, ToAccountId: destination, Amount: 10})
+		Expect(httpStatusOf(err)).To(Equal(http.StatusMisdirectedRequest))
+		_, err = server.CancelTransfer(ctx, &proto.CancelTransferRequest{TransferId: "some-transfer"})
+		Expect(httpStatusOf(err)).To(Equal(http.StatusMisdirectedRequest))
+	})
+})
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// authorizationHeader is the header with the API key of the clients, as a bearer token.
+const authorizationHeader = "authorization"
+
+var ErrInvalidAPIKey = errors.New("missing or invalid API key")
+
+// TenantResolver resolves the tenant of the requests to the ClerkAPIService from their API key,
+// so a client can only read and write the data of the tenant its key was issued for.
+// Without credentials, every request is served in the DefaultTenant.
+type TenantResolver struct {
+	credentials map[string]domain.TenantID
+}
+
+// NewTenantResolver returns a resolver for the given tenants by API key.
+func NewTenantResolver(credentials map[string]domain.TenantID) *TenantResolver {
+	return &TenantResolver{credentials: credentials}
+}
+
+// Resolve returns the tenant of the API key in the value of the authorization header.
+// It returns ErrInvalidAPIKey if the key is missing or unknown.
+func (r *TenantResolver) Resolve(authorization string) (domain.TenantID, error) {
+	if len(r.credentials) == 0 {
+		return domain.DefaultTenant, nil
+	}
+
+	apiKey, isBearer := strings.CutPrefix(authorization, "Bearer ")
+	if !isBearer {
+		return "", ErrInvalidAPIKey
+	}
+	tenant, exists := r.credentials[apiKey]
+	if !exists {
+		return "", ErrInvalidAPIKey
+	}
+	return tenant, nil
+}
+
+// WithTenant returns the context scoped to the tenant of the API key in the value of the authorization header.
+func (r *TenantResolver) WithTenant(ctx context.Context, authorization string) (context.Context, error) {
+	tenant, err := r.Resolve(authorization)
+	if err != nil {
+		return nil, err
+	}
+	return domain.WithTenant(ctx, tenant), nil
+}
+
+// UnaryInterceptor scopes the calls to the ClerkAPIService to the tenant of their API key,
+// rejecting them as Unauthenticated if it is not valid. The calls to the other services are not scoped.
+func (r *TenantResolver) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	servicePrefix := "/" + proto.ClerkAPIService_ServiceDesc.ServiceName + "/"
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(ctx, request)
+		}
+
+		var authorization string
+		if values := metadata.ValueFromIncomingContext(ctx, authorizationHeader); len(values) > 0 {
+			authorization = values[0]
+		}
+		tenantCtx, err := r.WithTenant(ctx, authorization)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(tenantCtx, request)
+	}
+}
@@ -0,0 +1,89 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
+	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+)
+
+var _ = Describe("TenantResolver", func() {
+	var client proto.ClerkAPIServiceClient
+
+	BeforeEach(func() {
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		accountProjection := projection.NewPerTenant(account.NewProjection)
+		runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, inmemory.NewProjectionStore())
+		Expect(runtime.Register(accountProjection, projection.ErrorPolicyHalt)).To(Succeed())
+		runtimeCtx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		resolver := grpc.NewTenantResolver(map[string]domain.TenantID{
+			"key-a": "tenant-a",
+			"key-b": "tenant-b",
+		})
+		accountService := account.NewAccountService(account.NewRepository(eventStore), nil, eventStore)
+		listener := bufconn.Listen(1024 * 1024)
+		server := gogrpc.NewServer(gogrpc.UnaryInterceptor(resolver.UnaryInterceptor()))
+		proto.RegisterClerkAPIServiceServer(server, grpc.NewAccountGRPCServer(accountService, accountProjection, nil))
+		go server.Serve(listener)
+		DeferCleanup(server.Stop)
+
+		conn, err := gogrpc.NewClient("passthrough:///clerk",
+			gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		client = proto.NewClerkAPIServiceClient(conn)
+	})
+
+	withAPIKey := func(ctx context.Context, apiKey string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+apiKey)
+	}
+
+	It("rejects the calls without a valid API key", func(ctx context.Context) {
+		_, err := client.OpenAccount(ctx, &emptypb.Empty{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		_, err = client.OpenAccount(withAPIKey(ctx, "unknown-key"), &emptypb.Empty{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+	})
+
+	It("serves every client the accounts of its tenant only", func(ctx context.Context) {
+		opened, err := client.OpenAccount(withAPIKey(ctx, "key-a"), &emptypb.Empty{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() ([]*proto.Account, error) {
+			response, err := client.ListAccounts(withAPIKey(ctx, "key-a"), &proto.ListAccountsRequest{})
+			return response.GetAccounts(), err
+		}).Should(HaveExactElements(HaveField("Id", opened.GetAccount().GetId())))
+		Expect(client.ListAccounts(withAPIKey(ctx, "key-b"), &proto.ListAccountsRequest{})).To(HaveField("Accounts", BeEmpty()))
+	})
+
+	It("does not let a client write to the accounts of another tenant", func(ctx context.Context) {
+		opened, err := client.OpenAccount(withAPIKey(ctx, "key-a"), &emptypb.Empty{})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.AddMoney(withAPIKey(ctx, "key-b"), &proto.AddMoneyRequest{AccountId: opened.GetAccount().GetId(), Amount: 10})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,19 +3,60 @@ package http
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/tembleking/myBankSourcing/pkg/account"
 	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
 	"github.com/tembleking/myBankSourcing/pkg/application/proto"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
 )
 
-func NewHTTPServer(ctx context.Context, accountService *account.Service, accountProjection *account.Projection) http.Handler {
+// NewHTTPServer returns the gateway of the ClerkAPIService. The ClerkAdminAPIService works on the data of every tenant,
+// so it is only served by the admin gRPC server, on its own listener.
+func NewHTTPServer(ctx context.Context, accountService *account.Service, accountProjection *projection.PerTenant[*account.Projection], transferProjection *projection.PerTenant[*account.TransferProjection], follower *grpc.Follower, tenantResolver *grpc.TenantResolver) http.Handler {
+	accountServer := grpc.NewAccountGRPCServer(accountService, accountProjection, transferProjection)
+	if follower != nil {
+		accountServer.WithLeaderAddress(follower.LeaderAddress())
+	}
+
 	mux := runtime.NewServeMux()
-	err := proto.RegisterClerkAPIServiceHandlerServer(ctx, mux, grpc.NewAccountGRPCServer(accountService, accountProjection))
+	err := proto.RegisterClerkAPIServiceHandlerServer(ctx, mux, accountServer)
 	if err != nil {
 		panic(err)
 	}
-	return mux
+	return withTenant(mux, tenantResolver)
+}
+
+// tenantScopedPaths are the paths of the ClerkAPIService, which are served in the tenant of the API key of the request.
+var tenantScopedPaths = []string{"/api/account/", "/api/transfer/"}
+
+// withTenant scopes the requests to the ClerkAPIService to the tenant of their API key,
+// since the gateway calls the server directly, without the interceptors of the gRPC server.
+func withTenant(mux *runtime.ServeMux, tenantResolver *grpc.TenantResolver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasAnyPrefix(r.URL.Path, tenantScopedPaths) {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := tenantResolver.WithTenant(r.Context(), r.Header.Get("Authorization"))
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, &runtime.JSONPb{}, w, r, status.Error(codes.Unauthenticated, err.Error()))
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
 }
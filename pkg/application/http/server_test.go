@@ -0,0 +1,95 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/application/grpc"
+	apphttp "github.com/tembleking/myBankSourcing/pkg/application/http"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+var _ = Describe("HTTP server routing", func() {
+	var (
+		handler    http.Handler
+		transferID string
+	)
+
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		GinkgoHelper()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return recorder
+	}
+
+	decode := func(recorder *httptest.ResponseRecorder) map[string]any {
+		GinkgoHelper()
+		var body map[string]any
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &body)).To(Succeed())
+		return body
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		accountProjection := projection.NewPerTenant(account.NewProjection)
+		transferProjection := projection.NewPerTenant(account.NewTransferProjection)
+		runtime := projection.NewRuntime(eventStore.ReadOnlyEventStore, inmemory.NewProjectionStore())
+		Expect(runtime.Register(accountProjection, projection.ErrorPolicyHalt)).To(Succeed())
+		Expect(runtime.Register(transferProjection, projection.ErrorPolicyHalt)).To(Succeed())
+		runtimeCtx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		accountService := account.NewAccountService(account.NewRepository(eventStore), transfer.NewRepository(eventStore), eventStore)
+		handler = apphttp.NewHTTPServer(runtimeCtx, accountService, accountProjection, transferProjection, nil, grpc.NewTenantResolver(nil))
+
+		origin, err := accountService.OpenAccount(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = accountService.DepositMoneyIntoAccount(ctx, origin.ID(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		destination, err := accountService.OpenAccount(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		response := request(http.MethodPost, "/api/transfer/v1/transfers", `{"fromAccountId": "`+origin.ID()+`", "toAccountId": "`+destination.ID()+`", "amount": 30}`)
+		Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+		transferID = decode(response)["transfer"].(map[string]any)["id"].(string)
+	})
+
+	It("lists the transfers", func() {
+		Eventually(func() map[string]any {
+			response := request(http.MethodGet, "/api/transfer/v1/transfers", "")
+			Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+			return decode(response)
+		}).Should(HaveKeyWithValue("transfers", ContainElement(HaveKeyWithValue("id", transferID))))
+	})
+
+	It("returns a transfer", func() {
+		Eventually(func() map[string]any {
+			response := request(http.MethodGet, "/api/transfer/v1/transfers/"+transferID, "")
+			return decode(response)
+		}).Should(HaveKeyWithValue("id", transferID))
+	})
+
+	It("cancels a transfer", func() {
+		response := request(http.MethodPost, "/api/transfer/v1/transfers/"+transferID+"/cancel", `{"reason": "wrong destination"}`)
+		Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+
+		Eventually(func() map[string]any {
+			return decode(request(http.MethodGet, "/api/transfer/v1/transfers/"+transferID, ""))
+		}).Should(HaveKeyWithValue("status", "rolled-back"))
+	})
+	It("does not serve the admin API", func() {
+		Expect(request(http.MethodGet, "/api/admin/v1/projections", "").Code).To(Equal(http.StatusNotFound))
+		Expect(request(http.MethodGet, "/api/projections/v1/status", "").Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -20,11 +20,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ClerkAPIService_OpenAccount_FullMethodName   = "/ClerkAPIService/OpenAccount"
-	ClerkAPIService_ListAccounts_FullMethodName  = "/ClerkAPIService/ListAccounts"
-	ClerkAPIService_AddMoney_FullMethodName      = "/ClerkAPIService/AddMoney"
-	ClerkAPIService_WithdrawMoney_FullMethodName = "/ClerkAPIService/WithdrawMoney"
-	ClerkAPIService_CloseAccount_FullMethodName  = "/ClerkAPIService/CloseAccount"
+	ClerkAPIService_OpenAccount_FullMethodName    = "/ClerkAPIService/OpenAccount"
+	ClerkAPIService_ListAccounts_FullMethodName   = "/ClerkAPIService/ListAccounts"
+	ClerkAPIService_GetAccount_FullMethodName     = "/ClerkAPIService/GetAccount"
+	ClerkAPIService_ListMovements_FullMethodName  = "/ClerkAPIService/ListMovements"
+	ClerkAPIService_AddMoney_FullMethodName       = "/ClerkAPIService/AddMoney"
+	ClerkAPIService_WithdrawMoney_FullMethodName  = "/ClerkAPIService/WithdrawMoney"
+	ClerkAPIService_CloseAccount_FullMethodName   = "/ClerkAPIService/CloseAccount"
+	ClerkAPIService_TransferMoney_FullMethodName  = "/ClerkAPIService/TransferMoney"
+	ClerkAPIService_CancelTransfer_FullMethodName = "/ClerkAPIService/CancelTransfer"
+	ClerkAPIService_ListTransfers_FullMethodName  = "/ClerkAPIService/ListTransfers"
+	ClerkAPIService_GetTransfer_FullMethodName    = "/ClerkAPIService/GetTransfer"
 )
 
 // ClerkAPIServiceClient is the client API for ClerkAPIService service.
@@ -35,14 +41,26 @@ const (
 type ClerkAPIServiceClient interface {
 	// Creates a new account and returns it
 	OpenAccount(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*OpenAccountResponse, error)
-	// Returns the list of open accounts
-	ListAccounts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	// Returns the list of accounts, by default the open ones
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	// Returns an account with the summary of its movements
+	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	// Returns a page of the movements of an account matching the filters, in the order they happened
+	ListMovements(ctx context.Context, in *ListMovementsRequest, opts ...grpc.CallOption) (*ListMovementsResponse, error)
 	// Adds money to an account
 	AddMoney(ctx context.Context, in *AddMoneyRequest, opts ...grpc.CallOption) (*AddMoneyResponse, error)
 	// Removes money from an account
 	WithdrawMoney(ctx context.Context, in *WithdrawMoneyRequest, opts ...grpc.CallOption) (*WithdrawMoneyResponse, error)
 	// Close an account
 	CloseAccount(ctx context.Context, in *CloseAccountRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Requests a transfer between two accounts, sending the money from the origin account
+	TransferMoney(ctx context.Context, in *TransferMoneyRequest, opts ...grpc.CallOption) (*TransferMoneyResponse, error)
+	// Cancels a transfer that has not been received yet, returning the money to the origin account
+	CancelTransfer(ctx context.Context, in *CancelTransferRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Returns the transfers matching the filters, sorted by request date
+	ListTransfers(ctx context.Context, in *ListTransfersRequest, opts ...grpc.CallOption) (*ListTransfersResponse, error)
+	// Returns a transfer
+	GetTransfer(ctx context.Context, in *GetTransferRequest, opts ...grpc.CallOption) (*Transfer, error)
 }
 
 type clerkAPIServiceClient struct {
@@ -63,7 +81,7 @@ func (c *clerkAPIServiceClient) OpenAccount(ctx context.Context, in *emptypb.Emp
 	return out, nil
 }
 
-func (c *clerkAPIServiceClient) ListAccounts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+func (c *clerkAPIServiceClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListAccountsResponse)
 	err := c.cc.Invoke(ctx, ClerkAPIService_ListAccounts_FullMethodName, in, out, cOpts...)
@@ -73,6 +91,26 @@ func (c *clerkAPIServiceClient) ListAccounts(ctx context.Context, in *emptypb.Em
 	return out, nil
 }
 
+func (c *clerkAPIServiceClient) GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, ClerkAPIService_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAPIServiceClient) ListMovements(ctx context.Context, in *ListMovementsRequest, opts ...grpc.CallOption) (*ListMovementsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMovementsResponse)
+	err := c.cc.Invoke(ctx, ClerkAPIService_ListMovements_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *clerkAPIServiceClient) AddMoney(ctx context.Context, in *AddMoneyRequest, opts ...grpc.CallOption) (*AddMoneyResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AddMoneyResponse)
@@ -103,6 +141,46 @@ func (c *clerkAPIServiceClient) CloseAccount(ctx context.Context, in *CloseAccou
 	return out, nil
 }
 
+func (c *clerkAPIServiceClient) TransferMoney(ctx context.Context, in *TransferMoneyRequest, opts ...grpc.CallOption) (*TransferMoneyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferMoneyResponse)
+	err := c.cc.Invoke(ctx, ClerkAPIService_TransferMoney_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAPIServiceClient) CancelTransfer(ctx context.Context, in *CancelTransferRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ClerkAPIService_CancelTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAPIServiceClient) ListTransfers(ctx context.Context, in *ListTransfersRequest, opts ...grpc.CallOption) (*ListTransfersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransfersResponse)
+	err := c.cc.Invoke(ctx, ClerkAPIService_ListTransfers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAPIServiceClient) GetTransfer(ctx context.Context, in *GetTransferRequest, opts ...grpc.CallOption) (*Transfer, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transfer)
+	err := c.cc.Invoke(ctx, ClerkAPIService_GetTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ClerkAPIServiceServer is the server API for ClerkAPIService service.
 // All implementations should embed UnimplementedClerkAPIServiceServer
 // for forward compatibility.
@@ -111,14 +189,26 @@ func (c *clerkAPIServiceClient) CloseAccount(ctx context.Context, in *CloseAccou
 type ClerkAPIServiceServer interface {
 	// Creates a new account and returns it
 	OpenAccount(context.Context, *emptypb.Empty) (*OpenAccountResponse, error)
-	// Returns the list of open accounts
-	ListAccounts(context.Context, *emptypb.Empty) (*ListAccountsResponse, error)
+	// Returns the list of accounts, by default the open ones
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	// Returns an account with the summary of its movements
+	GetAccount(context.Context, *GetAccountRequest) (*Account, error)
+	// Returns a page of the movements of an account matching the filters, in the order they happened
+	ListMovements(context.Context, *ListMovementsRequest) (*ListMovementsResponse, error)
 	// Adds money to an account
 	AddMoney(context.Context, *AddMoneyRequest) (*AddMoneyResponse, error)
 	// Removes money from an account
 	WithdrawMoney(context.Context, *WithdrawMoneyRequest) (*WithdrawMoneyResponse, error)
 	// Close an account
 	CloseAccount(context.Context, *CloseAccountRequest) (*emptypb.Empty, error)
+	// Requests a transfer between two accounts, sending the money from the origin account
+	TransferMoney(context.Context, *TransferMoneyRequest) (*TransferMoneyResponse, error)
+	// Cancels a transfer that has not been received yet, returning the money to the origin account
+	CancelTransfer(context.Context, *CancelTransferRequest) (*emptypb.Empty, error)
+	// Returns the transfers matching the filters, sorted by request date
+	ListTransfers(context.Context, *ListTransfersRequest) (*ListTransfersResponse, error)
+	// Returns a transfer
+	GetTransfer(context.Context, *GetTransferRequest) (*Transfer, error)
 }
 
 // UnimplementedClerkAPIServiceServer should be embedded to have
@@ -131,9 +221,15 @@ type UnimplementedClerkAPIServiceServer struct{}
 func (UnimplementedClerkAPIServiceServer) OpenAccount(context.Context, *emptypb.Empty) (*OpenAccountResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method OpenAccount not implemented")
 }
-func (UnimplementedClerkAPIServiceServer) ListAccounts(context.Context, *emptypb.Empty) (*ListAccountsResponse, error) {
+func (UnimplementedClerkAPIServiceServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
 }
+func (UnimplementedClerkAPIServiceServer) GetAccount(context.Context, *GetAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedClerkAPIServiceServer) ListMovements(context.Context, *ListMovementsRequest) (*ListMovementsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMovements not implemented")
+}
 func (UnimplementedClerkAPIServiceServer) AddMoney(context.Context, *AddMoneyRequest) (*AddMoneyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddMoney not implemented")
 }
@@ -143,6 +239,18 @@ func (UnimplementedClerkAPIServiceServer) WithdrawMoney(context.Context, *Withdr
 func (UnimplementedClerkAPIServiceServer) CloseAccount(context.Context, *CloseAccountRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CloseAccount not implemented")
 }
+func (UnimplementedClerkAPIServiceServer) TransferMoney(context.Context, *TransferMoneyRequest) (*TransferMoneyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferMoney not implemented")
+}
+func (UnimplementedClerkAPIServiceServer) CancelTransfer(context.Context, *CancelTransferRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTransfer not implemented")
+}
+func (UnimplementedClerkAPIServiceServer) ListTransfers(context.Context, *ListTransfersRequest) (*ListTransfersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransfers not implemented")
+}
+func (UnimplementedClerkAPIServiceServer) GetTransfer(context.Context, *GetTransferRequest) (*Transfer, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransfer not implemented")
+}
 func (UnimplementedClerkAPIServiceServer) testEmbeddedByValue() {}
 
 // UnsafeClerkAPIServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -182,7 +290,7 @@ func _ClerkAPIService_OpenAccount_Handler(srv interface{}, ctx context.Context,
 }
 
 func _ClerkAPIService_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(ListAccountsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -194,7 +302,43 @@ func _ClerkAPIService_ListAccounts_Handler(srv interface{}, ctx context.Context,
 		FullMethod: ClerkAPIService_ListAccounts_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ClerkAPIServiceServer).ListAccounts(ctx, req.(*emptypb.Empty))
+		return srv.(ClerkAPIServiceServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAPIService_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAPIServiceServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAPIService_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAPIServiceServer).GetAccount(ctx, req.(*GetAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAPIService_ListMovements_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMovementsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAPIServiceServer).ListMovements(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAPIService_ListMovements_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAPIServiceServer).ListMovements(ctx, req.(*ListMovementsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -253,6 +397,78 @@ func _ClerkAPIService_CloseAccount_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClerkAPIService_TransferMoney_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferMoneyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAPIServiceServer).TransferMoney(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAPIService_TransferMoney_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAPIServiceServer).TransferMoney(ctx, req.(*TransferMoneyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAPIService_CancelTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAPIServiceServer).CancelTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAPIService_CancelTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAPIServiceServer).CancelTransfer(ctx, req.(*CancelTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAPIService_ListTransfers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransfersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAPIServiceServer).ListTransfers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAPIService_ListTransfers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAPIServiceServer).ListTransfers(ctx, req.(*ListTransfersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAPIService_GetTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAPIServiceServer).GetTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAPIService_GetTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAPIServiceServer).GetTransfer(ctx, req.(*GetTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ClerkAPIService_ServiceDesc is the grpc.ServiceDesc for ClerkAPIService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -268,6 +484,14 @@ var ClerkAPIService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListAccounts",
 			Handler:    _ClerkAPIService_ListAccounts_Handler,
 		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _ClerkAPIService_GetAccount_Handler,
+		},
+		{
+			MethodName: "ListMovements",
+			Handler:    _ClerkAPIService_ListMovements_Handler,
+		},
 		{
 			MethodName: "AddMoney",
 			Handler:    _ClerkAPIService_AddMoney_Handler,
@@ -280,7 +504,598 @@ var ClerkAPIService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CloseAccount",
 			Handler:    _ClerkAPIService_CloseAccount_Handler,
 		},
+		{
+			MethodName: "TransferMoney",
+			Handler:    _ClerkAPIService_TransferMoney_Handler,
+		},
+		{
+			MethodName: "CancelTransfer",
+			Handler:    _ClerkAPIService_CancelTransfer_Handler,
+		},
+		{
+			MethodName: "ListTransfers",
+			Handler:    _ClerkAPIService_ListTransfers_Handler,
+		},
+		{
+			MethodName: "GetTransfer",
+			Handler:    _ClerkAPIService_GetTransfer_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "service.proto",
+}
+
+const (
+	ClerkAdminAPIService_ListProjections_FullMethodName      = "/ClerkAdminAPIService/ListProjections"
+	ClerkAdminAPIService_GetProjectionStatus_FullMethodName  = "/ClerkAdminAPIService/GetProjectionStatus"
+	ClerkAdminAPIService_RebuildProjection_FullMethodName    = "/ClerkAdminAPIService/RebuildProjection"
+	ClerkAdminAPIService_ResetProjection_FullMethodName      = "/ClerkAdminAPIService/ResetProjection"
+	ClerkAdminAPIService_RestartProjection_FullMethodName    = "/ClerkAdminAPIService/RestartProjection"
+	ClerkAdminAPIService_ForgetSubject_FullMethodName        = "/ClerkAdminAPIService/ForgetSubject"
+	ClerkAdminAPIService_VerifyEventLog_FullMethodName       = "/ClerkAdminAPIService/VerifyEventLog"
+	ClerkAdminAPIService_CreateBackup_FullMethodName         = "/ClerkAdminAPIService/CreateBackup"
+	ClerkAdminAPIService_ListBackups_FullMethodName          = "/ClerkAdminAPIService/ListBackups"
+	ClerkAdminAPIService_GetReplicationStatus_FullMethodName = "/ClerkAdminAPIService/GetReplicationStatus"
+)
+
+// ClerkAdminAPIServiceClient is the client API for ClerkAdminAPIService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Administration API
+type ClerkAdminAPIServiceClient interface {
+	// Returns the status of every projection
+	ListProjections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListProjectionsResponse, error)
+	// Returns the status of a projection
+	GetProjectionStatus(ctx context.Context, in *GetProjectionStatusRequest, opts ...grpc.CallOption) (*ProjectionStatus, error)
+	// Replays a projection from the beginning into a shadow copy, and swaps it once it has caught up
+	RebuildProjection(ctx context.Context, in *RebuildProjectionRequest, opts ...grpc.CallOption) (*ProjectionStatus, error)
+	// Discards the state of a projection and replays it in place
+	ResetProjection(ctx context.Context, in *ResetProjectionRequest, opts ...grpc.CallOption) (*ProjectionStatus, error)
+	// Resumes a halted projection from the last event it processed
+	RestartProjection(ctx context.Context, in *RestartProjectionRequest, opts ...grpc.CallOption) (*ProjectionStatus, error)
+	// Deletes the key of the personal data of a subject, so its personal data is read as redacted from then on
+	ForgetSubject(ctx context.Context, in *ForgetSubjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Walks the event log recomputing the hash chain, and reports the first broken link
+	VerifyEventLog(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*VerifyEventLogResponse, error)
+	// Takes a consistent backup of the event store while it keeps serving requests
+	CreateBackup(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Backup, error)
+	// Returns the backups kept, from the oldest to the newest
+	ListBackups(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListBackupsResponse, error)
+	// Returns whether this server is the leader or a follower replica, and how far behind the leader a follower is
+	GetReplicationStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ReplicationStatus, error)
+}
+
+type clerkAdminAPIServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClerkAdminAPIServiceClient(cc grpc.ClientConnInterface) ClerkAdminAPIServiceClient {
+	return &clerkAdminAPIServiceClient{cc}
+}
+
+func (c *clerkAdminAPIServiceClient) ListProjections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListProjectionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProjectionsResponse)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_ListProjections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) GetProjectionStatus(ctx context.Context, in *GetProjectionStatusRequest, opts ...grpc.CallOption) (*ProjectionStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProjectionStatus)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_GetProjectionStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) RebuildProjection(ctx context.Context, in *RebuildProjectionRequest, opts ...grpc.CallOption) (*ProjectionStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProjectionStatus)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_RebuildProjection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) ResetProjection(ctx context.Context, in *ResetProjectionRequest, opts ...grpc.CallOption) (*ProjectionStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProjectionStatus)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_ResetProjection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) RestartProjection(ctx context.Context, in *RestartProjectionRequest, opts ...grpc.CallOption) (*ProjectionStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProjectionStatus)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_RestartProjection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) ForgetSubject(ctx context.Context, in *ForgetSubjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_ForgetSubject_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) VerifyEventLog(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*VerifyEventLogResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyEventLogResponse)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_VerifyEventLog_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) CreateBackup(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Backup, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Backup)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_CreateBackup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) ListBackups(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListBackupsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBackupsResponse)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_ListBackups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clerkAdminAPIServiceClient) GetReplicationStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ReplicationStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicationStatus)
+	err := c.cc.Invoke(ctx, ClerkAdminAPIService_GetReplicationStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClerkAdminAPIServiceServer is the server API for ClerkAdminAPIService service.
+// All implementations should embed UnimplementedClerkAdminAPIServiceServer
+// for forward compatibility.
+//
+// Administration API
+type ClerkAdminAPIServiceServer interface {
+	// Returns the status of every projection
+	ListProjections(context.Context, *emptypb.Empty) (*ListProjectionsResponse, error)
+	// Returns the status of a projection
+	GetProjectionStatus(context.Context, *GetProjectionStatusRequest) (*ProjectionStatus, error)
+	// Replays a projection from the beginning into a shadow copy, and swaps it once it has caught up
+	RebuildProjection(context.Context, *RebuildProjectionRequest) (*ProjectionStatus, error)
+	// Discards the state of a projection and replays it in place
+	ResetProjection(context.Context, *ResetProjectionRequest) (*ProjectionStatus, error)
+	// Resumes a halted projection from the last event it processed
+	RestartProjection(context.Context, *RestartProjectionRequest) (*ProjectionStatus, error)
+	// Deletes the key of the personal data of a subject, so its personal data is read as redacted from then on
+	ForgetSubject(context.Context, *ForgetSubjectRequest) (*emptypb.Empty, error)
+	// Walks the event log recomputing the hash chain, and reports the first broken link
+	VerifyEventLog(context.Context, *emptypb.Empty) (*VerifyEventLogResponse, error)
+	// Takes a consistent backup of the event store while it keeps serving requests
+	CreateBackup(context.Context, *emptypb.Empty) (*Backup, error)
+	// Returns the backups kept, from the oldest to the newest
+	ListBackups(context.Context, *emptypb.Empty) (*ListBackupsResponse, error)
+	// Returns whether this server is the leader or a follower replica, and how far behind the leader a follower is
+	GetReplicationStatus(context.Context, *emptypb.Empty) (*ReplicationStatus, error)
+}
+
+// UnimplementedClerkAdminAPIServiceServer should be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClerkAdminAPIServiceServer struct{}
+
+func (UnimplementedClerkAdminAPIServiceServer) ListProjections(context.Context, *emptypb.Empty) (*ListProjectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProjections not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) GetProjectionStatus(context.Context, *GetProjectionStatusRequest) (*ProjectionStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProjectionStatus not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) RebuildProjection(context.Context, *RebuildProjectionRequest) (*ProjectionStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RebuildProjection not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) ResetProjection(context.Context, *ResetProjectionRequest) (*ProjectionStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetProjection not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) RestartProjection(context.Context, *RestartProjectionRequest) (*ProjectionStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestartProjection not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) ForgetSubject(context.Context, *ForgetSubjectRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForgetSubject not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) VerifyEventLog(context.Context, *emptypb.Empty) (*VerifyEventLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEventLog not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) CreateBackup(context.Context, *emptypb.Empty) (*Backup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBackup not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) ListBackups(context.Context, *emptypb.Empty) (*ListBackupsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBackups not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) GetReplicationStatus(context.Context, *emptypb.Empty) (*ReplicationStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReplicationStatus not implemented")
+}
+func (UnimplementedClerkAdminAPIServiceServer) testEmbeddedByValue() {}
+
+// UnsafeClerkAdminAPIServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClerkAdminAPIServiceServer will
+// result in compilation errors.
+type UnsafeClerkAdminAPIServiceServer interface {
+	mustEmbedUnimplementedClerkAdminAPIServiceServer()
+}
+
+func RegisterClerkAdminAPIServiceServer(s grpc.ServiceRegistrar, srv ClerkAdminAPIServiceServer) {
+	// If the following call pancis, it indicates UnimplementedClerkAdminAPIServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ClerkAdminAPIService_ServiceDesc, srv)
+}
+
+func _ClerkAdminAPIService_ListProjections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).ListProjections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_ListProjections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).ListProjections(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_GetProjectionStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProjectionStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).GetProjectionStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_GetProjectionStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).GetProjectionStatus(ctx, req.(*GetProjectionStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_RebuildProjection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebuildProjectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).RebuildProjection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_RebuildProjection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).RebuildProjection(ctx, req.(*RebuildProjectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_ResetProjection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetProjectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).ResetProjection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_ResetProjection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).ResetProjection(ctx, req.(*ResetProjectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_RestartProjection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestartProjectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).RestartProjection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_RestartProjection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).RestartProjection(ctx, req.(*RestartProjectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_ForgetSubject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForgetSubjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).ForgetSubject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_ForgetSubject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).ForgetSubject(ctx, req.(*ForgetSubjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_VerifyEventLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).VerifyEventLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_VerifyEventLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).VerifyEventLog(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_CreateBackup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).CreateBackup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_CreateBackup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).CreateBackup(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_ListBackups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).ListBackups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_ListBackups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).ListBackups(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClerkAdminAPIService_GetReplicationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClerkAdminAPIServiceServer).GetReplicationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClerkAdminAPIService_GetReplicationStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClerkAdminAPIServiceServer).GetReplicationStatus(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClerkAdminAPIService_ServiceDesc is the grpc.ServiceDesc for ClerkAdminAPIService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClerkAdminAPIService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ClerkAdminAPIService",
+	HandlerType: (*ClerkAdminAPIServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListProjections",
+			Handler:    _ClerkAdminAPIService_ListProjections_Handler,
+		},
+		{
+			MethodName: "GetProjectionStatus",
+			Handler:    _ClerkAdminAPIService_GetProjectionStatus_Handler,
+		},
+		{
+			MethodName: "RebuildProjection",
+			Handler:    _ClerkAdminAPIService_RebuildProjection_Handler,
+		},
+		{
+			MethodName: "ResetProjection",
+			Handler:    _ClerkAdminAPIService_ResetProjection_Handler,
+		},
+		{
+			MethodName: "RestartProjection",
+			Handler:    _ClerkAdminAPIService_RestartProjection_Handler,
+		},
+		{
+			MethodName: "ForgetSubject",
+			Handler:    _ClerkAdminAPIService_ForgetSubject_Handler,
+		},
+		{
+			MethodName: "VerifyEventLog",
+			Handler:    _ClerkAdminAPIService_VerifyEventLog_Handler,
+		},
+		{
+			MethodName: "CreateBackup",
+			Handler:    _ClerkAdminAPIService_CreateBackup_Handler,
+		},
+		{
+			MethodName: "ListBackups",
+			Handler:    _ClerkAdminAPIService_ListBackups_Handler,
+		},
+		{
+			MethodName: "GetReplicationStatus",
+			Handler:    _ClerkAdminAPIService_GetReplicationStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
 }
+
+const (
+	ClerkReplicationService_StreamEvents_FullMethodName = "/ClerkReplicationService/StreamEvents"
+)
+
+// ClerkReplicationServiceClient is the client API for ClerkReplicationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Replication of the event log to the follower replicas
+type ClerkReplicationServiceClient interface {
+	// Streams the events appended after the given one, and keeps streaming the new ones as they are appended
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamEventsResponse], error)
+}
+
+type clerkReplicationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClerkReplicationServiceClient(cc grpc.ClientConnInterface) ClerkReplicationServiceClient {
+	return &clerkReplicationServiceClient{cc}
+}
+
+func (c *clerkReplicationServiceClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamEventsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ClerkReplicationService_ServiceDesc.Streams[0], ClerkReplicationService_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, StreamEventsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ClerkReplicationService_StreamEventsClient = grpc.ServerStreamingClient[StreamEventsResponse]
+
+// ClerkReplicationServiceServer is the server API for ClerkReplicationService service.
+// All implementations should embed UnimplementedClerkReplicationServiceServer
+// for forward compatibility.
+//
+// Replication of the event log to the follower replicas
+type ClerkReplicationServiceServer interface {
+	// Streams the events appended after the given one, and keeps streaming the new ones as they are appended
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[StreamEventsResponse]) error
+}
+
+// UnimplementedClerkReplicationServiceServer should be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClerkReplicationServiceServer struct{}
+
+func (UnimplementedClerkReplicationServiceServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[StreamEventsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedClerkReplicationServiceServer) testEmbeddedByValue() {}
+
+// UnsafeClerkReplicationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClerkReplicationServiceServer will
+// result in compilation errors.
+type UnsafeClerkReplicationServiceServer interface {
+	mustEmbedUnimplementedClerkReplicationServiceServer()
+}
+
+func RegisterClerkReplicationServiceServer(s grpc.ServiceRegistrar, srv ClerkReplicationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedClerkReplicationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ClerkReplicationService_ServiceDesc, srv)
+}
+
+func _ClerkReplicationService_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClerkReplicationServiceServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, StreamEventsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ClerkReplicationService_StreamEventsServer = grpc.ServerStreamingServer[StreamEventsResponse]
+
+// ClerkReplicationService_ServiceDesc is the grpc.ServiceDesc for ClerkReplicationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClerkReplicationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ClerkReplicationService",
+	HandlerType: (*ClerkReplicationServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _ClerkReplicationService_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "context"
+
+// TenantID identifies the legal entity that owns the data. Every tenant has its own streams and read models.
+type TenantID string
+
+// DefaultTenant owns the data of the deployments with a single tenant,
+// and the data stored before the tenants were introduced.
+const DefaultTenant TenantID = ""
+
+type tenantContextKey struct{}
+
+type tenantScope struct {
+	tenant     TenantID
+	allTenants bool
+}
+
+// WithTenant returns a context scoped to the tenant, so the stores only read and write the data of the tenant.
+func WithTenant(ctx context.Context, tenant TenantID) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantScope{tenant: tenant})
+}
+
+// WithAllTenants returns a context that reads the data of every tenant and appends the events to the tenant they carry.
+// It is meant for the processes working on the whole store, like the projections or the backups,
+// and must never be derived from a request of the public API.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantScope{allTenants: true})
+}
+
+// TenantFromContext returns the tenant the context is scoped to, which is the DefaultTenant if it has none.
+func TenantFromContext(ctx context.Context) TenantID {
+	scope, _ := ctx.Value(tenantContextKey{}).(tenantScope)
+	return scope.tenant
+}
+
+// IsAllTenants reports whether the context was returned by WithAllTenants.
+func IsAllTenants(ctx context.Context) bool {
+	scope, _ := ctx.Value(tenantContextKey{}).(tenantScope)
+	return scope.allTenants
+}
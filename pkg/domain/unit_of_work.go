@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrAggregateAlreadyRegistered = errors.New("aggregate already registered in the unit of work")
+	ErrUnitOfWorkCommitted        = errors.New("unit of work already committed")
+)
+
+// UnitOfWork commits the changes of several aggregates atomically, so either all their new events are stored or none.
+//
+// Every aggregate is still its own consistency boundary, so a unit of work is only allowed when a single command
+// must change several aggregates to keep an invariant that spans them, like requesting a transfer and debiting its
+// origin account, so the money never leaves the account without a transfer or the other way around.
+// All the aggregates are committed in the store and tenant of the unit of work, and the changes that can follow later,
+// like crediting the destination account of a transfer, are still made by their own commands reacting to the events.
+type UnitOfWork interface {
+	// Register adds the aggregates to the unit of work. It returns ErrAggregateAlreadyRegistered
+	// if another instance of an aggregate was already registered, as only one of them can be committed.
+	Register(aggregates ...Aggregate) error
+	// Commit stores the new events of all the registered aggregates in one transaction.
+	// It fails without storing any of them if another event was stored in the stream of any aggregate
+	// after the version it was loaded at. A unit of work can only be committed once.
+	Commit(ctx context.Context) error
+}
+
+// UnitOfWorkFactory starts the units of work of a store.
+type UnitOfWorkFactory interface {
+	NewUnitOfWork() UnitOfWork
+}
@@ -0,0 +1,11 @@
+package persistence
+
+import "context"
+
+// AppendListener is implemented by the stores that can notify the appends committed by other processes,
+// so the subscriptions are woken up as soon as they happen instead of waiting for the next poll.
+type AppendListener interface {
+	// ListenAppends calls notify after every append committed to the store.
+	// It blocks until the context is cancelled or the store stops notifying, and returns the reason.
+	ListenAppends(ctx context.Context, notify func()) error
+}
@@ -8,8 +8,11 @@ import (
 )
 
 //go:generate mockgen -source=$GOFILE -destination=mocks/$GOFILE -package=mocks
+
+// AppendOnlyStore stores the events of every tenant.
+// Every call is scoped to the tenant of the context, see domain.WithTenant and domain.WithAllTenants.
 type AppendOnlyStore interface {
-	// Append appends the marshalled events to the store.
+	// Append appends the marshalled events to the store, in the stream of the tenant of the context.
 	// It returns an error if the expected version does not match the current version.
 	Append(ctx context.Context, events ...StoredStreamEvent) error
 
@@ -17,10 +20,13 @@ type AppendOnlyStore interface {
 }
 
 type ReadOnlyStore interface {
-	// ReadAllRecords reads all events in the store.
+	// ReadAllRecords reads all events of the tenant of the context in the store.
 	ReadAllRecords(ctx context.Context) ([]StoredStreamEvent, error)
 
-	// ReadRecords reads events within a single Stream by their names.
+	// CountRecords counts the events ReadAllRecords would return, without reading them.
+	CountRecords(ctx context.Context) (int, error)
+
+	// ReadRecords reads events within a single Stream of the tenant of the context by their names.
 	ReadRecords(ctx context.Context, streamName string) ([]StoredStreamEvent, error)
 
 	// AfterEventID returns a ReadOnlyStore that only contains events that happened after the given eventID.
@@ -40,6 +46,9 @@ type StoredStreamEvent struct {
 	EventID     domain.EventID
 	EventName   string
 	ContentType string
-	ID          StreamID
-	EventData   []byte
+	// TenantID is the tenant owning the stream. It is set by the store from the context of the Append,
+	// unless the context is for all the tenants, in which case it is kept.
+	TenantID  domain.TenantID
+	ID        StreamID
+	EventData []byte
 }
@@ -0,0 +1,21 @@
+package persistence
+
+import (
+	"context"
+	"time"
+)
+
+// Backuper is implemented by the stores that can take consistent backups while they keep serving requests.
+type Backuper interface {
+	// TakeBackup copies the whole store to a new backup, removing the old backups beyond the retention.
+	TakeBackup(ctx context.Context) (Backup, error)
+
+	// ListBackups returns the backups kept, from the oldest to the newest.
+	ListBackups(ctx context.Context) ([]Backup, error)
+}
+
+type Backup struct {
+	CreatedOn time.Time
+	Path      string
+	Size      int64
+}
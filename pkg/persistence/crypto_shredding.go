@@ -0,0 +1,238 @@
+package persistence
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+const (
+	// PersonalDataTag marks the string fields of an event holding personal data.
+	// Its value is the name of the field with the ID of the subject the data belongs to, e.g.:
+	//
+	//	HolderName string `personal:"AccountID"`
+	PersonalDataTag = "personal"
+
+	// RedactedValue replaces the personal data of the subjects whose key has been deleted.
+	RedactedValue = "[redacted]"
+
+	encryptedValuePrefix = "encrypted:v1:"
+)
+
+// CryptoShreddingSerializer encrypts the personal data fields of the events with the key of their subject
+// before serializing them with the wrapped serializer, and decrypts them after deserializing them.
+// Once the key of a subject is deleted its personal data is deserialized as RedactedValue,
+// while the rest of the fields of the events stay readable, so they can still be replayed.
+type CryptoShreddingSerializer struct {
+	serializer     DomainEventSerializer
+	deserializer   DomainEventDeserializer
+	cipher         *PersonalDataCipher
+	personalFields sync.Map
+}
+
+type personalField struct {
+	name         string
+	index        int
+	subjectIndex int
+}
+
+func NewCryptoShreddingSerializer(serializer DomainEventSerializer, deserializer DomainEventDeserializer, keyStore KeyStore) *CryptoShreddingSerializer {
+	return &CryptoShreddingSerializer{
+		serializer:   serializer,
+		deserializer: deserializer,
+		cipher:       NewPersonalDataCipher(keyStore),
+	}
+}
+
+func (c *CryptoShreddingSerializer) ContentType() string {
+	return c.serializer.ContentType()
+}
+
+func (c *CryptoShreddingSerializer) SerializeDomainEvent(event domain.Event) ([]byte, error) {
+	fields, err := c.fieldsOf(event)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return c.serializer.SerializeDomainEvent(event)
+	}
+
+	// The fields are encrypted in a copy, so the event of the aggregate keeps the data in clear.
+	encrypted := reflect.New(reflect.TypeOf(event).Elem())
+	encrypted.Elem().Set(reflect.ValueOf(event).Elem())
+
+	// The serializers receive no context, so the key store can't be cancelled from the caller.
+	ctx := context.Background()
+	for _, field := range fields {
+		value := encrypted.Elem().Field(field.index)
+		subjectID := encrypted.Elem().Field(field.subjectIndex).String()
+		ciphertext, err := c.cipher.Encrypt(ctx, subjectID, field.name, value.String())
+		if err != nil {
+			return nil, fmt.Errorf("unable to encrypt the field '%s' of the event %s: %w", field.name, event.EventName(), err)
+		}
+		value.SetString(ciphertext)
+	}
+
+	return c.serializer.SerializeDomainEvent(encrypted.Interface().(domain.Event))
+}
+
+func (c *CryptoShreddingSerializer) DeserializeDomainEvent(eventName string, data []byte) (domain.Event, error) {
+	event, err := c.deserializer.DeserializeDomainEvent(eventName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := c.fieldsOf(event)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	for _, field := range fields {
+		value := reflect.ValueOf(event).Elem().Field(field.index)
+		subjectID := reflect.ValueOf(event).Elem().Field(field.subjectIndex).String()
+		plaintext, err := c.cipher.Decrypt(ctx, subjectID, field.name, value.String())
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt the field '%s' of the event %s: %w", field.name, eventName, err)
+		}
+		value.SetString(plaintext)
+	}
+
+	return event, nil
+}
+
+// fieldsOf returns the personal data fields of the event, which must be a pointer to a struct.
+func (c *CryptoShreddingSerializer) fieldsOf(event domain.Event) ([]personalField, error) {
+	eventType := reflect.TypeOf(event)
+	if fields, ok := c.personalFields.Load(eventType); ok {
+		return fields.([]personalField), nil
+	}
+
+	if eventType.Kind() != reflect.Pointer || eventType.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("the event %s must be a pointer to a struct to be serialized", event.EventName())
+	}
+
+	var fields []personalField
+	structType := eventType.Elem()
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		subjectFieldName, ok := field.Tag.Lookup(PersonalDataTag)
+		if !ok {
+			continue
+		}
+
+		subjectField, exists := structType.FieldByName(subjectFieldName)
+		if field.Type.Kind() != reflect.String || !exists || subjectField.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("the personal field '%s' of the event %s must be a string with the name of a string field of the subject", field.Name, event.EventName())
+		}
+
+		fields = append(fields, personalField{name: field.Name, index: i, subjectIndex: subjectField.Index[0]})
+	}
+
+	c.personalFields.Store(eventType, fields)
+	return fields, nil
+}
+
+// PersonalDataCipher encrypts the personal data of the subjects with their keys, so it can be kept
+// out of the events, e.g. in the snapshots of the projections, and still be forgotten by deleting the key.
+type PersonalDataCipher struct {
+	keyStore KeyStore
+}
+
+func NewPersonalDataCipher(keyStore KeyStore) *PersonalDataCipher {
+	return &PersonalDataCipher{keyStore: keyStore}
+}
+
+// Encrypt encrypts the value of the field with the key of the subject, creating it if the subject has none yet.
+// It returns RedactedValue if the key of the subject has been deleted, and empty values as they are.
+func (c *PersonalDataCipher) Encrypt(ctx context.Context, subjectID, fieldName, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	key, err := c.keyStore.CreateKey(ctx, subjectID)
+	if errors.Is(err, ErrSubjectKeyDeleted) {
+		return RedactedValue, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the key of the subject '%s': %w", subjectID, err)
+	}
+	return encrypt(key, plaintext, additionalData(subjectID, fieldName))
+}
+
+// Decrypt decrypts the value of the field encrypted by Encrypt, or returns RedactedValue if the key of the subject
+// has been deleted. The values without the prefix were stored before encrypting the field, and are kept as they are.
+func (c *PersonalDataCipher) Decrypt(ctx context.Context, subjectID, fieldName, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedValuePrefix) {
+		return value, nil
+	}
+
+	key, err := c.keyStore.Key(ctx, subjectID)
+	if errors.Is(err, ErrSubjectKeyDeleted) || errors.Is(err, ErrSubjectKeyNotFound) {
+		return RedactedValue, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the key of the subject '%s': %w", subjectID, err)
+	}
+	return decrypt(key, value, additionalData(subjectID, fieldName))
+}
+
+// additionalData binds the ciphertext to the subject and field it was encrypted for,
+// so it can't be copied to another event to disclose it.
+func additionalData(subjectID, fieldName string) []byte {
+	return []byte(subjectID + "/" + fieldName)
+}
+
+func encrypt(key []byte, plaintext string, additionalData []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("unable to generate the nonce: %w", err)
+	}
+
+	ciphertext := aead.Seal(nonce, nonce, []byte(plaintext), additionalData)
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func decrypt(key []byte, value string, additionalData []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedValuePrefix))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode the ciphertext: %w", err)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return "", errors.New("the ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt the ciphertext: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,197 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+func init() {
+	serializer.RegisterSerializableEvent(&holderRegistered{})
+}
+
+var _ = Describe("CryptoShreddingSerializer", func() {
+	var (
+		keyStore         *inmemory.KeyStore
+		cryptoSerializer *persistence.CryptoShreddingSerializer
+		event            *holderRegistered
+	)
+
+	BeforeEach(func() {
+		keyStore = inmemory.NewKeyStore()
+		jsonSerializer := &serializer.JSON{}
+		cryptoSerializer = persistence.NewCryptoShreddingSerializer(jsonSerializer, jsonSerializer, keyStore)
+		event = &holderRegistered{ID: "event0", AccountID: "some-account", HolderName: "Jane Doe", Balance: 50}
+	})
+
+	It("does not store the personal data in clear", func() {
+		data, err := cryptoSerializer.SerializeDomainEvent(event)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(data)).ToNot(ContainSubstring("Jane Doe"))
+		Expect(string(data)).To(ContainSubstring("some-account"))
+		Expect(event.HolderName).To(Equal("Jane Doe"), "the serialized event must not be modified")
+	})
+
+	It("decrypts the personal data while the key exists", func() {
+		data, err := cryptoSerializer.SerializeDomainEvent(event)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cryptoSerializer.DeserializeDomainEvent(event.EventName(), data)).To(Equal(event))
+	})
+
+	When("the key of the subject is deleted", func() {
+		var data []byte
+
+		BeforeEach(func(ctx context.Context) {
+			var err error
+			data, err = cryptoSerializer.SerializeDomainEvent(event)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(keyStore.DeleteKey(ctx, "some-account")).To(Succeed())
+		})
+
+		It("redacts the personal data and keeps the rest of the event", func() {
+			Expect(cryptoSerializer.DeserializeDomainEvent(event.EventName(), data)).To(Equal(&holderRegistered{
+				ID:         "event0",
+				AccountID:  "some-account",
+				HolderName: persistence.RedactedValue,
+				Balance:    50,
+			}))
+		})
+
+		It("does not store new personal data of the subject", func() {
+			newData, err := cryptoSerializer.SerializeDomainEvent(event)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(newData)).ToNot(ContainSubstring("Jane Doe"))
+			Expect(cryptoSerializer.DeserializeDomainEvent(event.EventName(), newData)).To(HaveField("HolderName", persistence.RedactedValue))
+		})
+
+		It("keeps the personal data of other subjects", func() {
+			otherEvent := &holderRegistered{ID: "event1", AccountID: "other-account", HolderName: "John Doe"}
+			otherData, err := cryptoSerializer.SerializeDomainEvent(otherEvent)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cryptoSerializer.DeserializeDomainEvent(otherEvent.EventName(), otherData)).To(Equal(otherEvent))
+		})
+	})
+
+	It("fails to decrypt the personal data moved to another subject", func(ctx context.Context) {
+		_, err := keyStore.CreateKey(ctx, "other-account")
+		Expect(err).ToNot(HaveOccurred())
+		data, err := cryptoSerializer.SerializeDomainEvent(event)
+		Expect(err).ToNot(HaveOccurred())
+
+		encrypted, err := (&serializer.JSON{}).DeserializeDomainEvent(event.EventName(), data)
+		Expect(err).ToNot(HaveOccurred())
+		encrypted.(*holderRegistered).AccountID = "other-account"
+		moved, err := (&serializer.JSON{}).SerializeDomainEvent(encrypted)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = cryptoSerializer.DeserializeDomainEvent(event.EventName(), moved)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("keeps the personal data stored before it was encrypted", func() {
+		data, err := (&serializer.JSON{}).SerializeDomainEvent(event)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cryptoSerializer.DeserializeDomainEvent(event.EventName(), data)).To(Equal(event))
+	})
+
+	It("serializes the events without personal data like the wrapped serializer", func() {
+		deposit := &account.AmountDeposited{ID: "event0", AccountID: "some-account", Quantity: 10, Balance: 10}
+
+		Expect(cryptoSerializer.SerializeDomainEvent(deposit)).To(Equal(dataRecordInStore()))
+		Expect(cryptoSerializer.ContentType()).To(Equal("application/json"))
+	})
+
+	It("replays the streams of the subjects whose key has been deleted", func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).
+			WithSerializer(cryptoSerializer).
+			WithDeserializer(cryptoSerializer).
+			Build()
+		aggregate := fakeAggregate{id: "some-account"}.withEvents(
+			&holderRegistered{ID: "event0", AccountID: "some-account", HolderName: "Jane Doe", Balance: 50, EventVersion: 1},
+		)
+		Expect(eventStore.AppendToStream(ctx, &aggregate)).To(Succeed())
+		Expect(keyStore.DeleteKey(ctx, "some-account")).To(Succeed())
+
+		Expect(eventStore.LoadEventStream(ctx, "some-account")).To(ConsistOf(
+			&holderRegistered{ID: "event0", AccountID: "some-account", HolderName: persistence.RedactedValue, Balance: 50, EventVersion: 1},
+		))
+	})
+})
+
+var _ = Describe("PersonalDataCipher", func() {
+	var (
+		keyStore *inmemory.KeyStore
+		cipher   *persistence.PersonalDataCipher
+	)
+
+	BeforeEach(func() {
+		keyStore = inmemory.NewKeyStore()
+		cipher = persistence.NewPersonalDataCipher(keyStore)
+	})
+
+	It("decrypts the values it encrypted while the key exists", func(ctx context.Context) {
+		encrypted, err := cipher.Encrypt(ctx, "some-account", "HolderName", "Jane Doe")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(encrypted).ToNot(ContainSubstring("Jane Doe"))
+		Expect(cipher.Decrypt(ctx, "some-account", "HolderName", encrypted)).To(Equal("Jane Doe"))
+	})
+
+	It("redacts the values once the key of the subject is deleted", func(ctx context.Context) {
+		encrypted, err := cipher.Encrypt(ctx, "some-account", "HolderName", "Jane Doe")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(keyStore.DeleteKey(ctx, "some-account")).To(Succeed())
+
+		Expect(cipher.Decrypt(ctx, "some-account", "HolderName", encrypted)).To(Equal(persistence.RedactedValue))
+		Expect(cipher.Encrypt(ctx, "some-account", "HolderName", "Jane Doe")).To(Equal(persistence.RedactedValue))
+	})
+
+	It("keeps the empty and the unencrypted values as they are", func(ctx context.Context) {
+		Expect(cipher.Encrypt(ctx, "some-account", "HolderName", "")).To(BeEmpty())
+		Expect(cipher.Decrypt(ctx, "some-account", "HolderName", "Jane Doe")).To(Equal("Jane Doe"))
+	})
+})
+
+type holderRegistered struct {
+	Timestamp    time.Time
+	ID           domain.EventID
+	AccountID    string
+	HolderName   string `personal:"AccountID"`
+	Balance      int
+	EventVersion uint64
+}
+
+func (h *holderRegistered) AggregateID() string {
+	return h.AccountID
+}
+
+func (h *holderRegistered) Version() uint64 {
+	return h.EventVersion
+}
+
+func (h *holderRegistered) EventID() domain.EventID {
+	return h.ID
+}
+
+func (h *holderRegistered) EventName() string {
+	return "HolderRegistered"
+}
+
+func (h *holderRegistered) HappenedOn() time.Time {
+	return h.Timestamp
+}
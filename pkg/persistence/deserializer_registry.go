@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+var ErrUnknownContentType = errors.New("no deserializer registered for content type")
+
+// DeserializerRegistry deserializes every record with the deserializer registered for its content type,
+// so a store can be read after switching serializers without rewriting the events already stored.
+// The records stored without a content type are deserialized with the default deserializer.
+type DeserializerRegistry struct {
+	deserializers       map[string]DomainEventDeserializer
+	defaultDeserializer DomainEventDeserializer
+}
+
+// NewDeserializerRegistry returns a registry with the deserializers of the serializer package for the events
+// of the serializer.DefaultEventRegistry, using the JSON one for the records without a content type.
+func NewDeserializerRegistry() *DeserializerRegistry {
+	return NewDeserializerRegistryForEvents(serializer.DefaultEventRegistry())
+}
+
+// NewDeserializerRegistryForEvents returns a registry with the deserializers of the serializer package
+// for the events of the given registry, using the JSON one for the records without a content type.
+func NewDeserializerRegistryForEvents(events *serializer.EventRegistry) *DeserializerRegistry {
+	jsonDeserializer := serializer.NewJSON(events)
+	msgpackDeserializer := serializer.NewMsgpack(events)
+	goBinaryDeserializer := serializer.NewGoBinarySerializer(events)
+	protobufDeserializer := serializer.NewProtobuf(events)
+
+	return &DeserializerRegistry{
+		deserializers: map[string]DomainEventDeserializer{
+			jsonDeserializer.ContentType():     jsonDeserializer,
+			msgpackDeserializer.ContentType():  msgpackDeserializer,
+			goBinaryDeserializer.ContentType(): goBinaryDeserializer,
+			protobufDeserializer.ContentType(): protobufDeserializer,
+		},
+		defaultDeserializer: jsonDeserializer,
+	}
+}
+
+// Register sets the deserializer of the records with the given content type, replacing the previous one.
+func (r *DeserializerRegistry) Register(contentType string, deserializer DomainEventDeserializer) *DeserializerRegistry {
+	r.deserializers[contentType] = deserializer
+	return r
+}
+
+// WithDefaultDeserializer sets the deserializer of the records stored without a content type.
+func (r *DeserializerRegistry) WithDefaultDeserializer(deserializer DomainEventDeserializer) *DeserializerRegistry {
+	r.defaultDeserializer = deserializer
+	return r
+}
+
+// Deserialize deserializes the event data with the deserializer of the content type.
+// It returns ErrUnknownContentType if no deserializer is registered for it.
+func (r *DeserializerRegistry) Deserialize(contentType, eventName string, data []byte) (domain.Event, error) {
+	if contentType == "" {
+		return r.defaultDeserializer.DeserializeDomainEvent(eventName, data)
+	}
+
+	deserializer, ok := r.deserializers[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownContentType, contentType)
+	}
+	return deserializer.DeserializeDomainEvent(eventName, data)
+}
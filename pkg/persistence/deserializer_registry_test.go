@@ -0,0 +1,128 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+var _ = Describe("DeserializerRegistry", func() {
+	var registry *persistence.DeserializerRegistry
+
+	BeforeEach(func() {
+		registry = persistence.NewDeserializerRegistry()
+	})
+
+	DescribeTable("deserializes the events with the deserializer of their content type",
+		func(eventSerializer persistence.DomainEventSerializer) {
+			deposit := &account.AmountDeposited{ID: "event0", AccountID: "some-account", Quantity: 10, Balance: 10}
+			data, err := eventSerializer.SerializeDomainEvent(deposit)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(registry.Deserialize(eventSerializer.ContentType(), deposit.EventName(), data)).To(Equal(deposit))
+		},
+		Entry("json", &serializer.JSON{}),
+		Entry("msgpack", &serializer.Msgpack{}),
+		Entry("gob", &serializer.GoBinarySerializer{}),
+		Entry("protobuf", &serializer.Protobuf{}),
+	)
+
+	It("deserializes the records without content type with the default deserializer", func() {
+		Expect(registry.Deserialize("", "AmountDeposited", dataRecordInStore())).To(BeAssignableToTypeOf(&account.AmountDeposited{}))
+
+		registry.WithDefaultDeserializer(&serializer.Msgpack{})
+		_, err := registry.Deserialize("", "AmountDeposited", dataRecordInStore())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails with a clear error for the content types without deserializer", func() {
+		_, err := registry.Deserialize("application/xml", "AmountDeposited", []byte("<AmountDeposited/>"))
+
+		Expect(err).To(MatchError(persistence.ErrUnknownContentType))
+		Expect(err).To(MatchError(ContainSubstring("application/xml")))
+	})
+
+	It("reads the stores written with different serializers", func(ctx context.Context) {
+		appendOnlyStore := inmemory.NewAppendOnlyStore()
+		jsonAccount, err := account.OpenAccount("json-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(appendOnlyStore).Build().AppendToStream(ctx, jsonAccount)).To(Succeed())
+
+		msgpackAccount, err := account.OpenAccount("msgpack-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msgpackAccount.DepositMoney(10)).To(Succeed())
+		msgpackEventStore := persistence.NewEventStoreBuilder(appendOnlyStore).
+			WithSerializer(&serializer.Msgpack{}).
+			WithDeserializer(&serializer.Msgpack{}).
+			Build()
+		Expect(msgpackEventStore.AppendToStream(ctx, msgpackAccount)).To(Succeed())
+
+		events, err := persistence.NewEventStoreBuilder(appendOnlyStore).Build().LoadAllEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveExactElements(
+			HaveField("AccountID", "json-account"),
+			HaveField("AccountID", "msgpack-account"),
+			HaveField("Quantity", 10),
+		))
+	})
+
+	It("reads only the events of the event registry of the store", func(ctx context.Context) {
+		appendOnlyStore := inmemory.NewAppendOnlyStore()
+		acc, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		accountEvents := serializer.NewEventRegistry()
+		account.RegisterEvents(accountEvents)
+		Expect(persistence.NewEventStoreBuilder(appendOnlyStore).WithEventRegistry(accountEvents).Build().AppendToStream(ctx, acc)).To(Succeed())
+
+		Expect(persistence.NewEventStoreBuilder(appendOnlyStore).WithEventRegistry(accountEvents).Build().LoadEventStream(ctx, "some-account")).To(HaveLen(1))
+		_, err = persistence.NewEventStoreBuilder(appendOnlyStore).WithEventRegistry(serializer.NewEventRegistry()).Build().LoadEventStream(ctx, "some-account")
+		Expect(err).To(MatchError(ContainSubstring("type not registered: AccountOpened")))
+	})
+
+	It("keeps the serializer of the store when setting the event registry", func(ctx context.Context) {
+		appendOnlyStore := inmemory.NewAppendOnlyStore()
+		acc, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		accountEvents := serializer.NewEventRegistry()
+		account.RegisterEvents(accountEvents)
+		eventStore := persistence.NewEventStoreBuilder(appendOnlyStore).
+			WithSerializer(&serializer.Msgpack{}).
+			WithEventRegistry(accountEvents).
+			Build()
+
+		Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+
+		Expect(appendOnlyStore.ReadRecords(ctx, "some-account")).To(ConsistOf(HaveField("ContentType", "application/x-msgpack")))
+		Expect(eventStore.LoadEventStream(ctx, "some-account")).To(HaveLen(1))
+	})
+
+	It("rejects setting both the event registry and the deserializer registry", func() {
+		builder := persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).
+			WithEventRegistry(serializer.NewEventRegistry()).
+			WithDeserializerRegistry(persistence.NewDeserializerRegistry())
+
+		Expect(func() { builder.Build() }).To(Panic())
+	})
+
+	It("fails to read a stream with a record of an unknown content type", func(ctx context.Context) {
+		appendOnlyStore := inmemory.NewAppendOnlyStore()
+		Expect(appendOnlyStore.Append(ctx, persistence.StoredStreamEvent{
+			ID:          persistence.StreamID{StreamName: "some-account", StreamVersion: 0},
+			EventID:     "event0",
+			EventName:   "AmountDeposited",
+			EventData:   []byte("<AmountDeposited/>"),
+			ContentType: "application/xml",
+			HappenedOn:  time.Now(),
+		})).To(Succeed())
+
+		_, err := persistence.NewEventStoreBuilder(appendOnlyStore).Build().LoadEventStream(ctx, "some-account")
+		Expect(err).To(MatchError(persistence.ErrUnknownContentType))
+	})
+})
@@ -4,4 +4,12 @@ import (
 	"errors"
 )
 
-var ErrUnexpectedVersion = errors.New("unexpected version for stream")
+var (
+	ErrUnexpectedVersion            = errors.New("unexpected version for stream")
+	ErrProjectionCheckpointNotFound = errors.New("projection checkpoint not found")
+	ErrProjectionSnapshotNotFound   = errors.New("projection snapshot not found")
+	ErrSubjectKeyNotFound           = errors.New("subject key not found")
+	ErrSubjectKeyDeleted            = errors.New("subject key deleted")
+	ErrTargetStoreNotEmpty          = errors.New("target store is not empty")
+	ErrReencodedStoreMismatch       = errors.New("re-encoded store does not match the source store")
+)
@@ -0,0 +1,430 @@
+package persistence
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+const (
+	exportBatchSize = 1000
+	importBatchSize = 1000
+
+	// maxExportLineSize is the size of the largest line the Importer can read.
+	maxExportLineSize = 64 * 1024 * 1024
+)
+
+var (
+	ErrCorruptExport = errors.New("corrupt export")
+	// ErrPartialImport is returned when an import fails after appending some of the events,
+	// which are kept in the store, so the import can be resumed after the last one of them.
+	ErrPartialImport = errors.New("partial import")
+)
+
+// EventFilter selects the events to export or import. Every empty field selects all the events.
+type EventFilter struct {
+	// From is the first instant of the events, inclusive.
+	From time.Time
+	// To is the last instant of the events, exclusive.
+	To          time.Time
+	StreamNames []string
+	EventNames  []string
+}
+
+func (f EventFilter) matches(record StoredStreamEvent) bool {
+	if !f.From.IsZero() && record.HappenedOn.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !record.HappenedOn.Before(f.To) {
+		return false
+	}
+	if len(f.StreamNames) > 0 && !slices.Contains(f.StreamNames, record.ID.StreamName) {
+		return false
+	}
+	if len(f.EventNames) > 0 && !slices.Contains(f.EventNames, record.EventName) {
+		return false
+	}
+	return true
+}
+
+// exportedEvent is a line of an export with an event, which is followed by a line with the exportFooter.
+// The payload is the event encoded in JSON whatever its content type in the store, so it can be read and edited.
+type exportedEvent struct {
+	EventID       domain.EventID  `json:"eventId"`
+	StreamName    string          `json:"streamName"`
+	StreamVersion uint64          `json:"streamVersion"`
+	EventName     string          `json:"eventName"`
+	HappenedOn    time.Time       `json:"happenedOn"`
+	Tenant        domain.TenantID `json:"tenant,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	Checksum      string          `json:"checksum,omitempty"`
+	Footer        *exportFooter   `json:"footer,omitempty"`
+}
+
+// exportFooter closes an export, so truncated, reordered or removed lines can be detected.
+type exportFooter struct {
+	Events int `json:"events"`
+	// Checksum is the checksum of the checksums of all the events.
+	Checksum string `json:"checksum"`
+}
+
+// checksum returns the checksum of the line without its checksum, with the payload in compact form,
+// so the indentation of the payload can be changed without changing it.
+func (e exportedEvent) checksum() (string, error) {
+	e.Checksum = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Exporter writes the events of a store to a JSON Lines stream, one event per line in the order of the store.
+// The events of every tenant are exported, each with its tenant.
+type Exporter struct {
+	store          ReadOnlyStore
+	deserializers  *DeserializerRegistry
+	payloadEncoder DomainEventSerializer
+	filter         EventFilter
+}
+
+func NewExporter(store ReadOnlyStore) *Exporter {
+	return &Exporter{
+		store:          store,
+		deserializers:  NewDeserializerRegistry(),
+		payloadEncoder: &serializer.JSON{},
+	}
+}
+
+// WithEventRegistry sets the events the Exporter can decode.
+func (e *Exporter) WithEventRegistry(events *serializer.EventRegistry) *Exporter {
+	e.deserializers = NewDeserializerRegistryForEvents(events)
+	e.payloadEncoder = serializer.NewJSON(events)
+	return e
+}
+
+func (e *Exporter) WithFilter(filter EventFilter) *Exporter {
+	e.filter = filter
+	return e
+}
+
+// Export writes the events selected by the filter, returning how many were written.
+func (e *Exporter) Export(ctx context.Context, w io.Writer) (int, error) {
+	ctx = domain.WithAllTenants(ctx)
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	footer := exportFooter{}
+	footerChecksum := sha256.New()
+
+	var lastEventID *domain.EventID
+	for {
+		records, err := readBatch(ctx, e.store, lastEventID, exportBatchSize)
+		if err != nil {
+			return footer.Events, fmt.Errorf("error reading records: %w", err)
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		for _, record := range records {
+			if !e.filter.matches(record) {
+				continue
+			}
+
+			line, err := e.exportedEvent(record)
+			if err != nil {
+				return footer.Events, err
+			}
+			if err := encoder.Encode(line); err != nil {
+				return footer.Events, fmt.Errorf("error writing event '%s': %w", record.EventID, err)
+			}
+			footerChecksum.Write([]byte(line.Checksum))
+			footer.Events++
+		}
+		lastEventID = &records[len(records)-1].EventID
+	}
+
+	footer.Checksum = hex.EncodeToString(footerChecksum.Sum(nil))
+	if err := encoder.Encode(map[string]exportFooter{"footer": footer}); err != nil {
+		return footer.Events, fmt.Errorf("error writing the footer: %w", err)
+	}
+	return footer.Events, writer.Flush()
+}
+
+func (e *Exporter) exportedEvent(record StoredStreamEvent) (exportedEvent, error) {
+	event, err := e.deserializers.Deserialize(record.ContentType, record.EventName, record.EventData)
+	if err != nil {
+		return exportedEvent{}, fmt.Errorf("error deserializing event '%s': %w", record.EventID, err)
+	}
+	payload, err := e.payloadEncoder.SerializeDomainEvent(event)
+	if err != nil {
+		return exportedEvent{}, fmt.Errorf("error encoding event '%s': %w", record.EventID, err)
+	}
+
+	line := exportedEvent{
+		EventID:       record.EventID,
+		StreamName:    record.ID.StreamName,
+		StreamVersion: record.ID.StreamVersion,
+		EventName:     record.EventName,
+		HappenedOn:    record.HappenedOn,
+		Tenant:        record.TenantID,
+		Payload:       payload,
+	}
+	line.Checksum, err = line.checksum()
+	if err != nil {
+		return exportedEvent{}, fmt.Errorf("error computing the checksum of event '%s': %w", record.EventID, err)
+	}
+	return line, nil
+}
+
+// Importer appends to a store the events of a JSON Lines stream written by the Exporter,
+// re-serializing their payload with its serializer.
+// The integrity of the whole stream is verified before appending any event, so a corrupt export is not partially imported.
+// Every event is appended to the tenant it was exported from.
+type Importer struct {
+	store           AppendOnlyStore
+	serializer      DomainEventSerializer
+	payloadDecoder  DomainEventDeserializer
+	filter          EventFilter
+	integrityChecks bool
+	batchSize       int
+	resumeAfter     *domain.EventID
+}
+
+func NewImporter(store AppendOnlyStore) *Importer {
+	return &Importer{
+		store:           store,
+		serializer:      &serializer.JSON{},
+		payloadDecoder:  &serializer.JSON{},
+		integrityChecks: true,
+		batchSize:       importBatchSize,
+	}
+}
+
+func (i *Importer) WithSerializer(serializer DomainEventSerializer) *Importer {
+	i.serializer = serializer
+	return i
+}
+
+// WithEventRegistry sets the events the Importer can decode.
+func (i *Importer) WithEventRegistry(events *serializer.EventRegistry) *Importer {
+	i.payloadDecoder = serializer.NewJSON(events)
+	return i
+}
+
+func (i *Importer) WithFilter(filter EventFilter) *Importer {
+	i.filter = filter
+	return i
+}
+
+// WithoutIntegrityChecks skips verifying the checksums and the footer,
+// so the exports edited by hand can be imported.
+func (i *Importer) WithoutIntegrityChecks() *Importer {
+	i.integrityChecks = false
+	return i
+}
+
+// WithBatchSize sets how many events are appended to the store at once.
+func (i *Importer) WithBatchSize(batchSize int) *Importer {
+	i.batchSize = max(batchSize, 1)
+	return i
+}
+
+// WithResumeAfter skips the events of the export up to the given one, inclusive,
+// to resume an import that failed with ErrPartialImport after appending it.
+func (i *Importer) WithResumeAfter(eventID domain.EventID) *Importer {
+	i.resumeAfter = &eventID
+	return i
+}
+
+// Import appends the events selected by the filter in batches, returning how many were appended.
+// The stream is read twice, first to verify the integrity of the whole of it and then to convert and append
+// its events a batch at a time, so the export does not need to fit in memory and every event is decoded once.
+// It returns ErrCorruptExport if the integrity checks fail, in which case nothing is appended.
+// The batches appended before a failure, including an event that cannot be decoded, are kept,
+// and the error wraps ErrPartialImport with the ID of the last event appended,
+// to resume the import after it with WithResumeAfter.
+func (i *Importer) Import(ctx context.Context, r io.ReadSeeker) (int, error) {
+	if err := i.verify(r); err != nil {
+		return 0, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error rewinding the export: %w", err)
+	}
+
+	ctx = domain.WithAllTenants(ctx)
+	var (
+		imported     int
+		batch        []StoredStreamEvent
+		lastImported = i.resumeAfter
+		resumed      = i.resumeAfter == nil
+	)
+	failed := func(err error) error {
+		if lastImported == nil {
+			return err
+		}
+		return fmt.Errorf("%w: the events up to '%s' were imported, resume after it: %w", ErrPartialImport, *lastImported, err)
+	}
+	appendBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := i.store.Append(ctx, batch...); err != nil {
+			return failed(fmt.Errorf("error appending the imported events: %w", err))
+		}
+		imported += len(batch)
+		lastImported = &batch[len(batch)-1].EventID
+		batch = nil
+		return nil
+	}
+
+	err := i.eachLine(r, func(lineNumber int, line exportedEvent) error {
+		if line.Footer != nil {
+			return nil
+		}
+		if !resumed {
+			resumed = line.EventID == *i.resumeAfter
+			return nil
+		}
+
+		record := line.storedStreamEvent()
+		if !i.filter.matches(record) {
+			return nil
+		}
+		if err := i.convert(&record, line.Payload); err != nil {
+			return failed(fmt.Errorf("line %d: %w", lineNumber, err))
+		}
+		batch = append(batch, record)
+		if len(batch) < i.batchSize {
+			return nil
+		}
+		return appendBatch()
+	})
+	if err != nil {
+		return imported, err
+	}
+	return imported, appendBatch()
+}
+
+// verify reads the whole export checking, with the integrity checks, that no line was modified, removed or reordered.
+// The payloads are only decoded when the events are imported.
+func (i *Importer) verify(r io.Reader) error {
+	var (
+		footer         *exportFooter
+		events         int
+		footerChecksum = sha256.New()
+		resumeFound    = i.resumeAfter == nil
+	)
+	err := i.eachLine(r, func(lineNumber int, line exportedEvent) error {
+		if footer != nil {
+			return fmt.Errorf("%w: line %d is after the footer", ErrCorruptExport, lineNumber)
+		}
+		if line.Footer != nil {
+			footer = line.Footer
+			return nil
+		}
+
+		if err := i.verifyChecksum(line); err != nil {
+			return fmt.Errorf("%w: line %d: %w", ErrCorruptExport, lineNumber, err)
+		}
+		footerChecksum.Write([]byte(line.Checksum))
+		events++
+
+		resumeFound = resumeFound || line.EventID == *i.resumeAfter
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !resumeFound {
+		return fmt.Errorf("the event '%s' to resume after is not in the export", *i.resumeAfter)
+	}
+
+	if !i.integrityChecks {
+		return nil
+	}
+	if footer == nil {
+		return fmt.Errorf("%w: the footer is missing, the export may be truncated", ErrCorruptExport)
+	}
+	if footer.Events != events || footer.Checksum != hex.EncodeToString(footerChecksum.Sum(nil)) {
+		return fmt.Errorf("%w: the events do not match the footer, some may have been removed or reordered", ErrCorruptExport)
+	}
+	return nil
+}
+
+// eachLine calls the function with every non-empty line of the export, in order.
+func (i *Importer) eachLine(r io.Reader, f func(lineNumber int, line exportedEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxExportLineSize)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var line exportedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("%w: line %d is not valid: %w", ErrCorruptExport, lineNumber, err)
+		}
+		if err := f(lineNumber, line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading the export: %w", err)
+	}
+	return nil
+}
+
+func (i *Importer) verifyChecksum(line exportedEvent) error {
+	if !i.integrityChecks {
+		return nil
+	}
+
+	checksum, err := line.checksum()
+	if err != nil {
+		return fmt.Errorf("unable to compute the checksum: %w", err)
+	}
+	if checksum != line.Checksum {
+		return fmt.Errorf("the checksum of event '%s' does not match", line.EventID)
+	}
+	return nil
+}
+
+// storedStreamEvent returns the record of the line, without its payload, which is set by Importer.convert.
+func (e exportedEvent) storedStreamEvent() StoredStreamEvent {
+	return StoredStreamEvent{
+		ID:         StreamID{StreamName: e.StreamName, StreamVersion: e.StreamVersion},
+		EventID:    e.EventID,
+		EventName:  e.EventName,
+		HappenedOn: e.HappenedOn,
+		TenantID:   e.Tenant,
+	}
+}
+
+// convert decodes the exported payload of the record and sets its data serialized with the serializer of the Importer.
+func (i *Importer) convert(record *StoredStreamEvent, payload json.RawMessage) error {
+	event, err := i.payloadDecoder.DeserializeDomainEvent(record.EventName, payload)
+	if err != nil {
+		return fmt.Errorf("error decoding event '%s': %w", record.EventID, err)
+	}
+	data, err := i.serializer.SerializeDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("error serializing event '%s': %w", record.EventID, err)
+	}
+
+	record.EventData = data
+	record.ContentType = i.serializer.ContentType()
+	return nil
+}
@@ -0,0 +1,209 @@
+package persistence_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+var _ = Describe("Exporter and Importer", func() {
+	var (
+		source *inmemory.AppendOnlyStore
+		target *inmemory.AppendOnlyStore
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		source = inmemory.NewAppendOnlyStore()
+		target = inmemory.NewAppendOnlyStore()
+
+		msgpackEventStore := persistence.NewEventStoreBuilder(source).WithSerializer(&serializer.Msgpack{}).Build()
+		for _, id := range []string{"some-account", "other-account"} {
+			acc, err := account.OpenAccount(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(acc.DepositMoney(10)).To(Succeed())
+			Expect(msgpackEventStore.AppendToStream(ctx, acc)).To(Succeed())
+		}
+	})
+
+	export := func(ctx context.Context, filter persistence.EventFilter) string {
+		var buf bytes.Buffer
+		_, err := persistence.NewExporter(source).WithFilter(filter).Export(ctx, &buf)
+		Expect(err).ToNot(HaveOccurred())
+		return buf.String()
+	}
+
+	It("imports the exported events with the same metadata and payload", func(ctx context.Context) {
+		var buf bytes.Buffer
+		Expect(persistence.NewExporter(source).Export(ctx, &buf)).To(Equal(4))
+		Expect(persistence.NewImporter(target).Import(ctx, bytes.NewReader(buf.Bytes()))).To(Equal(4))
+
+		sourceRecords, err := source.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		targetRecords, err := target.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targetRecords).To(HaveLen(4))
+		for i, targetRecord := range targetRecords {
+			Expect(targetRecord.EventID).To(Equal(sourceRecords[i].EventID))
+			Expect(targetRecord.ID).To(Equal(sourceRecords[i].ID))
+			Expect(targetRecord.EventName).To(Equal(sourceRecords[i].EventName))
+			Expect(targetRecord.HappenedOn).To(BeTemporally("==", sourceRecords[i].HappenedOn))
+			Expect(targetRecord.ContentType).To(Equal("application/json"))
+		}
+
+		imported, err := account.NewRepository(persistence.NewEventStoreBuilder(target).Build()).GetByID(ctx, "other-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(imported.Balance()).To(Equal(10))
+	})
+
+	It("imports every event in the tenant it was exported from", func(ctx context.Context) {
+		tenantCtx := domain.WithTenant(ctx, "some-tenant")
+		acc, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(source).Build().AppendToStream(tenantCtx, acc)).To(Succeed())
+
+		var buf bytes.Buffer
+		Expect(persistence.NewExporter(source).Export(ctx, &buf)).To(Equal(5))
+		Expect(buf.String()).To(ContainSubstring(`"tenant":"some-tenant"`))
+		Expect(persistence.NewImporter(target).Import(ctx, bytes.NewReader(buf.Bytes()))).To(Equal(5))
+
+		Expect(target.ReadAllRecords(tenantCtx)).To(HaveExactElements(HaveField("ID.StreamName", "some-account")))
+		Expect(target.ReadAllRecords(ctx)).To(HaveLen(4))
+	})
+
+	It("writes one event per line with a readable payload and a footer", func(ctx context.Context) {
+		lines := strings.Split(strings.TrimSpace(export(ctx, persistence.EventFilter{})), "\n")
+
+		Expect(lines).To(HaveLen(5))
+		Expect(lines[1]).To(ContainSubstring(`"streamName":"some-account","streamVersion":1,"eventName":"AmountDeposited"`))
+		Expect(lines[1]).To(ContainSubstring(`"Quantity":10`))
+		Expect(lines[4]).To(HavePrefix(`{"footer":{"events":4,`))
+	})
+
+	DescribeTable("exports and imports only the events selected by the filter",
+		func(ctx context.Context, filter persistence.EventFilter, expectedEvents int) {
+			Expect(strings.Count(export(ctx, filter), "\n")).To(Equal(expectedEvents + 1))
+			Expect(persistence.NewImporter(target).WithFilter(filter).Import(ctx, strings.NewReader(export(ctx, persistence.EventFilter{})))).To(Equal(expectedEvents))
+		},
+		Entry("by stream", persistence.EventFilter{StreamNames: []string{"other-account"}}, 2),
+		Entry("by event name", persistence.EventFilter{EventNames: []string{"AmountDeposited"}}, 2),
+		Entry("by stream and event name", persistence.EventFilter{StreamNames: []string{"other-account"}, EventNames: []string{"AccountOpened"}}, 1),
+		Entry("from an instant", persistence.EventFilter{From: time.Now().Add(time.Hour)}, 0),
+		Entry("to an instant", persistence.EventFilter{To: time.Now().Add(time.Hour)}, 4),
+	)
+
+	DescribeTable("does not import anything from a corrupt export",
+		func(ctx context.Context, corrupt func(lines []string) []string) {
+			lines := strings.Split(strings.TrimSpace(export(ctx, persistence.EventFilter{})), "\n")
+			corrupted := strings.Join(corrupt(lines), "\n")
+
+			_, err := persistence.NewImporter(target).Import(ctx, strings.NewReader(corrupted))
+
+			Expect(err).To(MatchError(persistence.ErrCorruptExport))
+			Expect(target.ReadAllRecords(ctx)).To(BeEmpty())
+		},
+		Entry("with a modified payload", func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"Quantity":10`, `"Quantity":1000`, 1)
+			return lines
+		}),
+		Entry("with a removed event", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}),
+		Entry("with reordered events", func(lines []string) []string {
+			lines[0], lines[2] = lines[2], lines[0]
+			return lines
+		}),
+		Entry("truncated", func(lines []string) []string {
+			return lines[:3]
+		}),
+	)
+
+	It("imports the exports edited by hand without the integrity checks", func(ctx context.Context) {
+		lines := strings.Split(strings.TrimSpace(export(ctx, persistence.EventFilter{StreamNames: []string{"some-account"}})), "\n")
+		edited := strings.Replace(lines[1], `"Balance":10`, `"Balance":1000`, 1)
+
+		Expect(persistence.NewImporter(target).WithoutIntegrityChecks().Import(ctx, strings.NewReader(lines[0]+"\n"+edited))).To(Equal(2))
+
+		imported, err := account.NewRepository(persistence.NewEventStoreBuilder(target).Build()).GetByID(ctx, "some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(imported.Balance()).To(Equal(1000))
+	})
+	It("reports where to resume an import that failed after appending some events", func(ctx context.Context) {
+		exported := export(ctx, persistence.EventFilter{})
+		failing := &failingAppendOnlyStore{AppendOnlyStore: target, appendsLeft: 1}
+
+		imported, importErr := persistence.NewImporter(failing).WithBatchSize(2).Import(ctx, strings.NewReader(exported))
+		Expect(importErr).To(MatchError(persistence.ErrPartialImport))
+		Expect(imported).To(Equal(2))
+		importedRecords, err := target.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(importedRecords).To(HaveLen(2))
+		lastImported := importedRecords[1].EventID
+		Expect(importErr.Error()).To(ContainSubstring(string(lastImported)))
+
+		Expect(persistence.NewImporter(target).WithResumeAfter(lastImported).Import(ctx, strings.NewReader(exported))).To(Equal(2))
+		Expect(target.ReadAllRecords(ctx)).To(HaveLen(4))
+	})
+
+	It("reports where to resume an import that failed decoding an event after appending some events", func(ctx context.Context) {
+		lines := strings.Split(strings.TrimSpace(export(ctx, persistence.EventFilter{})), "\n")
+		lines[3] = strings.Replace(lines[3], `"Quantity":10`, `"Quantity":"ten"`, 1)
+
+		imported, importErr := persistence.NewImporter(target).WithoutIntegrityChecks().WithBatchSize(2).Import(ctx, strings.NewReader(strings.Join(lines, "\n")))
+
+		Expect(importErr).To(MatchError(persistence.ErrPartialImport))
+		Expect(importErr.Error()).To(ContainSubstring("line 4"))
+		Expect(imported).To(Equal(2))
+		Expect(target.ReadAllRecords(ctx)).To(HaveLen(2))
+	})
+
+	It("decodes and serializes every imported event once", func(ctx context.Context) {
+		countingSerializer := &countingSerializer{DomainEventSerializer: &serializer.JSON{}}
+
+		Expect(persistence.NewImporter(target).WithSerializer(countingSerializer).WithBatchSize(3).Import(ctx, strings.NewReader(export(ctx, persistence.EventFilter{})))).To(Equal(4))
+
+		Expect(countingSerializer.serialized).To(Equal(4))
+	})
+
+	It("does not import anything if the event to resume after is not in the export", func(ctx context.Context) {
+		_, err := persistence.NewImporter(target).WithResumeAfter("unknown-event").Import(ctx, strings.NewReader(export(ctx, persistence.EventFilter{})))
+
+		Expect(err).To(HaveOccurred())
+		Expect(target.ReadAllRecords(ctx)).To(BeEmpty())
+	})
+})
+
+// failingAppendOnlyStore fails every append after the given number of them.
+type failingAppendOnlyStore struct {
+	persistence.AppendOnlyStore
+	appendsLeft int
+}
+
+func (f *failingAppendOnlyStore) Append(ctx context.Context, events ...persistence.StoredStreamEvent) error {
+	if f.appendsLeft == 0 {
+		return errors.New("the store is down")
+	}
+	f.appendsLeft--
+	return f.AppendOnlyStore.Append(ctx, events...)
+}
+
+// countingSerializer counts the events it serializes.
+type countingSerializer struct {
+	persistence.DomainEventSerializer
+	serialized int
+}
+
+func (c *countingSerializer) SerializeDomainEvent(event domain.Event) ([]byte, error) {
+	c.serialized++
+	return c.DomainEventSerializer.SerializeDomainEvent(event)
+}
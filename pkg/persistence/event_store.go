@@ -3,13 +3,16 @@ package persistence
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
 // EventStore is a store for events that can be used to load and save domain events.
 // It is a wrapper around an AppendOnlyStore that handles serialization and deserialization of events.
-// It also handles dispatching events to any registered EventDispatchers.
+// Publishing the committed events to an EventBus is left to the AppendOnlyStore, see sqlite.OutboxRelay.
+// Subscriptions created from it are notified as soon as an append is committed,
+// and so are the appends of other processes if the store is an AppendListener.
 // It can be constructed using the EventStoreBuilder.
 type EventStore struct {
 	serializer      DomainEventSerializer
@@ -19,8 +22,10 @@ type EventStore struct {
 }
 
 type ReadOnlyEventStore struct {
-	deserializer  DomainEventDeserializer
+	deserializers *DeserializerRegistry
 	readOnlyStore ReadOnlyStore
+	notifier      *appendNotifier
+	pollInterval  time.Duration
 }
 
 // LoadEventStream loads all events for a given aggregate id
@@ -32,7 +37,7 @@ func (e *ReadOnlyEventStore) LoadEventStream(ctx context.Context, streamName str
 
 	events := make([]domain.Event, 0, len(records))
 	for _, record := range records {
-		event, err := e.deserializer.DeserializeDomainEvent(record.EventName, record.EventData)
+		event, err := e.deserializers.Deserialize(record.ContentType, record.EventName, record.EventData)
 		if err != nil {
 			return nil, fmt.Errorf("error deserializing event: %w", err)
 		}
@@ -42,10 +47,23 @@ func (e *ReadOnlyEventStore) LoadEventStream(ctx context.Context, streamName str
 	return events, nil
 }
 
-// AppendToStream appends a list of events to the event stream for a given aggregate id
-// returning an error if the expected version does not match the current version
-// FIXME: This should only save one aggregate
-func (e *EventStore) AppendToStream(ctx context.Context, aggregates ...domain.Aggregate) error {
+func (e *ReadOnlyEventStore) deserializeRecord(record StoredStreamEvent) (domain.Event, error) {
+	event, err := e.deserializers.Deserialize(record.ContentType, record.EventName, record.EventData)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing event '%s' for stream '%s' in version '%d': %w", record.EventName, record.ID.StreamName, record.ID.StreamVersion, err)
+	}
+	return event, nil
+}
+
+// AppendToStream appends the uncommitted events of the aggregate to its event stream,
+// returning an error if the expected version does not match the current version.
+// Use a UnitOfWork to append the events of several aggregates atomically.
+func (e *EventStore) AppendToStream(ctx context.Context, aggregate domain.Aggregate) error {
+	return e.append(ctx, aggregate)
+}
+
+// append appends the uncommitted events of all the aggregates in a single append.
+func (e *EventStore) append(ctx context.Context, aggregates ...domain.Aggregate) error {
 	storedStreamEvents := []StoredStreamEvent{}
 
 	for _, aggregate := range aggregates {
@@ -62,6 +80,10 @@ func (e *EventStore) AppendToStream(ctx context.Context, aggregates ...domain.Ag
 		return fmt.Errorf("error appending to stream: %w", err)
 	}
 
+	if len(storedStreamEvents) > 0 {
+		e.notifier.notify()
+	}
+
 	return nil
 }
 
@@ -102,9 +124,9 @@ func (e *ReadOnlyEventStore) LoadAllEvents(ctx context.Context) ([]domain.Event,
 
 	events := make([]domain.Event, 0, len(records))
 	for _, record := range records {
-		event, err := e.deserializer.DeserializeDomainEvent(record.EventName, record.EventData)
+		event, err := e.deserializeRecord(record)
 		if err != nil {
-			return nil, fmt.Errorf("error deserializing event '%s' for stream '%s' in version '%d': %w", record.EventName, record.ID.StreamName, record.ID.StreamVersion, err)
+			return nil, err
 		}
 		events = append(events, event)
 	}
@@ -112,16 +134,55 @@ func (e *ReadOnlyEventStore) LoadAllEvents(ctx context.Context) ([]domain.Event,
 	return events, nil
 }
 
+// TenantEvent is an event with the tenant that owns its stream.
+type TenantEvent struct {
+	Tenant domain.TenantID
+	Event  domain.Event
+}
+
+// LoadAllTenantEvents loads all the events with their tenant.
+// With a context for all the tenants, it is how the processes working on the whole store tell the tenants apart.
+func (e *ReadOnlyEventStore) LoadAllTenantEvents(ctx context.Context) ([]TenantEvent, error) {
+	records, err := e.readOnlyStore.ReadAllRecords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading records: %w", err)
+	}
+
+	events := make([]TenantEvent, 0, len(records))
+	for _, record := range records {
+		event, err := e.deserializeRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, TenantEvent{Tenant: record.TenantID, Event: event})
+	}
+
+	return events, nil
+}
+
+// CountEvents counts the events of the store without loading them, applying the same filters as LoadAllTenantEvents.
+func (e *ReadOnlyEventStore) CountEvents(ctx context.Context) (int, error) {
+	count, err := e.readOnlyStore.CountRecords(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error counting records: %w", err)
+	}
+	return count, nil
+}
+
 func (e *ReadOnlyEventStore) AfterEventID(eventID domain.EventID) *ReadOnlyEventStore {
 	return &ReadOnlyEventStore{
-		deserializer:  e.deserializer,
+		deserializers: e.deserializers,
 		readOnlyStore: e.readOnlyStore.AfterEventID(eventID),
+		notifier:      e.notifier,
+		pollInterval:  e.pollInterval,
 	}
 }
 
 func (e *ReadOnlyEventStore) Limit(limit int) *ReadOnlyEventStore {
 	return &ReadOnlyEventStore{
-		deserializer:  e.deserializer,
+		deserializers: e.deserializers,
 		readOnlyStore: e.readOnlyStore.Limit(limit),
+		notifier:      e.notifier,
+		pollInterval:  e.pollInterval,
 	}
 }
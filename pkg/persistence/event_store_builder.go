@@ -1,53 +1,108 @@
 package persistence
 
 import (
+	"time"
+
 	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
 )
 
 type EventStoreBuilder struct {
 	serializer      DomainEventSerializer
 	deserializer    DomainEventDeserializer
+	deserializers   *DeserializerRegistry
+	events          *serializer.EventRegistry
 	appendOnlyStore AppendOnlyStore
+	pollInterval    time.Duration
 }
 
 func NewEventStoreBuilder(appendOnlyStore AppendOnlyStore) *EventStoreBuilder {
-	defaultSerializer := &serializer.JSON{}
-	defaultDeserializer := &serializer.JSON{}
-
 	return &EventStoreBuilder{
-		serializer:      defaultSerializer,
-		deserializer:    defaultDeserializer,
 		appendOnlyStore: appendOnlyStore,
+		pollInterval:    defaultSubscriptionPollInterval,
 	}
 }
 
+// WithSerializer sets the serializer of the appended events. Without it the store uses the JSON serializer.
 func (b *EventStoreBuilder) WithSerializer(serializer DomainEventSerializer) *EventStoreBuilder {
 	b.serializer = serializer
 	return b
 }
 
+// WithDeserializer sets the deserializer of the records stored without a content type,
+// and of the records with its content type if it has one, like the serializers do.
+// The records with other content types keep being deserialized with the deserializers of the registry.
 func (b *EventStoreBuilder) WithDeserializer(deserializer DomainEventDeserializer) *EventStoreBuilder {
 	b.deserializer = deserializer
 	return b
 }
 
+// WithDeserializerRegistry sets the registry used to deserialize each record by its content type,
+// so the store can be read after switching serializers without rewriting the events already stored.
+// It cannot be combined with WithEventRegistry, since the registry already has the events it deserializes.
+func (b *EventStoreBuilder) WithDeserializerRegistry(deserializers *DeserializerRegistry) *EventStoreBuilder {
+	b.deserializers = deserializers
+	return b
+}
+
+// WithEventRegistry sets the events the store can deserialize, with the deserializers of the serializer package.
+// The serializer and deserializer set with WithSerializer and WithDeserializer are kept,
+// since serializing an event does not depend on the registry.
+// Without it the store uses the events of the serializer.DefaultEventRegistry.
+func (b *EventStoreBuilder) WithEventRegistry(events *serializer.EventRegistry) *EventStoreBuilder {
+	b.events = events
+	return b
+}
+
 func (b *EventStoreBuilder) WithAppendOnlyStore(appendOnlyStore AppendOnlyStore) *EventStoreBuilder {
 	b.appendOnlyStore = appendOnlyStore
 	return b
 }
 
+// WithSubscriptionPollInterval sets how often subscriptions check the store for events
+// appended by other processes, which cannot notify them directly.
+func (b *EventStoreBuilder) WithSubscriptionPollInterval(pollInterval time.Duration) *EventStoreBuilder {
+	b.pollInterval = pollInterval
+	return b
+}
+
 func (b *EventStoreBuilder) Build() *EventStore {
 	if b.appendOnlyStore == nil {
 		panic("append only store type not set")
 	}
+	if b.events != nil && b.deserializers != nil {
+		panic("both the event registry and the deserializer registry are set, create the deserializer registry with NewDeserializerRegistryForEvents instead")
+	}
+
+	events := b.events
+	if events == nil {
+		events = serializer.DefaultEventRegistry()
+	}
+
+	eventSerializer := b.serializer
+	if eventSerializer == nil {
+		eventSerializer = serializer.NewJSON(events)
+	}
+
+	deserializers := b.deserializers
+	if deserializers == nil {
+		deserializers = NewDeserializerRegistryForEvents(events)
+	}
+	if b.deserializer != nil {
+		deserializers.WithDefaultDeserializer(b.deserializer)
+		if withContentType, ok := b.deserializer.(interface{ ContentType() string }); ok {
+			deserializers.Register(withContentType.ContentType(), b.deserializer)
+		}
+	}
 
 	return &EventStore{
-		serializer:      b.serializer,
+		serializer:      eventSerializer,
 		appendOnlyStore: b.appendOnlyStore,
 
 		ReadOnlyEventStore: &ReadOnlyEventStore{
-			deserializer:  b.deserializer,
+			deserializers: deserializers,
 			readOnlyStore: b.appendOnlyStore,
+			notifier:      newAppendNotifier(),
+			pollInterval:  b.pollInterval,
 		},
 	}
 }
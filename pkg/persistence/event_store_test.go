@@ -10,9 +10,9 @@ import (
 	"github.com/tembleking/myBankSourcing/pkg/account"
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
 	"github.com/tembleking/myBankSourcing/pkg/persistence/mocks"
 	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
-	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
 	. "github.com/tembleking/myBankSourcing/test/matchers"
 )
 
@@ -28,7 +28,7 @@ var _ = Describe("EventStore", func() {
 		ctx = context.Background()
 		ctrl = gomock.NewController(GinkgoT())
 		appendOnlyStore = mocks.NewMockAppendOnlyStore(ctrl)
-		eventStore = persistence.NewEventStoreBuilder(sqlite.InMemory()).WithAppendOnlyStore(appendOnlyStore).Build()
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).WithAppendOnlyStore(appendOnlyStore).Build()
 	})
 
 	It("should be able to load an event stream", func() {
@@ -45,7 +45,7 @@ var _ = Describe("EventStore", func() {
 		}))
 	})
 
-	It("should be able to append to an event stream", func() {
+	It("should be able to append the events of several aggregates in a unit of work", func() {
 		appendOnlyStore.EXPECT().Append(
 			ctx,
 			persistence.StoredStreamEvent{
@@ -70,7 +70,9 @@ var _ = Describe("EventStore", func() {
 		anotherAggregate := fakeAggregate{}.withID("aggregate-1").withVersion(1).withEvents(
 			&account.AmountDeposited{ID: "event0", AccountID: "some-account", Quantity: 10, Balance: 10},
 		)
-		err := eventStore.AppendToStream(ctx, &anAggregate, &anotherAggregate)
+		unitOfWork := eventStore.NewUnitOfWork()
+		Expect(unitOfWork.Register(&anAggregate, &anotherAggregate)).To(Succeed())
+		err := unitOfWork.Commit(ctx)
 		Expect(err).To(BeNil())
 	})
 
@@ -0,0 +1,470 @@
+package filelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+const defaultMaxSegmentSize = 64 << 20
+
+var ErrCorruptSegment = errors.New("corrupt segment")
+
+// SyncPolicy decides when the appended events are flushed to the disk with fsync.
+type SyncPolicy int
+
+const (
+	// SyncEveryAppend flushes every append before returning, so no committed event is lost on a power failure.
+	SyncEveryAppend SyncPolicy = iota
+	// SyncInterval flushes an append only if the last flush is older than the sync interval,
+	// so the events appended within the interval may be lost on a power failure.
+	SyncInterval
+	// SyncNever leaves the flushing to the operating system. The events survive a crash of the process,
+	// but not a power failure.
+	SyncNever
+)
+
+// AppendOnlyStore is a persistence.AppendOnlyStore that keeps the events in segment files in a directory,
+// so it needs no database nor cgo. Every record is checksummed, and the index of the streams is rebuilt
+// in memory when the store is opened, discarding any append that was not completely written.
+// The directory must only be opened by a single process at a time.
+type AppendOnlyStore struct {
+	log          *segmentedLog
+	afterEventID *domain.EventID
+	limit        int
+}
+
+type segmentedLog struct {
+	directory      string
+	segments       []*segment
+	entries        []indexEntry
+	streams        map[tenantStream][]int
+	eventIDs       map[domain.EventID]int
+	versions       map[tenantStreamID]struct{}
+	syncPolicy     SyncPolicy
+	syncInterval   time.Duration
+	lastSync       time.Time
+	maxSegmentSize int64
+	mutex          sync.RWMutex
+}
+
+// indexEntry is the position of an event in the segments. The entries are kept in the order they were appended.
+type indexEntry struct {
+	segment *segment
+	offset  int64
+	tenant  domain.TenantID
+}
+
+// tenantStream and tenantStreamID identify a stream and a version of it in the whole log,
+// since every tenant has its own streams.
+type (
+	tenantStream struct {
+		tenant     domain.TenantID
+		streamName string
+	}
+	tenantStreamID struct {
+		tenant domain.TenantID
+		id     persistence.StreamID
+	}
+)
+
+// Open opens the store in the given directory, creating it if it does not exist.
+func Open(directory string) (*AppendOnlyStore, error) {
+	if err := os.MkdirAll(directory, 0o750); err != nil {
+		return nil, fmt.Errorf("unable to create the store directory: %w", err)
+	}
+
+	log := &segmentedLog{
+		directory:      directory,
+		streams:        map[tenantStream][]int{},
+		eventIDs:       map[domain.EventID]int{},
+		versions:       map[tenantStreamID]struct{}{},
+		syncPolicy:     SyncEveryAppend,
+		maxSegmentSize: defaultMaxSegmentSize,
+	}
+	if err := log.load(); err != nil {
+		log.close()
+		return nil, err
+	}
+
+	return &AppendOnlyStore{log: log}, nil
+}
+
+// WithSyncPolicy sets when the appends are flushed to the disk. The interval is only used by SyncInterval.
+func (a *AppendOnlyStore) WithSyncPolicy(syncPolicy SyncPolicy, interval time.Duration) *AppendOnlyStore {
+	a.log.mutex.Lock()
+	defer a.log.mutex.Unlock()
+
+	a.log.syncPolicy = syncPolicy
+	a.log.syncInterval = interval
+	return a
+}
+
+// WithMaxSegmentSize sets the size in bytes after which a new segment is started.
+// An append is never split between segments, so a segment may be bigger if a single append does not fit.
+func (a *AppendOnlyStore) WithMaxSegmentSize(maxSegmentSize int64) *AppendOnlyStore {
+	a.log.mutex.Lock()
+	defer a.log.mutex.Unlock()
+
+	a.log.maxSegmentSize = maxSegmentSize
+	return a
+}
+
+func (a *AppendOnlyStore) AfterEventID(eventID domain.EventID) persistence.ReadOnlyStore {
+	return &AppendOnlyStore{log: a.log, afterEventID: &eventID, limit: a.limit}
+}
+
+func (a *AppendOnlyStore) Limit(limit int) persistence.ReadOnlyStore {
+	return &AppendOnlyStore{log: a.log, afterEventID: a.afterEventID, limit: limit}
+}
+
+func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.StoredStreamEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	events = persistence.WithTenantOf(ctx, events)
+
+	buffer := &bytes.Buffer{}
+	offsets := make([]int64, 0, len(events))
+	for i, event := range events {
+		var flags byte
+		if i == len(events)-1 {
+			flags = flagEndOfBatch
+		}
+		offsets = append(offsets, int64(buffer.Len()))
+		if err := encodeRecord(buffer, event, flags); err != nil {
+			return fmt.Errorf("unable to encode the event '%s': %w", event.EventID, err)
+		}
+	}
+
+	a.log.mutex.Lock()
+	defer a.log.mutex.Unlock()
+
+	if err := a.log.checkConflicts(events); err != nil {
+		return err
+	}
+
+	segment, err := a.log.segmentForAppend(int64(buffer.Len()))
+	if err != nil {
+		return err
+	}
+
+	start := segment.size
+	if _, err := segment.file.WriteAt(buffer.Bytes(), start); err != nil {
+		// The partial write is discarded, so the next append does not leave it in the middle of the segment.
+		_ = segment.file.Truncate(start)
+		return fmt.Errorf("unable to write the events to the segment: %w", err)
+	}
+	segment.size += int64(buffer.Len())
+
+	if err := a.log.syncAfterAppend(segment); err != nil {
+		// The events are not indexed, so they are discarded from the segment too, as if they were never written.
+		_ = segment.file.Truncate(start)
+		segment.size = start
+		return err
+	}
+
+	for i, event := range events {
+		a.log.index(event, indexEntry{segment: segment, offset: start + offsets[i], tenant: event.TenantID})
+	}
+	return nil
+}
+
+func (a *AppendOnlyStore) ReadAllRecords(ctx context.Context) ([]persistence.StoredStreamEvent, error) {
+	a.log.mutex.RLock()
+	defer a.log.mutex.RUnlock()
+
+	return a.readPositions(a.tenantPositions(ctx))
+}
+
+func (a *AppendOnlyStore) CountRecords(ctx context.Context) (int, error) {
+	a.log.mutex.RLock()
+	defer a.log.mutex.RUnlock()
+
+	return len(a.filterPositions(a.tenantPositions(ctx))), nil
+}
+
+// tenantPositions returns the positions of the events of the tenant of the context in the log.
+func (a *AppendOnlyStore) tenantPositions(ctx context.Context) []int {
+	tenant, scoped := persistence.TenantFilter(ctx)
+	positions := make([]int, 0, len(a.log.entries))
+	for position, entry := range a.log.entries {
+		if !scoped || entry.tenant == tenant {
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+func (a *AppendOnlyStore) ReadRecords(ctx context.Context, streamName string) ([]persistence.StoredStreamEvent, error) {
+	a.log.mutex.RLock()
+	defer a.log.mutex.RUnlock()
+
+	tenant, scoped := persistence.TenantFilter(ctx)
+	if scoped {
+		return a.readPositions(a.log.streams[tenantStream{tenant: tenant, streamName: streamName}])
+	}
+
+	var positions []int
+	for stream, streamPositions := range a.log.streams {
+		if stream.streamName == streamName {
+			positions = append(positions, streamPositions...)
+		}
+	}
+	sort.Ints(positions)
+	return a.readPositions(positions)
+}
+
+// readPositions reads the events in the given positions of the index, applying the filters of the store.
+func (a *AppendOnlyStore) readPositions(positions []int) ([]persistence.StoredStreamEvent, error) {
+	positions = a.filterPositions(positions)
+	events := make([]persistence.StoredStreamEvent, 0, len(positions))
+	for _, position := range positions {
+		entry := a.log.entries[position]
+		event, _, _, err := readRecord(entry.segment.file, entry.offset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the event in segment %d at offset %d: %w", entry.segment.number, entry.offset, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// filterPositions returns the given positions of the index that pass the filters of the store.
+func (a *AppendOnlyStore) filterPositions(positions []int) []int {
+	if a.afterEventID != nil {
+		afterPosition, exists := a.log.eventIDs[*a.afterEventID]
+		if !exists {
+			return nil
+		}
+		first := sort.SearchInts(positions, afterPosition+1)
+		positions = positions[first:]
+	}
+	if a.limit > 0 && len(positions) > a.limit {
+		positions = positions[:a.limit]
+	}
+	return positions
+}
+
+// Close flushes the appended events to the disk and closes the segments.
+func (a *AppendOnlyStore) Close() error {
+	a.log.mutex.Lock()
+	defer a.log.mutex.Unlock()
+
+	return a.log.close()
+}
+
+func (l *segmentedLog) checkConflicts(events []persistence.StoredStreamEvent) error {
+	appendedIDs := make(map[domain.EventID]struct{}, len(events))
+	appendedVersions := make(map[tenantStreamID]struct{}, len(events))
+	for _, event := range events {
+		version := tenantStreamID{tenant: event.TenantID, id: event.ID}
+		_, versionExists := l.versions[version]
+		_, versionAppended := appendedVersions[version]
+		_, eventIDExists := l.eventIDs[event.EventID]
+		_, eventIDAppended := appendedIDs[event.EventID]
+		if versionExists || versionAppended || eventIDExists || eventIDAppended {
+			return persistence.ErrUnexpectedVersion
+		}
+		appendedVersions[version] = struct{}{}
+		appendedIDs[event.EventID] = struct{}{}
+	}
+	return nil
+}
+
+// segmentForAppend returns the segment the append must be written to, starting a new one if it does not fit.
+func (l *segmentedLog) segmentForAppend(size int64) (*segment, error) {
+	last := l.segments[len(l.segments)-1]
+	if last.size == 0 || last.size+size <= l.maxSegmentSize {
+		return last, nil
+	}
+
+	// The previous segment is flushed before starting the next one, so only the last one can have torn writes.
+	if err := last.file.Sync(); err != nil {
+		return nil, fmt.Errorf("unable to flush the segment: %w", err)
+	}
+	return l.createSegment(last.number + 1)
+}
+
+func (l *segmentedLog) syncAfterAppend(segment *segment) error {
+	switch l.syncPolicy {
+	case SyncEveryAppend:
+	case SyncInterval:
+		if time.Since(l.lastSync) < l.syncInterval {
+			return nil
+		}
+	case SyncNever:
+		return nil
+	}
+
+	if err := segment.file.Sync(); err != nil {
+		return fmt.Errorf("unable to flush the segment: %w", err)
+	}
+	l.lastSync = time.Now()
+	return nil
+}
+
+func (l *segmentedLog) index(event persistence.StoredStreamEvent, entry indexEntry) {
+	position := len(l.entries)
+	l.entries = append(l.entries, entry)
+	stream := tenantStream{tenant: event.TenantID, streamName: event.ID.StreamName}
+	l.streams[stream] = append(l.streams[stream], position)
+	l.eventIDs[event.EventID] = position
+	l.versions[tenantStreamID{tenant: event.TenantID, id: event.ID}] = struct{}{}
+}
+
+// load opens the segments of the directory and rebuilds the index from them.
+// The records after the last complete append of the last segment are the result of a torn write and are truncated.
+func (l *segmentedLog) load() error {
+	numbers, err := l.segmentNumbers()
+	if err != nil {
+		return err
+	}
+	if len(numbers) == 0 {
+		_, err := l.createSegment(0)
+		return err
+	}
+
+	for i, number := range numbers {
+		file, err := os.OpenFile(segmentFileName(l.directory, number), os.O_RDWR, 0o640)
+		if err != nil {
+			return fmt.Errorf("unable to open the segment %d: %w", number, err)
+		}
+		segment := &segment{file: file, number: number}
+		l.segments = append(l.segments, segment)
+
+		isLast := i == len(numbers)-1
+		if err := l.loadSegment(segment, isLast); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *segmentedLog) loadSegment(segment *segment, isLast bool) error {
+	info, err := segment.file.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to read the size of the segment %d: %w", segment.number, err)
+	}
+	fileSize := info.Size()
+
+	var (
+		offset         int64
+		pendingEvents  []persistence.StoredStreamEvent
+		pendingEntries []indexEntry
+	)
+	for offset < fileSize {
+		event, flags, next, err := readRecord(segment.file, offset)
+		if isTornRecord(err, next, fileSize) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w %d: %w", ErrCorruptSegment, segment.number, err)
+		}
+
+		pendingEvents = append(pendingEvents, event)
+		pendingEntries = append(pendingEntries, indexEntry{segment: segment, offset: offset, tenant: event.TenantID})
+		offset = next
+
+		if flags&flagEndOfBatch != 0 {
+			for i := range pendingEvents {
+				l.index(pendingEvents[i], pendingEntries[i])
+			}
+			pendingEvents, pendingEntries = nil, nil
+			segment.size = offset
+		}
+	}
+
+	if segment.size == fileSize {
+		return nil
+	}
+	if !isLast {
+		return fmt.Errorf("%w %d: incomplete append at offset %d", ErrCorruptSegment, segment.number, segment.size)
+	}
+	if err := segment.file.Truncate(segment.size); err != nil {
+		return fmt.Errorf("unable to discard the incomplete append of the segment %d: %w", segment.number, err)
+	}
+	return segment.file.Sync()
+}
+
+// isTornRecord reports if the error reading a record is the result of a write that did not finish.
+// Only the trailing record of a segment can be torn: an incomplete record, or one whose checksum does not match
+// and ends at the end of the file. A damaged record followed by other records is a corruption of the segment.
+func isTornRecord(err error, next int64, fileSize int64) bool {
+	if errors.Is(err, errTornRecord) {
+		return true
+	}
+	return errors.Is(err, errDamagedRecord) && next == fileSize
+}
+
+func (l *segmentedLog) segmentNumbers() ([]int, error) {
+	files, err := os.ReadDir(l.directory)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the segments: %w", err)
+	}
+
+	var numbers []int
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) != segmentFileExtension {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimSuffix(name, segmentFileExtension))
+		if err != nil {
+			continue
+		}
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+func (l *segmentedLog) createSegment(number int) (*segment, error) {
+	file, err := os.OpenFile(segmentFileName(l.directory, number), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the segment %d: %w", number, err)
+	}
+
+	// The directory is flushed so the new segment file is not lost on a power failure.
+	if err := syncDirectory(l.directory); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	segment := &segment{file: file, number: number}
+	l.segments = append(l.segments, segment)
+	return segment, nil
+}
+
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return fmt.Errorf("unable to open the store directory: %w", err)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("unable to flush the store directory: %w", err)
+	}
+	return nil
+}
+
+func (l *segmentedLog) close() error {
+	var errs []error
+	for _, segment := range l.segments {
+		errs = append(errs, segment.file.Sync(), segment.file.Close())
+	}
+	l.segments = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,13 @@
+package filelog_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFilelog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Filelog Suite")
+}
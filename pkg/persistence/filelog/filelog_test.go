@@ -0,0 +1,295 @@
+package filelog_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/filelog"
+	"github.com/tembleking/myBankSourcing/test/conformance"
+)
+
+var _ = Describe("Filelog AppendOnlyStore conformance", func() {
+	conformance.AppendOnlyStore(func(context.Context) persistence.AppendOnlyStore {
+		store, err := filelog.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(store.Close)
+		return store
+	})
+})
+
+var _ = Describe("Filelog AppendOnlyStore", func() {
+	var (
+		ctx       context.Context
+		directory string
+		store     *filelog.AppendOnlyStore
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		directory = GinkgoT().TempDir()
+
+		var err error
+		store, err = filelog.Open(directory)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	reopen := func() {
+		GinkgoHelper()
+		Expect(store.Close()).To(Succeed())
+
+		var err error
+		store, err = filelog.Open(directory)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("should be able to append to multiple events to an event stream", func() {
+		err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data1"), ContentType: "some-content-type"})
+		Expect(err).To(BeNil())
+
+		err = store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName", EventData: []byte("data2"), ContentType: "some-content-type"})
+		Expect(err).To(BeNil())
+	})
+
+	It("keeps the tenant of the events after reopening the store", func() {
+		tenantCtx := domain.WithTenant(ctx, "some-tenant")
+		Expect(store.Append(tenantCtx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName"})).To(Succeed())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event1", EventName: "eventName"})).To(Succeed())
+		reopen()
+
+		Expect(store.ReadRecords(tenantCtx, "aggregate-0")).To(HaveExactElements(HaveField("EventID", domain.EventID("event0"))))
+		Expect(store.ReadRecords(ctx, "aggregate-0")).To(HaveExactElements(HaveField("EventID", domain.EventID("event1"))))
+		Expect(store.Append(tenantCtx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event2", EventName: "eventName"})).To(MatchError(persistence.ErrUnexpectedVersion))
+	})
+
+	When("there is a double append with the same expected version", func() {
+		It("should return an error", func() {
+			err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})
+			Expect(err).To(BeNil())
+
+			err = store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event1", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+
+		It("should return an error after reopening the store", func() {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName"})).To(Succeed())
+			reopen()
+
+			err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event1", EventName: "eventName"})
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+	})
+
+	It("should not append any event of the batch if one of them conflicts", func() {
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName"})).To(Succeed())
+
+		err := store.Append(ctx,
+			persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "event1", EventName: "eventName"},
+			persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 1}, EventID: "event0", EventName: "eventName"},
+		)
+
+		Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		Expect(store.ReadAllRecords(ctx)).To(HaveLen(1))
+	})
+
+	It("should be able to read from an event stream", func() {
+		happenedOn := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type", HappenedOn: happenedOn})
+		Expect(err).To(BeNil())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "event1", EventName: "eventName"})).To(Succeed())
+
+		data, err := store.ReadRecords(ctx, "aggregate-0")
+		Expect(err).To(BeNil())
+		Expect(data).To(Equal([]persistence.StoredStreamEvent{
+			{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type", HappenedOn: happenedOn},
+		}))
+	})
+
+	When("filtering events after other eventID", func() {
+		BeforeEach(func() {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data0"), ContentType: "some-content-type-0"})).To(Succeed())
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "event1", EventName: "eventNameToIgnore", EventData: []byte("data1"), ContentType: "some-content-type-1"})).To(Succeed())
+			Expect(store.Append(ctx,
+				persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 0}, EventID: "event2", EventName: "eventName", EventData: []byte("data2-0"), ContentType: "some-content-type-0"},
+				persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 1}, EventID: "event3", EventName: "eventName", EventData: []byte("data2-1"), ContentType: "some-content-type-1"},
+			)).To(Succeed())
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 2}, EventID: "event4", EventName: "eventName", EventData: []byte("data2-2"), ContentType: "some-content-type-2"})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			// Checks if the store is not modified by the filter methods.
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(5))
+		})
+
+		It("should return only the events after the eventID", func() {
+			records, err := store.AfterEventID("event2").ReadAllRecords(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(records).To(Equal([]persistence.StoredStreamEvent{
+				{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 1}, EventID: "event3", EventName: "eventName", EventData: []byte("data2-1"), ContentType: "some-content-type-1"},
+				{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 2}, EventID: "event4", EventName: "eventName", EventData: []byte("data2-2"), ContentType: "some-content-type-2"},
+			}))
+		})
+
+		It("should return a limit number of events after the eventID", func() {
+			records, err := store.AfterEventID("event1").Limit(2).ReadAllRecords(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(records).To(Equal([]persistence.StoredStreamEvent{
+				{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 0}, EventID: "event2", EventName: "eventName", EventData: []byte("data2-0"), ContentType: "some-content-type-0"},
+				{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 1}, EventID: "event3", EventName: "eventName", EventData: []byte("data2-1"), ContentType: "some-content-type-1"},
+			}))
+		})
+
+		It("should filter the events of a stream", func() {
+			Expect(store.AfterEventID("event2").ReadRecords(ctx, "aggregate-2")).To(HaveExactElements(
+				HaveField("EventID", BeEquivalentTo("event3")),
+				HaveField("EventID", BeEquivalentTo("event4")),
+			))
+		})
+
+		It("should return nothing if the eventID does not exist", func() {
+			Expect(store.AfterEventID("nonexistent").ReadAllRecords(ctx)).To(BeEmpty())
+		})
+
+		It("should rebuild the index when the store is reopened", func() {
+			reopen()
+
+			Expect(store.AfterEventID("event1").Limit(2).ReadAllRecords(ctx)).To(HaveExactElements(
+				HaveField("EventID", BeEquivalentTo("event2")),
+				HaveField("EventID", BeEquivalentTo("event3")),
+			))
+			Expect(store.ReadRecords(ctx, "aggregate-2")).To(HaveLen(3))
+		})
+	})
+
+	It("should start new segments when they are full", func() {
+		store.WithMaxSegmentSize(1).WithSyncPolicy(filelog.SyncNever, 0)
+		for i := range 3 {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: uint64(i)}, EventID: domain.EventID(fmt.Sprintf("event%d", i)), EventName: "eventName"})).To(Succeed())
+		}
+		reopen()
+
+		Expect(filepath.Glob(filepath.Join(directory, "*.log"))).To(HaveLen(3))
+		Expect(store.ReadRecords(ctx, "aggregate-0")).To(HaveLen(3))
+	})
+
+	When("the last append was torn", func() {
+		var segmentFile string
+
+		BeforeEach(func() {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName"})).To(Succeed())
+			segments, err := filepath.Glob(filepath.Join(directory, "*.log"))
+			Expect(err).ToNot(HaveOccurred())
+			segmentFile = segments[0]
+			info, err := os.Stat(segmentFile)
+			Expect(err).ToNot(HaveOccurred())
+			completeSize := info.Size()
+
+			Expect(store.Append(ctx,
+				persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName"},
+				persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 2}, EventID: "event2", EventName: "eventName"},
+			)).To(Succeed())
+			Expect(store.Close()).To(Succeed())
+
+			info, err = os.Stat(segmentFile)
+			Expect(err).ToNot(HaveOccurred())
+			// Keeps the first event of the second append and half of the second one.
+			Expect(os.Truncate(segmentFile, completeSize+(info.Size()-completeSize)*3/4)).To(Succeed())
+
+			store, err = filelog.Open(directory)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("discards the whole append", func() {
+			Expect(store.ReadAllRecords(ctx)).To(HaveExactElements(
+				HaveField("EventID", BeEquivalentTo("event0")),
+			))
+		})
+
+		It("accepts the events of the torn append again", func() {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName"})).To(Succeed())
+			reopen()
+
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(2))
+		})
+	})
+
+	It("discards the last append if its checksum does not match", func() {
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data0")})).To(Succeed())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName", EventData: []byte("data1")})).To(Succeed())
+		Expect(store.Close()).To(Succeed())
+
+		segments, err := filepath.Glob(filepath.Join(directory, "*.log"))
+		Expect(err).ToNot(HaveOccurred())
+		content, err := os.ReadFile(segments[0])
+		Expect(err).ToNot(HaveOccurred())
+		content[len(content)-1] ^= 0xff
+		Expect(os.WriteFile(segments[0], content, 0o640)).To(Succeed())
+
+		store, err = filelog.Open(directory)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.ReadAllRecords(ctx)).To(HaveExactElements(
+			HaveField("EventID", BeEquivalentTo("event0")),
+		))
+	})
+
+	It("fails to open if a record whose checksum does not match is followed by other records", func() {
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data0")})).To(Succeed())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName", EventData: []byte("data1")})).To(Succeed())
+		Expect(store.Close()).To(Succeed())
+
+		segments, err := filepath.Glob(filepath.Join(directory, "*.log"))
+		Expect(err).ToNot(HaveOccurred())
+		content, err := os.ReadFile(segments[0])
+		Expect(err).ToNot(HaveOccurred())
+		dataOffset := bytes.Index(content, []byte("data0"))
+		Expect(dataOffset).To(BeNumerically(">", 0))
+		content[dataOffset] ^= 0xff
+		Expect(os.WriteFile(segments[0], content, 0o640)).To(Succeed())
+
+		_, err = filelog.Open(directory)
+		Expect(err).To(MatchError(filelog.ErrCorruptSegment))
+
+		Expect(os.ReadFile(segments[0])).To(Equal(content), "the segment must not be truncated")
+	})
+
+	It("fails to open if a segment that is not the last one is corrupt", func() {
+		store.WithMaxSegmentSize(1)
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName"})).To(Succeed())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName"})).To(Succeed())
+		Expect(store.Close()).To(Succeed())
+
+		segments, err := filepath.Glob(filepath.Join(directory, "*.log"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Truncate(segments[0], 10)).To(Succeed())
+
+		_, err = filelog.Open(directory)
+		Expect(err).To(MatchError(filelog.ErrCorruptSegment))
+	})
+
+	It("can be used as the store of an event store", func() {
+		anAccount, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(anAccount.DepositMoney(50)).To(Succeed())
+		Expect(account.NewRepository(persistence.NewEventStoreBuilder(store).Build()).Save(ctx, anAccount)).To(Succeed())
+		reopen()
+
+		loaded, err := account.NewRepository(persistence.NewEventStoreBuilder(store).Build()).GetByID(ctx, "some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Balance()).To(Equal(50))
+	})
+})
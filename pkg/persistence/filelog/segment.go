@@ -0,0 +1,184 @@
+package filelog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+// Every record in a segment is a header followed by the encoded event:
+//
+//	length   uint32, little endian, length of the payload
+//	checksum uint32, little endian, CRC-32C of the payload
+//	payload  flags byte | stream version uvarint | happened on | stream name | event ID | event name | content type | event data [| tenant ID]
+//
+// where the strings and byte slices are prefixed with their uvarint length.
+// The tenant ID is only written for the events outside of the DefaultTenant, so the older segments are still valid.
+// The last record of every append has the flagEndOfBatch flag, so a batch that was not completely written is discarded.
+const (
+	recordHeaderSize = 8
+	flagEndOfBatch   = byte(1)
+
+	// maxRecordSize protects the recovery from allocating huge buffers when reading a corrupted length.
+	maxRecordSize = 64 << 20
+
+	segmentFileExtension = ".log"
+)
+
+var (
+	crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+	errTornRecord    = errors.New("torn record")
+	errDamagedRecord = errors.New("damaged record")
+)
+
+type segment struct {
+	file   *os.File
+	number int
+	size   int64
+}
+
+func segmentFileName(directory string, number int) string {
+	return filepath.Join(directory, fmt.Sprintf("%020d%s", number, segmentFileExtension))
+}
+
+func encodeRecord(buffer *bytes.Buffer, event persistence.StoredStreamEvent, flags byte) error {
+	happenedOn, err := event.HappenedOn.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("error encoding the date the event happened on: %w", err)
+	}
+
+	payload := []byte{flags}
+	payload = binary.AppendUvarint(payload, event.ID.StreamVersion)
+	payload = appendBytes(payload, happenedOn)
+	payload = appendBytes(payload, []byte(event.ID.StreamName))
+	payload = appendBytes(payload, []byte(event.EventID))
+	payload = appendBytes(payload, []byte(event.EventName))
+	payload = appendBytes(payload, []byte(event.ContentType))
+	payload = appendBytes(payload, event.EventData)
+	if event.TenantID != domain.DefaultTenant {
+		payload = appendBytes(payload, []byte(event.TenantID))
+	}
+
+	header := make([]byte, recordHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(payload, crc32c))
+
+	buffer.Write(header)
+	buffer.Write(payload)
+	return nil
+}
+
+func appendBytes(payload []byte, value []byte) []byte {
+	payload = binary.AppendUvarint(payload, uint64(len(value)))
+	return append(payload, value...)
+}
+
+// readRecord reads the record at the given offset of the segment, and returns it with the offset of the next one.
+// It returns errTornRecord if the record is incomplete, and errDamagedRecord if its length is invalid or its checksum does not match.
+// The offset of the next record is also returned for a checksum mismatch, so the caller can tell if the record was the trailing one.
+func readRecord(reader io.ReaderAt, offset int64) (event persistence.StoredStreamEvent, flags byte, next int64, err error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := reader.ReadAt(header, offset); err != nil {
+		return event, 0, 0, fmt.Errorf("%w: reading header at offset %d: %w", errTornRecord, offset, err)
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if length == 0 || length > maxRecordSize {
+		return event, 0, 0, fmt.Errorf("%w: invalid length %d at offset %d", errDamagedRecord, length, offset)
+	}
+
+	payload := make([]byte, length)
+	if _, err := reader.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		return event, 0, 0, fmt.Errorf("%w: reading payload at offset %d: %w", errTornRecord, offset, err)
+	}
+	next = offset + recordHeaderSize + int64(length)
+	if crc32.Checksum(payload, crc32c) != binary.LittleEndian.Uint32(header[4:8]) {
+		return event, 0, next, fmt.Errorf("%w: checksum mismatch at offset %d", errDamagedRecord, offset)
+	}
+
+	event, flags, err = decodePayload(payload)
+	if err != nil {
+		return event, 0, 0, fmt.Errorf("error decoding the record at offset %d: %w", offset, err)
+	}
+	return event, flags, next, nil
+}
+
+func decodePayload(payload []byte) (persistence.StoredStreamEvent, byte, error) {
+	decoder := payloadDecoder{payload: payload[1:]}
+	flags := payload[0]
+
+	streamVersion := decoder.uvarint()
+	happenedOnBytes := decoder.bytes()
+	streamName := decoder.bytes()
+	eventID := decoder.bytes()
+	eventName := decoder.bytes()
+	contentType := decoder.bytes()
+	eventData := decoder.bytes()
+	var tenantID []byte
+	if len(decoder.payload) > 0 {
+		tenantID = decoder.bytes()
+	}
+	if decoder.err != nil {
+		return persistence.StoredStreamEvent{}, 0, decoder.err
+	}
+
+	var happenedOn time.Time
+	if err := happenedOn.UnmarshalBinary(happenedOnBytes); err != nil {
+		return persistence.StoredStreamEvent{}, 0, fmt.Errorf("error decoding the date the event happened on: %w", err)
+	}
+
+	return persistence.StoredStreamEvent{
+		ID: persistence.StreamID{
+			StreamName:    string(streamName),
+			StreamVersion: streamVersion,
+		},
+		EventID:     domain.EventID(eventID),
+		EventName:   string(eventName),
+		EventData:   eventData,
+		HappenedOn:  happenedOn.UTC(),
+		ContentType: string(contentType),
+		TenantID:    domain.TenantID(tenantID),
+	}, flags, nil
+}
+
+type payloadDecoder struct {
+	err     error
+	payload []byte
+}
+
+func (d *payloadDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, read := binary.Uvarint(d.payload)
+	if read <= 0 {
+		d.err = errors.New("invalid varint in record")
+		return 0
+	}
+	d.payload = d.payload[read:]
+	return value
+}
+
+func (d *payloadDecoder) bytes() []byte {
+	length := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if length > uint64(len(d.payload)) {
+		d.err = errors.New("field longer than the record")
+		return nil
+	}
+	value := d.payload[:length:length]
+	d.payload = d.payload[length:]
+	return value
+}
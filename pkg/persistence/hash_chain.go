@@ -0,0 +1,61 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// ChainVerifier is implemented by the stores that chain every event to its predecessors with a hash,
+// so any modification, removal or reordering of the stored events can be detected.
+type ChainVerifier interface {
+	// VerifyChain walks the whole log recomputing the hashes, and stops at the first broken link.
+	VerifyChain(ctx context.Context) (ChainVerification, error)
+}
+
+type ChainVerification struct {
+	// BrokenLink is the first event whose hash does not match, or nil if the whole chain is valid.
+	BrokenLink *BrokenLink
+	// VerifiedEvents is the number of events verified before the broken link, if any.
+	VerifiedEvents int
+	// UnchainedEvents is the number of events stored before the hashes were introduced, which cannot be verified.
+	UnchainedEvents int
+}
+
+type BrokenLink struct {
+	EventID domain.EventID
+	Reason  string
+	ID      StreamID
+}
+
+// ChainHash returns the hash linking the event to the previous one, either in its stream or in the whole log.
+// The hash of the first event is linked to an empty previous hash.
+func ChainHash(previousHash string, event StoredStreamEvent) string {
+	fields := [][]byte{
+		[]byte(previousHash),
+		[]byte(event.ID.StreamName),
+		[]byte(strconv.FormatUint(event.ID.StreamVersion, 10)),
+		[]byte(event.EventID),
+		[]byte(event.EventName),
+		[]byte(event.ContentType),
+		[]byte(event.HappenedOn.UTC().Format(time.RFC3339Nano)),
+		event.EventData,
+	}
+	// The tenant is only hashed if it is not the default one, so the events stored before the tenants still verify.
+	if event.TenantID != domain.DefaultTenant {
+		fields = append(fields, []byte(event.TenantID))
+	}
+
+	hash := sha256.New()
+	for _, field := range fields {
+		// Every field is prefixed with its length, so the boundaries between them can't be moved.
+		_ = binary.Write(hash, binary.BigEndian, uint64(len(field)))
+		hash.Write(field)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,161 @@
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+// AppendOnlyStore is a persistence.AppendOnlyStore that keeps the events in memory,
+// with the same version conflict semantics as the stores backed by a database.
+type AppendOnlyStore struct {
+	log          *eventLog
+	afterEventID *domain.EventID
+	limit        int
+}
+
+type eventLog struct {
+	events   []persistence.StoredStreamEvent
+	streams  map[tenantStream][]int
+	eventIDs map[domain.EventID]int
+	versions map[tenantStreamID]struct{}
+	mutex    sync.RWMutex
+}
+
+// tenantStream and tenantStreamID identify a stream and a version of it in the whole log,
+// since every tenant has its own streams.
+type (
+	tenantStream struct {
+		tenant     domain.TenantID
+		streamName string
+	}
+	tenantStreamID struct {
+		tenant domain.TenantID
+		id     persistence.StreamID
+	}
+)
+
+func NewAppendOnlyStore() *AppendOnlyStore {
+	return &AppendOnlyStore{log: &eventLog{
+		streams:  map[tenantStream][]int{},
+		eventIDs: map[domain.EventID]int{},
+		versions: map[tenantStreamID]struct{}{},
+	}}
+}
+
+func (a *AppendOnlyStore) AfterEventID(eventID domain.EventID) persistence.ReadOnlyStore {
+	return &AppendOnlyStore{log: a.log, afterEventID: &eventID, limit: a.limit}
+}
+
+func (a *AppendOnlyStore) Limit(limit int) persistence.ReadOnlyStore {
+	return &AppendOnlyStore{log: a.log, afterEventID: a.afterEventID, limit: limit}
+}
+
+func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.StoredStreamEvent) error {
+	events = persistence.WithTenantOf(ctx, events)
+
+	a.log.mutex.Lock()
+	defer a.log.mutex.Unlock()
+
+	appendedIDs := make(map[domain.EventID]struct{}, len(events))
+	appendedVersions := make(map[tenantStreamID]struct{}, len(events))
+	for _, event := range events {
+		version := tenantStreamID{tenant: event.TenantID, id: event.ID}
+		_, versionExists := a.log.versions[version]
+		_, versionAppended := appendedVersions[version]
+		_, eventIDExists := a.log.eventIDs[event.EventID]
+		_, eventIDAppended := appendedIDs[event.EventID]
+		if versionExists || versionAppended || eventIDExists || eventIDAppended {
+			return persistence.ErrUnexpectedVersion
+		}
+		appendedVersions[version] = struct{}{}
+		appendedIDs[event.EventID] = struct{}{}
+	}
+
+	for _, event := range events {
+		position := len(a.log.events)
+		stream := tenantStream{tenant: event.TenantID, streamName: event.ID.StreamName}
+		event.EventData = bytes.Clone(event.EventData)
+		a.log.events = append(a.log.events, event)
+		a.log.streams[stream] = append(a.log.streams[stream], position)
+		a.log.eventIDs[event.EventID] = position
+		a.log.versions[tenantStreamID{tenant: event.TenantID, id: event.ID}] = struct{}{}
+	}
+	return nil
+}
+
+func (a *AppendOnlyStore) ReadAllRecords(ctx context.Context) ([]persistence.StoredStreamEvent, error) {
+	a.log.mutex.RLock()
+	defer a.log.mutex.RUnlock()
+
+	return a.readPositions(a.tenantPositions(ctx)), nil
+}
+
+func (a *AppendOnlyStore) CountRecords(ctx context.Context) (int, error) {
+	a.log.mutex.RLock()
+	defer a.log.mutex.RUnlock()
+
+	return len(a.filterPositions(a.tenantPositions(ctx))), nil
+}
+
+// tenantPositions returns the positions of the events of the tenant of the context in the log.
+func (a *AppendOnlyStore) tenantPositions(ctx context.Context) []int {
+	tenant, scoped := persistence.TenantFilter(ctx)
+	positions := make([]int, 0, len(a.log.events))
+	for position, event := range a.log.events {
+		if !scoped || event.TenantID == tenant {
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+func (a *AppendOnlyStore) ReadRecords(ctx context.Context, streamName string) ([]persistence.StoredStreamEvent, error) {
+	a.log.mutex.RLock()
+	defer a.log.mutex.RUnlock()
+
+	tenant, scoped := persistence.TenantFilter(ctx)
+	if scoped {
+		return a.readPositions(a.log.streams[tenantStream{tenant: tenant, streamName: streamName}]), nil
+	}
+
+	var positions []int
+	for stream, streamPositions := range a.log.streams {
+		if stream.streamName == streamName {
+			positions = append(positions, streamPositions...)
+		}
+	}
+	sort.Ints(positions)
+	return a.readPositions(positions), nil
+}
+
+// readPositions returns a copy of the events in the given positions of the log, applying the filters of the store.
+func (a *AppendOnlyStore) readPositions(positions []int) []persistence.StoredStreamEvent {
+	positions = a.filterPositions(positions)
+	events := make([]persistence.StoredStreamEvent, 0, len(positions))
+	for _, position := range positions {
+		event := a.log.events[position]
+		event.EventData = bytes.Clone(event.EventData)
+		events = append(events, event)
+	}
+	return events
+}
+
+// filterPositions returns the given positions of the log that pass the filters of the store.
+func (a *AppendOnlyStore) filterPositions(positions []int) []int {
+	if a.afterEventID != nil {
+		afterPosition, exists := a.log.eventIDs[*a.afterEventID]
+		if !exists {
+			return nil
+		}
+		positions = positions[sort.SearchInts(positions, afterPosition+1):]
+	}
+	if a.limit > 0 && len(positions) > a.limit {
+		positions = positions[:a.limit]
+	}
+	return positions
+}
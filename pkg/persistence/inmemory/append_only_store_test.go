@@ -0,0 +1,31 @@
+package inmemory_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/test/conformance"
+)
+
+var _ = Describe("Inmemory AppendOnlyStore", func() {
+	conformance.AppendOnlyStore(func(context.Context) persistence.AppendOnlyStore {
+		return inmemory.NewAppendOnlyStore()
+	})
+
+	It("does not share the event data with the caller", func(ctx context.Context) {
+		store := inmemory.NewAppendOnlyStore()
+		data := []byte("data")
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0"}, EventID: "event0", EventData: data})).To(Succeed())
+		data[0] = 'D'
+
+		records, err := store.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		records[0].EventData[1] = 'A'
+
+		Expect(store.ReadAllRecords(ctx)).To(ConsistOf(HaveField("EventData", []byte("data"))))
+	})
+})
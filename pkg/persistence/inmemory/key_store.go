@@ -0,0 +1,64 @@
+package inmemory
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+const keySize = 32
+
+type KeyStore struct {
+	keys  map[string][]byte
+	mutex sync.Mutex
+}
+
+func NewKeyStore() *KeyStore {
+	return &KeyStore{keys: make(map[string][]byte)}
+}
+
+func (k *KeyStore) CreateKey(_ context.Context, subjectID string) ([]byte, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, exists := k.keys[subjectID]
+	if exists && key == nil {
+		return nil, persistence.ErrSubjectKeyDeleted
+	}
+	if exists {
+		return key, nil
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("unable to generate the key of the subject '%s': %w", subjectID, err)
+	}
+	k.keys[subjectID] = key
+	return key, nil
+}
+
+func (k *KeyStore) Key(_ context.Context, subjectID string) ([]byte, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, exists := k.keys[subjectID]
+	if !exists {
+		return nil, persistence.ErrSubjectKeyNotFound
+	}
+	if key == nil {
+		return nil, persistence.ErrSubjectKeyDeleted
+	}
+	return key, nil
+}
+
+// DeleteKey keeps a nil key for the subject, so it is known to be deleted.
+func (k *KeyStore) DeleteKey(_ context.Context, subjectID string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.keys[subjectID] = nil
+	return nil
+}
@@ -0,0 +1,57 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+type ProjectionStore struct {
+	checkpoints map[string]persistence.ProjectionCheckpoint
+	snapshots   map[string]persistence.ProjectionSnapshot
+	mutex       sync.RWMutex
+}
+
+func NewProjectionStore() *ProjectionStore {
+	return &ProjectionStore{
+		checkpoints: make(map[string]persistence.ProjectionCheckpoint),
+		snapshots:   make(map[string]persistence.ProjectionSnapshot),
+	}
+}
+
+func (p *ProjectionStore) LoadCheckpoint(_ context.Context, projectionName string) (persistence.ProjectionCheckpoint, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if checkpoint, ok := p.checkpoints[projectionName]; ok {
+		return checkpoint, nil
+	}
+	return persistence.ProjectionCheckpoint{}, persistence.ErrProjectionCheckpointNotFound
+}
+
+func (p *ProjectionStore) SaveCheckpoint(_ context.Context, checkpoint persistence.ProjectionCheckpoint) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.checkpoints[checkpoint.Name] = checkpoint
+	return nil
+}
+
+func (p *ProjectionStore) LoadSnapshot(_ context.Context, projectionName string) (persistence.ProjectionSnapshot, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if snapshot, ok := p.snapshots[projectionName]; ok {
+		return snapshot, nil
+	}
+	return persistence.ProjectionSnapshot{}, persistence.ErrProjectionSnapshotNotFound
+}
+
+func (p *ProjectionStore) SaveSnapshot(_ context.Context, snapshot persistence.ProjectionSnapshot) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.snapshots[snapshot.Name] = snapshot
+	return nil
+}
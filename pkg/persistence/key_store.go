@@ -0,0 +1,21 @@
+package persistence
+
+import (
+	"context"
+)
+
+// KeyStore keeps the encryption keys of the personal data of every subject, apart from the events.
+// The personal data of a subject is erased by deleting its key, even if the events containing it cannot be modified.
+type KeyStore interface {
+	// CreateKey returns the key of the subject, creating it if the subject has none yet.
+	// It returns ErrSubjectKeyDeleted if the key of the subject has been deleted.
+	CreateKey(ctx context.Context, subjectID string) ([]byte, error)
+
+	// Key returns the key of the subject.
+	// It returns ErrSubjectKeyNotFound if the subject has no key, and ErrSubjectKeyDeleted if it has been deleted.
+	Key(ctx context.Context, subjectID string) ([]byte, error)
+
+	// DeleteKey deletes the key of the subject, so its personal data can no longer be decrypted.
+	// The subject can never get a new key, so no new personal data is stored for it either.
+	DeleteKey(ctx context.Context, subjectID string) error
+}
@@ -74,6 +74,21 @@ func (mr *MockAppendOnlyStoreMockRecorder) Append(ctx any, events ...any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAppendOnlyStore)(nil).Append), varargs...)
 }
 
+// CountRecords mocks base method.
+func (m *MockAppendOnlyStore) CountRecords(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecords", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecords indicates an expected call of CountRecords.
+func (mr *MockAppendOnlyStoreMockRecorder) CountRecords(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecords", reflect.TypeOf((*MockAppendOnlyStore)(nil).CountRecords), ctx)
+}
+
 // Limit mocks base method.
 func (m *MockAppendOnlyStore) Limit(limit int) persistence.ReadOnlyStore {
 	m.ctrl.T.Helper()
@@ -155,6 +170,21 @@ func (mr *MockReadOnlyStoreMockRecorder) AfterEventID(eventID any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterEventID", reflect.TypeOf((*MockReadOnlyStore)(nil).AfterEventID), eventID)
 }
 
+// CountRecords mocks base method.
+func (m *MockReadOnlyStore) CountRecords(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecords", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecords indicates an expected call of CountRecords.
+func (mr *MockReadOnlyStoreMockRecorder) CountRecords(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecords", reflect.TypeOf((*MockReadOnlyStore)(nil).CountRecords), ctx)
+}
+
 // Limit mocks base method.
 func (m *MockReadOnlyStore) Limit(limit int) persistence.ReadOnlyStore {
 	m.ctrl.T.Helper()
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// appendListener shares a single LISTEN connection between all the subscriptions of a store, instead of taking
+// a connection of the database for each of them. The connection is opened when the first subscription registers,
+// and closed when the last one leaves.
+type appendListener struct {
+	connectionString string
+	notifies         map[int]func()
+	nextID           int
+	session          *listenSession
+	mutex            sync.Mutex
+}
+
+// listenSession is the lifetime of a LISTEN connection. It is done when the connection fails,
+// or when it is closed because there are no subscriptions left.
+type listenSession struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	// err is the reason the session ended, set before done is closed.
+	err error
+}
+
+func newAppendListener(connectionString string) *appendListener {
+	return &appendListener{
+		connectionString: connectionString,
+		notifies:         map[int]func(){},
+	}
+}
+
+// register calls notify after every append, until the returned function is called.
+// It returns the session of the connection notifying it, which is started if there is none.
+func (l *appendListener) register(notify func()) (*listenSession, func()) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	id := l.nextID
+	l.nextID++
+	l.notifies[id] = notify
+
+	if l.session == nil {
+		l.session = l.listen()
+	}
+	session := l.session
+	return session, func() { l.unregister(id, session) }
+}
+
+func (l *appendListener) unregister(id int, session *listenSession) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.notifies, id)
+	if len(l.notifies) == 0 && l.session == session {
+		session.cancel()
+		l.session = nil
+	}
+}
+
+// close ends the session in progress, so the subscriptions stop listening to the store.
+func (l *appendListener) close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.session != nil {
+		l.session.cancel()
+		l.session = nil
+	}
+}
+
+// listen starts a session in the background. It must be called with the mutex held.
+func (l *appendListener) listen() *listenSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &listenSession{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(session.done)
+		session.err = l.run(ctx)
+
+		// The next subscription starts a new session, instead of waiting on this one.
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if l.session == session {
+			l.session = nil
+		}
+	}()
+	return session
+}
+
+func (l *appendListener) run(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.connectionString)
+	if err != nil {
+		return fmt.Errorf("unable to open the connection to listen to the appends: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	_, err = conn.Exec(ctx, "LISTEN "+appendedChannel)
+	if err != nil {
+		return fmt.Errorf("unable to listen to the appends: %w", err)
+	}
+
+	for {
+		_, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("error waiting for appends: %w", err)
+		}
+		l.notifyAll()
+	}
+}
+
+func (l *appendListener) notifyAll() {
+	l.mutex.Lock()
+	notifies := make([]func(), 0, len(l.notifies))
+	for _, notify := range l.notifies {
+		notifies = append(notifies, notify)
+	}
+	l.mutex.Unlock()
+
+	for _, notify := range notifies {
+		notify()
+	}
+}
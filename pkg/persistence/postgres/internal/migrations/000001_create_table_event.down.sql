@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS event_appended_trigger ON event;
+DROP FUNCTION IF EXISTS notify_event_appended();
+DROP TABLE IF EXISTS event;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS event
+(
+    row_id         BIGSERIAL PRIMARY KEY,
+    stream_name    TEXT        NOT NULL,
+    stream_version BIGINT      NOT NULL,
+    event_id       TEXT        NOT NULL,
+    event_name     TEXT        NOT NULL,
+    event_data     BYTEA       NOT NULL,
+    happened_on    TIMESTAMPTZ NOT NULL,
+    content_type   TEXT        NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS stream_name_version_unique_idx ON event (stream_name, stream_version);
+CREATE UNIQUE INDEX IF NOT EXISTS event_id_unique_idx ON event (event_id);
+
+CREATE OR REPLACE FUNCTION notify_event_appended() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('event_appended', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS event_appended_trigger ON event;
+CREATE TRIGGER event_appended_trigger
+    AFTER INSERT
+    ON event
+    FOR EACH STATEMENT
+EXECUTE FUNCTION notify_event_appended();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tenant_row_id_idx;
+DROP INDEX IF EXISTS tenant_stream_name_version_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS stream_name_version_unique_idx ON event (stream_name, stream_version);
+
+ALTER TABLE event DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,5 @@
+ALTER TABLE event ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS stream_name_version_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS tenant_stream_name_version_unique_idx ON event (tenant_id, stream_name, stream_version);
+CREATE INDEX IF NOT EXISTS tenant_row_id_idx ON event (tenant_id, row_id);
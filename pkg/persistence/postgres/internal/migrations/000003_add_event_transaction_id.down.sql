@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tenant_transaction_row_id_idx;
+DROP INDEX IF EXISTS transaction_row_id_idx;
+
+ALTER TABLE event DROP COLUMN IF EXISTS transaction_id;
@@ -0,0 +1,6 @@
+-- The appends are not serialized, so the row IDs may become visible out of order. The events are read in the order
+-- of the transactions that appended them instead, and only from the transactions older than any still in progress.
+ALTER TABLE event ADD COLUMN IF NOT EXISTS transaction_id XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS transaction_row_id_idx ON event (transaction_id, row_id);
+CREATE INDEX IF NOT EXISTS tenant_transaction_row_id_idx ON event (tenant_id, transaction_id, row_id);
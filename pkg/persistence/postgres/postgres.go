@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/postgres/internal/model"
+)
+
+const (
+	// https://www.postgresql.org/docs/current/errcodes-appendix.html
+	postgresErrorUniqueViolation = "23505"
+
+	// appendedChannel is notified by a trigger every time events are inserted, see the migrations.
+	appendedChannel = "event_appended"
+)
+
+// AppendOnlyStore is a persistence.AppendOnlyStore in PostgreSQL. The appends to different streams run concurrently,
+// so the log is read in the order of the transactions that appended the events, see inLogOrder.
+type AppendOnlyStore struct {
+	db       *gorm.DB
+	listener *appendListener
+}
+
+func (a *AppendOnlyStore) AfterEventID(eventID domain.EventID) persistence.ReadOnlyStore {
+	return &AppendOnlyStore{db: a.db.Where("(transaction_id, row_id) > (select transaction_id, row_id from event where event_id = ?)", eventID), listener: a.listener}
+}
+
+func (a *AppendOnlyStore) Limit(limit int) persistence.ReadOnlyStore {
+	return &AppendOnlyStore{db: a.db.Limit(limit), listener: a.listener}
+}
+
+func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.StoredStreamEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	eventsToInsert := make([]model.Event, 0, len(events))
+	for _, event := range persistence.WithTenantOf(ctx, events) {
+		eventsToInsert = append(eventsToInsert, model.Event{
+			TenantID:      string(event.TenantID),
+			StreamName:    event.ID.StreamName,
+			StreamVersion: int64(event.ID.StreamVersion),
+			EventName:     event.EventName,
+			EventID:       string(event.EventID),
+			EventData:     event.EventData,
+			HappenedOn:    event.HappenedOn,
+			ContentType:   event.ContentType,
+		})
+	}
+
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the appends to the same streams wait for each other, so an append conflicting with one in progress
+		// fails with ErrUnexpectedVersion once the other commits, while the appends to other streams go on.
+		// The locks are taken in order, so two appends to the same streams never deadlock.
+		for _, stream := range lockedStreams(eventsToInsert) {
+			err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", stream).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Omit("row_id").CreateInBatches(eventsToInsert, 1000).Error
+	})
+	if isErrorUniqueConstraintViolation(err) {
+		return persistence.ErrUnexpectedVersion
+	}
+	if err != nil {
+		return fmt.Errorf("unable to push stored stream event into the postgres append only store: %w", err)
+	}
+	return nil
+}
+
+// lockedStreams returns the streams of the events, sorted and without duplicates, as the keys of their advisory locks.
+func lockedStreams(events []model.Event) []string {
+	streams := make([]string, 0, len(events))
+	for _, event := range events {
+		streams = append(streams, event.TenantID+"/"+event.StreamName)
+	}
+	slices.Sort(streams)
+	return slices.Compact(streams)
+}
+
+func isErrorUniqueConstraintViolation(err error) bool {
+	var postgresError *pgconn.PgError
+	return errors.As(err, &postgresError) && postgresError.Code == postgresErrorUniqueViolation
+}
+
+func (a *AppendOnlyStore) ReadAllRecords(ctx context.Context) ([]persistence.StoredStreamEvent, error) {
+	return readRecordsWithQuery(ctx, inLogOrder(scopedToTenant(ctx, a.db.WithContext(ctx))))
+}
+
+// CountRecords counts the events in a subquery, so the limit of the store is applied before counting them.
+func (a *AppendOnlyStore) CountRecords(ctx context.Context) (int, error) {
+	var count int
+	filtered := inLogOrder(scopedToTenant(ctx, a.db.WithContext(ctx))).Model(&model.Event{}).Select("row_id")
+	err := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Raw("SELECT count(*) FROM (?) AS filtered", filtered).Scan(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("unable to count the records: %w", err)
+	}
+	return count, nil
+}
+
+// ReadRecords reads every committed event of the stream, even if an older transaction is still in progress,
+// so an aggregate is always loaded with the events appended right before.
+func (a *AppendOnlyStore) ReadRecords(ctx context.Context, streamName string) ([]persistence.StoredStreamEvent, error) {
+	return readRecordsWithQuery(ctx, scopedToTenant(ctx, a.db.WithContext(ctx)).Where("stream_name = ?", streamName).Order("row_id"))
+}
+
+// scopedToTenant restricts the query to the events of the tenant of the context, unless it is for all the tenants.
+func scopedToTenant(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tenant, scoped := persistence.TenantFilter(ctx); scoped {
+		return db.Where("tenant_id = ?", tenant)
+	}
+	return db
+}
+
+// inLogOrder sorts the query by the transactions that appended the events, and restricts it to the transactions
+// older than any still in progress. The concurrent appends commit in any order, so neither the row IDs nor the
+// transaction IDs become visible in order, but the events committed later always come after the ones returned,
+// and a reader that has read up to an event never misses one. The events of a transaction are only returned
+// once the older transactions have ended.
+func inLogOrder(db *gorm.DB) *gorm.DB {
+	return db.Where("transaction_id < pg_snapshot_xmin(pg_current_snapshot())").Order("transaction_id, row_id")
+}
+
+func readRecordsWithQuery(ctx context.Context, db *gorm.DB) ([]persistence.StoredStreamEvent, error) {
+	var dbEvents []model.Event
+	err := db.WithContext(ctx).Find(&dbEvents).Error
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve records from stream: %w", err)
+	}
+
+	events := make([]persistence.StoredStreamEvent, 0, len(dbEvents))
+	for _, event := range dbEvents {
+		events = append(events, modelEventToPersistence(event))
+	}
+	return events, nil
+}
+
+func modelEventToPersistence(dbEvent model.Event) persistence.StoredStreamEvent {
+	return persistence.StoredStreamEvent{
+		ID: persistence.StreamID{
+			StreamName:    dbEvent.StreamName,
+			StreamVersion: uint64(dbEvent.StreamVersion),
+		},
+		EventName:   dbEvent.EventName,
+		EventID:     domain.EventID(dbEvent.EventID),
+		EventData:   dbEvent.EventData,
+		HappenedOn:  dbEvent.HappenedOn.UTC(),
+		ContentType: dbEvent.ContentType,
+		TenantID:    domain.TenantID(dbEvent.TenantID),
+	}
+}
+
+// ListenAppends implements persistence.AppendListener with LISTEN/NOTIFY on a connection shared by every
+// subscription of the store, so they are notified of the appends of every process sharing the database.
+func (a *AppendOnlyStore) ListenAppends(ctx context.Context, notify func()) error {
+	session, unregister := a.listener.register(notify)
+	defer unregister()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-session.done:
+		return session.err
+	}
+}
+
+func New(connectionString string) (*AppendOnlyStore, error) {
+	db, err := gorm.Open(postgres.Open(connectionString), &gorm.Config{
+		Logger: logger.New(log.Default(), logger.Config{
+			Colorful:                  false,
+			IgnoreRecordNotFoundError: true,
+			LogLevel:                  logger.Error,
+		}),
+		SkipDefaultTransaction: true,
+		// The events are read by their columns, so the transaction_id the model lacks is never scanned.
+		QueryFields: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open postgres database connection: %w", err)
+	}
+
+	return &AppendOnlyStore{
+		db:       db,
+		listener: newAppendListener(connectionString),
+	}, nil
+}
+
+func (a *AppendOnlyStore) Close() error {
+	a.listener.close()
+
+	db, err := a.db.DB()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve database connection: %w", err)
+	}
+	return db.Close()
+}
@@ -0,0 +1,122 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPostgres(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Postgres Suite")
+}
+
+var (
+	// serverConnectionString points to the throwaway server started for the suite, see startThrowawayPostgres.
+	serverConnectionString string
+	createdDatabases       int
+)
+
+var _ = BeforeSuite(func() {
+	serverConnectionString = startThrowawayPostgres()
+})
+
+// startThrowawayPostgres initializes a database cluster in a temporary directory and starts a server on it,
+// listening only on a unix socket, so it does not collide with any other server. The server is stopped and
+// removed after the suite. The specs are skipped if the PostgreSQL binaries are not installed.
+// Postgres refuses to run as root, so in that case the server is run by an unprivileged user, see serverCredential.
+func startThrowawayPostgres() string {
+	initdb, err := postgresBinary("initdb")
+	if err != nil {
+		Skip("skipping the postgres specs, initdb is not installed: " + err.Error())
+	}
+	pgCtl, err := postgresBinary("pg_ctl")
+	if err != nil {
+		Skip("skipping the postgres specs, pg_ctl is not installed: " + err.Error())
+	}
+
+	dataDir, err := os.MkdirTemp("", "postgres-test-")
+	Expect(err).ToNot(HaveOccurred())
+	DeferCleanup(os.RemoveAll, dataDir)
+	credential := serverCredential(dataDir)
+
+	output, err := serverCommand(credential, initdb, "--pgdata", dataDir, "--username", "postgres", "--auth", "trust").CombinedOutput()
+	Expect(err).ToNot(HaveOccurred(), string(output))
+
+	serverOptions := fmt.Sprintf("-c listen_addresses='' -k %s", dataDir)
+	output, err = serverCommand(credential, pgCtl, "start", "--wait", "--pgdata", dataDir, "--log", filepath.Join(dataDir, "postgres.log"), "-o", serverOptions).CombinedOutput()
+	Expect(err).ToNot(HaveOccurred(), string(output))
+	DeferCleanup(func() {
+		_ = serverCommand(credential, pgCtl, "stop", "--pgdata", dataDir, "--mode", "immediate").Run()
+	})
+
+	return fmt.Sprintf("host=%s user=postgres sslmode=disable", dataDir)
+}
+
+// serverCredential returns the user to run the server as when the specs run as root, giving it the data directory,
+// or nil to run the server as the current user. It is the postgres user if it exists, or nobody otherwise.
+func serverCredential(dataDir string) *syscall.Credential {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+
+	serverUser, err := user.Lookup("postgres")
+	if err != nil {
+		serverUser, err = user.Lookup("nobody")
+	}
+	Expect(err).ToNot(HaveOccurred())
+	uid, err := strconv.ParseUint(serverUser.Uid, 10, 32)
+	Expect(err).ToNot(HaveOccurred())
+	gid, err := strconv.ParseUint(serverUser.Gid, 10, 32)
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(os.Chown(dataDir, int(uid), int(gid))).To(Succeed())
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+}
+
+func serverCommand(credential *syscall.Credential, name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if credential != nil {
+		// The working directory of the specs may not be readable by the server user, which pg_ctl fails on.
+		cmd.Dir = os.TempDir()
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	}
+	return cmd
+}
+
+// postgresBinary looks for the binary in the PATH, and in the directory the Debian packages install it.
+func postgresBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	paths, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql/*/bin", name))
+	if len(paths) == 0 {
+		return "", fmt.Errorf("%s not found", name)
+	}
+	return paths[len(paths)-1], nil
+}
+
+// createDatabase creates an empty database in the throwaway server and returns its connection string.
+func createDatabase(ctx context.Context) string {
+	db, err := sql.Open("pgx", serverConnectionString+" dbname=postgres")
+	Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+
+	createdDatabases++
+	name := fmt.Sprintf("test_%d", createdDatabases)
+	_, err = db.ExecContext(ctx, "CREATE DATABASE "+name)
+	Expect(err).ToNot(HaveOccurred())
+
+	return serverConnectionString + " dbname=" + name
+}
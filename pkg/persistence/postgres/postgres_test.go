@@ -0,0 +1,210 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/postgres"
+	"github.com/tembleking/myBankSourcing/test/conformance"
+)
+
+var _ = Describe("Postgres AppendOnlyStore conformance", func() {
+	conformance.AppendOnlyStore(func(ctx context.Context) persistence.AppendOnlyStore {
+		store := setupStore(createDatabase(ctx))
+		DeferCleanup(store.Close)
+		return store
+	})
+})
+
+var _ = Describe("Postgres AppendOnlyStore", func() {
+	var (
+		ctx              context.Context
+		connectionString string
+		store            *postgres.AppendOnlyStore
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(GinkgoWriter)
+		ctx = context.Background()
+		connectionString = createDatabase(ctx)
+		store = setupStore(connectionString)
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("should be able to append to multiple events to an event stream", func() {
+		err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data1"), ContentType: "some-content-type"})
+		Expect(err).To(BeNil())
+
+		err = store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event1", EventName: "eventName", EventData: []byte("data2"), ContentType: "some-content-type"})
+		Expect(err).To(BeNil())
+	})
+
+	When("there is a double append with the same expected version", func() {
+		It("should return an error", func() {
+			err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})
+			Expect(err).To(BeNil())
+
+			err = store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event1", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+	})
+
+	It("should be able to read from an event stream", func() {
+		err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})
+		Expect(err).To(BeNil())
+
+		data, err := store.ReadRecords(ctx, "aggregate-0")
+		Expect(err).To(BeNil())
+		Expect(data).To(Equal([]persistence.StoredStreamEvent{
+			{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"},
+		}))
+	})
+
+	When("filtering events after other eventID", func() {
+		BeforeEach(func() {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data0"), ContentType: "some-content-type-0"})).To(Succeed())
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "event1", EventName: "eventNameToIgnore", EventData: []byte("data1"), ContentType: "some-content-type-1"})).To(Succeed())
+			Expect(store.Append(ctx,
+				persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 0}, EventID: "event2", EventName: "eventName", EventData: []byte("data2-0"), ContentType: "some-content-type-0"},
+				persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 1}, EventID: "event3", EventName: "eventName", EventData: []byte("data2-1"), ContentType: "some-content-type-1"},
+			)).To(Succeed())
+		})
+
+		It("should return only the events after the eventID", func() {
+			records, err := store.AfterEventID("event1").ReadAllRecords(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(records).To(Equal([]persistence.StoredStreamEvent{
+				{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 0}, EventID: "event2", EventName: "eventName", EventData: []byte("data2-0"), ContentType: "some-content-type-0"},
+				{ID: persistence.StreamID{StreamName: "aggregate-2", StreamVersion: 1}, EventID: "event3", EventName: "eventName", EventData: []byte("data2-1"), ContentType: "some-content-type-1"},
+			}))
+		})
+
+		It("should return a limit number of events after the eventID", func() {
+			records, err := store.AfterEventID("event0").Limit(2).ReadAllRecords(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(records).To(HaveExactElements(
+				HaveField("EventID", BeEquivalentTo("event1")),
+				HaveField("EventID", BeEquivalentTo("event2")),
+			))
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(4))
+		})
+	})
+
+	It("notifies the subscriptions of the appends made by other processes", func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(store).WithSubscriptionPollInterval(time.Hour).Build()
+		subscription := eventStore.Subscribe(ctx, "")
+
+		otherProcessStore, err := postgres.New(connectionString)
+		Expect(err).ToNot(HaveOccurred())
+		defer otherProcessStore.Close()
+		acc, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(otherProcessStore).Build().AppendToStream(ctx, acc)).To(Succeed())
+
+		Eventually(subscription.Events()).WithTimeout(2 * time.Second).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AccountOpened{}))))
+	})
+})
+
+var _ = Describe("Postgres AppendOnlyStore with concurrent appends", func() {
+	var (
+		connectionString string
+		store            *postgres.AppendOnlyStore
+		db               *sql.DB
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		log.SetOutput(GinkgoWriter)
+		connectionString = createDatabase(ctx)
+		store = setupStore(connectionString)
+		DeferCleanup(store.Close)
+
+		var err error
+		db, err = sql.Open("pgx", connectionString)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(db.Close)
+	})
+
+	It("reads the events in the order of their transactions once the older transactions have committed", func(ctx context.Context) {
+		olderTransaction, err := db.BeginTx(ctx, nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer olderTransaction.Rollback()
+		_, err = olderTransaction.ExecContext(ctx, "SELECT pg_current_xact_id()")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "younger-event", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})).To(Succeed())
+		Expect(store.ReadRecords(ctx, "aggregate-1")).To(HaveLen(1))
+		Expect(store.ReadAllRecords(ctx)).To(BeEmpty())
+
+		_, err = olderTransaction.ExecContext(ctx, "INSERT INTO event (stream_name, stream_version, event_id, event_name, event_data, happened_on, content_type) VALUES ('aggregate-0', 0, 'older-event', 'eventName', 'data', now(), 'some-content-type')")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(olderTransaction.Commit()).To(Succeed())
+
+		Expect(store.ReadAllRecords(ctx)).To(HaveExactElements(
+			HaveField("EventID", BeEquivalentTo("older-event")),
+			HaveField("EventID", BeEquivalentTo("younger-event")),
+		))
+		Expect(store.AfterEventID("older-event").ReadAllRecords(ctx)).To(HaveExactElements(HaveField("EventID", BeEquivalentTo("younger-event"))))
+	})
+
+	It("does not make the appends to other streams wait for an append in progress", func(ctx context.Context) {
+		appendInProgress, err := db.BeginTx(ctx, nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer appendInProgress.Rollback()
+		_, err = appendInProgress.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('/aggregate-0', 0))")
+		Expect(err).ToNot(HaveOccurred())
+
+		appendCtx, cancelAppend := context.WithTimeout(ctx, 2*time.Second)
+		defer cancelAppend()
+		Expect(store.Append(appendCtx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})).To(Succeed())
+
+		lockedCtx, cancelLocked := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancelLocked()
+		Expect(store.Append(lockedCtx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event1", EventName: "eventName", EventData: []byte("data"), ContentType: "some-content-type"})).ToNot(Succeed())
+	})
+
+	It("listens to the appends of every subscription with a single connection", func(ctx context.Context) {
+		eventStore := persistence.NewEventStoreBuilder(store).WithSubscriptionPollInterval(time.Hour).Build()
+		subscriptions := []*persistence.Subscription{eventStore.Subscribe(ctx, ""), eventStore.Subscribe(ctx, "")}
+
+		listeningConnections := func() (count int) {
+			err := db.QueryRowContext(ctx, "SELECT count(*) FROM pg_stat_activity WHERE datname = current_database() AND query = 'LISTEN event_appended'").Scan(&count)
+			Expect(err).ToNot(HaveOccurred())
+			return count
+		}
+		Eventually(listeningConnections).Should(Equal(1))
+		Consistently(listeningConnections).Should(Equal(1))
+
+		otherProcessStore, err := postgres.New(connectionString)
+		Expect(err).ToNot(HaveOccurred())
+		defer otherProcessStore.Close()
+		acc, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(otherProcessStore).Build().AppendToStream(ctx, acc)).To(Succeed())
+
+		for _, subscription := range subscriptions {
+			Eventually(subscription.Events()).WithTimeout(2 * time.Second).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AccountOpened{}))))
+		}
+	})
+})
+
+func setupStore(connectionString string) *postgres.AppendOnlyStore {
+	store, err := postgres.New(connectionString)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	err = store.MigrateDB()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return store
+}
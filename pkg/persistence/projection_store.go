@@ -0,0 +1,46 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// ProjectionStore persists the progress of the projections, so they can resume from where they left off after a restart.
+type ProjectionStore interface {
+	// LoadCheckpoint returns the last checkpoint saved for the projection with the given name.
+	// It returns ErrProjectionCheckpointNotFound if the projection has never been saved.
+	LoadCheckpoint(ctx context.Context, projectionName string) (ProjectionCheckpoint, error)
+
+	// SaveCheckpoint saves the last event processed by the projection.
+	SaveCheckpoint(ctx context.Context, checkpoint ProjectionCheckpoint) error
+
+	// LoadSnapshot returns the last snapshot saved for the projection with the given name.
+	// It returns ErrProjectionSnapshotNotFound if no snapshot of the projection has been saved.
+	LoadSnapshot(ctx context.Context, projectionName string) (ProjectionSnapshot, error)
+
+	// SaveSnapshot replaces the snapshot of the projection.
+	SaveSnapshot(ctx context.Context, snapshot ProjectionSnapshot) error
+}
+
+// ProjectionCheckpoint is the position of a projection that persists its read model by itself,
+// which has processed all the events up to LastEventID.
+// Version identifies the handler logic that processed the events, so a checkpoint saved
+// by a different version must be discarded and the projection rebuilt from scratch.
+type ProjectionCheckpoint struct {
+	UpdatedOn   time.Time
+	Name        string
+	LastEventID domain.EventID
+	Version     uint64
+}
+
+// ProjectionSnapshot is the state of a projection kept in memory after processing all the events up to LastEventID.
+// Like the checkpoints, a snapshot taken by a different Version must be discarded.
+type ProjectionSnapshot struct {
+	TakenOn     time.Time
+	Name        string
+	LastEventID domain.EventID
+	State       []byte
+	Version     uint64
+}
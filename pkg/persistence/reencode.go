@@ -0,0 +1,213 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+const defaultReencodeBatchSize = 1000
+
+// AggregateFactory returns an empty aggregate for the stream starting with the given event.
+type AggregateFactory func(firstEvent domain.Event) (domain.Aggregate, error)
+
+// Reencoder copies every event of a store to an empty one, re-serialized with another serializer,
+// keeping their event IDs, stream versions and order.
+// The copy is verified by comparing the aggregates rehydrated from both stores.
+type Reencoder struct {
+	source        ReadOnlyStore
+	target        AppendOnlyStore
+	serializer    DomainEventSerializer
+	deserializers *DeserializerRegistry
+	newAggregate  AggregateFactory
+	batchSize     int
+}
+
+type ReencodeResult struct {
+	Events             int
+	VerifiedAggregates int
+}
+
+func NewReencoder(source ReadOnlyStore, target AppendOnlyStore, serializer DomainEventSerializer, newAggregate AggregateFactory) *Reencoder {
+	return &Reencoder{
+		source:        source,
+		target:        target,
+		serializer:    serializer,
+		deserializers: NewDeserializerRegistry(),
+		newAggregate:  newAggregate,
+		batchSize:     defaultReencodeBatchSize,
+	}
+}
+
+// WithDeserializerRegistry sets the registry used to deserialize the events of both stores.
+func (r *Reencoder) WithDeserializerRegistry(deserializers *DeserializerRegistry) *Reencoder {
+	r.deserializers = deserializers
+	return r
+}
+
+// WithBatchSize sets how many events are read from the source store and appended to the target one at once.
+func (r *Reencoder) WithBatchSize(batchSize int) *Reencoder {
+	r.batchSize = batchSize
+	return r
+}
+
+// Run copies the events to the target store and verifies the copy.
+// It returns ErrTargetStoreNotEmpty if the target store already has events,
+// and ErrReencodedStoreMismatch if the copy does not match the source store.
+func (r *Reencoder) Run(ctx context.Context) (ReencodeResult, error) {
+	ctx = domain.WithAllTenants(ctx)
+	existing, err := r.target.Limit(1).ReadAllRecords(ctx)
+	if err != nil {
+		return ReencodeResult{}, fmt.Errorf("error reading the target store: %w", err)
+	}
+	if len(existing) > 0 {
+		return ReencodeResult{}, ErrTargetStoreNotEmpty
+	}
+
+	copied, err := r.copyEvents(ctx)
+	if err != nil {
+		return ReencodeResult{}, err
+	}
+
+	streams, err := r.verifyRecords(ctx)
+	if err != nil {
+		return ReencodeResult{}, err
+	}
+
+	for _, stream := range streams {
+		if err := r.verifyAggregate(domain.WithTenant(ctx, stream.tenant), stream.name); err != nil {
+			return ReencodeResult{}, err
+		}
+	}
+
+	return ReencodeResult{Events: copied, VerifiedAggregates: len(streams)}, nil
+}
+
+func (r *Reencoder) copyEvents(ctx context.Context) (int, error) {
+	copied := 0
+	var lastEventID *domain.EventID
+	for {
+		records, err := readBatch(ctx, r.source, lastEventID, r.batchSize)
+		if err != nil {
+			return copied, fmt.Errorf("error reading the source store: %w", err)
+		}
+		if len(records) == 0 {
+			return copied, nil
+		}
+
+		reencoded := make([]StoredStreamEvent, 0, len(records))
+		for _, record := range records {
+			event, err := r.deserializers.Deserialize(record.ContentType, record.EventName, record.EventData)
+			if err != nil {
+				return copied, fmt.Errorf("error deserializing event '%s': %w", record.EventID, err)
+			}
+
+			data, err := r.serializer.SerializeDomainEvent(event)
+			if err != nil {
+				return copied, fmt.Errorf("error serializing event '%s': %w", record.EventID, err)
+			}
+
+			record.EventData = data
+			record.ContentType = r.serializer.ContentType()
+			reencoded = append(reencoded, record)
+		}
+
+		if err := r.target.Append(ctx, reencoded...); err != nil {
+			return copied, fmt.Errorf("error appending to the target store: %w", err)
+		}
+		copied += len(reencoded)
+		lastEventID = &records[len(records)-1].EventID
+	}
+}
+
+// tenantStream identifies a stream in the whole store, since every tenant has its own streams.
+type tenantStream struct {
+	tenant domain.TenantID
+	name   string
+}
+
+// verifyRecords checks that both stores have the same events in the same order,
+// returning the streams in the order they were started.
+func (r *Reencoder) verifyRecords(ctx context.Context) ([]tenantStream, error) {
+	var streams []tenantStream
+	seenStreams := map[tenantStream]struct{}{}
+
+	var lastEventID *domain.EventID
+	for {
+		sourceRecords, err := readBatch(ctx, r.source, lastEventID, r.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the source store: %w", err)
+		}
+		targetRecords, err := readBatch(ctx, r.target, lastEventID, r.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the target store: %w", err)
+		}
+		if len(sourceRecords) != len(targetRecords) {
+			return nil, fmt.Errorf("%w: the stores have a different number of events", ErrReencodedStoreMismatch)
+		}
+		if len(sourceRecords) == 0 {
+			return streams, nil
+		}
+
+		for i, source := range sourceRecords {
+			target := targetRecords[i]
+			if source.EventID != target.EventID || source.ID != target.ID || source.TenantID != target.TenantID || source.EventName != target.EventName || !source.HappenedOn.Equal(target.HappenedOn) {
+				return nil, fmt.Errorf("%w: event '%s' in stream '%s' version %d differs from the event '%s' in the same position", ErrReencodedStoreMismatch, source.EventID, source.ID.StreamName, source.ID.StreamVersion, target.EventID)
+			}
+
+			stream := tenantStream{tenant: source.TenantID, name: source.ID.StreamName}
+			if _, ok := seenStreams[stream]; !ok {
+				seenStreams[stream] = struct{}{}
+				streams = append(streams, stream)
+			}
+		}
+		lastEventID = &sourceRecords[len(sourceRecords)-1].EventID
+	}
+}
+
+func (r *Reencoder) verifyAggregate(ctx context.Context, streamName string) error {
+	sourceAggregate, err := r.rehydrate(ctx, r.source, streamName)
+	if err != nil {
+		return fmt.Errorf("error rehydrating stream '%s' from the source store: %w", streamName, err)
+	}
+	targetAggregate, err := r.rehydrate(ctx, r.target, streamName)
+	if err != nil {
+		return fmt.Errorf("error rehydrating stream '%s' from the target store: %w", streamName, err)
+	}
+
+	if sourceAggregate.Version() != targetAggregate.Version() || !sourceAggregate.SameEntityAs(targetAggregate) {
+		return fmt.Errorf("%w: the aggregate of stream '%s' differs", ErrReencodedStoreMismatch, streamName)
+	}
+	return nil
+}
+
+func (r *Reencoder) rehydrate(ctx context.Context, store ReadOnlyStore, streamName string) (domain.Aggregate, error) {
+	records, err := store.ReadRecords(ctx, streamName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading records: %w", err)
+	}
+
+	events := make([]domain.Event, 0, len(records))
+	for _, record := range records {
+		event, err := r.deserializers.Deserialize(record.ContentType, record.EventName, record.EventData)
+		if err != nil {
+			return nil, fmt.Errorf("error deserializing event '%s': %w", record.EventID, err)
+		}
+		events = append(events, event)
+	}
+
+	aggregate, err := r.newAggregate(events[0])
+	if err != nil {
+		return nil, err
+	}
+	aggregate.LoadFromHistory(events...)
+	return aggregate, nil
+}
+
+func readBatch(ctx context.Context, store ReadOnlyStore, afterEventID *domain.EventID, batchSize int) ([]StoredStreamEvent, error) {
+	if afterEventID != nil {
+		store = store.AfterEventID(*afterEventID)
+	}
+	return store.Limit(batchSize).ReadAllRecords(ctx)
+}
@@ -0,0 +1,106 @@
+package persistence_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+var _ = Describe("Reencoder", func() {
+	var (
+		source *inmemory.AppendOnlyStore
+		target *inmemory.AppendOnlyStore
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		source = inmemory.NewAppendOnlyStore()
+		target = inmemory.NewAppendOnlyStore()
+
+		gobEventStore := persistence.NewEventStoreBuilder(source).
+			WithSerializer(&serializer.GoBinarySerializer{}).
+			Build()
+		for i := range 5 {
+			acc, err := account.OpenAccount(fmt.Sprintf("account-%d", i))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(acc.DepositMoney(10 * (i + 1))).To(Succeed())
+			Expect(acc.WithdrawMoney(i)).To(Succeed())
+			Expect(gobEventStore.AppendToStream(ctx, acc)).To(Succeed())
+		}
+	})
+
+	It("copies the events re-serialized keeping their IDs, versions and order", func(ctx context.Context) {
+		result, err := persistence.NewReencoder(source, target, &serializer.Msgpack{}, newAccount).WithBatchSize(4).Run(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(persistence.ReencodeResult{Events: 15, VerifiedAggregates: 5}))
+
+		sourceRecords, err := source.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		targetRecords, err := target.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targetRecords).To(HaveLen(len(sourceRecords)))
+		for i, targetRecord := range targetRecords {
+			Expect(targetRecord.EventID).To(Equal(sourceRecords[i].EventID))
+			Expect(targetRecord.ID).To(Equal(sourceRecords[i].ID))
+			Expect(targetRecord.ContentType).To(Equal("application/x-msgpack"))
+		}
+
+		msgpackAccount, err := account.NewRepository(persistence.NewEventStoreBuilder(target).Build()).GetByID(ctx, "account-3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msgpackAccount.Balance()).To(Equal(37))
+	})
+
+	It("keeps the streams of every tenant apart", func(ctx context.Context) {
+		tenantCtx := domain.WithTenant(ctx, "some-tenant")
+		acc, err := account.OpenAccount("account-3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(source).Build().AppendToStream(tenantCtx, acc)).To(Succeed())
+
+		result, err := persistence.NewReencoder(source, target, &serializer.Msgpack{}, newAccount).Run(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(persistence.ReencodeResult{Events: 16, VerifiedAggregates: 6}))
+
+		tenantAccount, err := account.NewRepository(persistence.NewEventStoreBuilder(target).Build()).GetByID(tenantCtx, "account-3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tenantAccount.Balance()).To(BeZero())
+	})
+
+	It("fails if the target store is not empty", func(ctx context.Context) {
+		Expect(target.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "other"}, EventID: "event0"})).To(Succeed())
+
+		_, err := persistence.NewReencoder(source, target, &serializer.Msgpack{}, newAccount).Run(ctx)
+		Expect(err).To(MatchError(persistence.ErrTargetStoreNotEmpty))
+	})
+
+	It("fails if the rehydrated aggregates differ", func(ctx context.Context) {
+		_, err := persistence.NewReencoder(source, target, &lossySerializer{}, newAccount).Run(ctx)
+
+		Expect(err).To(MatchError(persistence.ErrReencodedStoreMismatch))
+		Expect(err).To(MatchError(ContainSubstring("the aggregate of stream 'account-1' differs")))
+	})
+})
+
+func newAccount(domain.Event) (domain.Aggregate, error) {
+	return account.NewAccount(), nil
+}
+
+// lossySerializer loses the withdrawals from the balance.
+type lossySerializer struct {
+	serializer.JSON
+}
+
+func (l *lossySerializer) SerializeDomainEvent(event domain.Event) ([]byte, error) {
+	if withdrawal, ok := event.(*account.AmountWithdrawn); ok {
+		lossyWithdrawal := *withdrawal
+		lossyWithdrawal.Balance += withdrawal.Quantity
+		event = &lossyWithdrawal
+	}
+	return l.JSON.SerializeDomainEvent(event)
+}
@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+const restoreBatchSize = 1000
+
+// RestorePoint is the last event of the log to restore. The zero value restores the whole log.
+type RestorePoint struct {
+	// Until restores the events up to the first one that happened after it.
+	Until time.Time
+	// Position restores this number of events from the beginning of the log.
+	Position int
+}
+
+func (p RestorePoint) reached(restored int, record StoredStreamEvent) bool {
+	if p.Position > 0 && restored >= p.Position {
+		return true
+	}
+	return !p.Until.IsZero() && record.HappenedOn.After(p.Until)
+}
+
+// Restore copies to the empty target store the events of the source store up to the restore point,
+// as they are stored and in the same order, returning how many were copied. The events of every tenant are copied.
+// It returns ErrTargetStoreNotEmpty if the target store already has events.
+func Restore(ctx context.Context, source ReadOnlyStore, target AppendOnlyStore, point RestorePoint) (int, error) {
+	ctx = domain.WithAllTenants(ctx)
+	existing, err := target.Limit(1).ReadAllRecords(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error reading the target store: %w", err)
+	}
+	if len(existing) > 0 {
+		return 0, ErrTargetStoreNotEmpty
+	}
+
+	restored := 0
+	var lastEventID *domain.EventID
+	for {
+		records, err := readBatch(ctx, source, lastEventID, restoreBatchSize)
+		if err != nil {
+			return restored, fmt.Errorf("error reading the source store: %w", err)
+		}
+		if len(records) == 0 {
+			return restored, nil
+		}
+
+		batch := make([]StoredStreamEvent, 0, len(records))
+		for _, record := range records {
+			if point.reached(restored+len(batch), record) {
+				break
+			}
+			batch = append(batch, record)
+		}
+
+		if err := target.Append(ctx, batch...); err != nil {
+			return restored, fmt.Errorf("error appending to the target store: %w", err)
+		}
+		restored += len(batch)
+		if len(batch) < len(records) {
+			return restored, nil
+		}
+		lastEventID = &records[len(records)-1].EventID
+	}
+}
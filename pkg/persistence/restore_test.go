@@ -0,0 +1,76 @@
+package persistence_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+)
+
+var _ = Describe("Restore", func() {
+	var (
+		source    *inmemory.AppendOnlyStore
+		target    *inmemory.AppendOnlyStore
+		startTime time.Time
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		source = inmemory.NewAppendOnlyStore()
+		target = inmemory.NewAppendOnlyStore()
+		startTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		for i := range 10 {
+			Expect(source.Append(ctx, persistence.StoredStreamEvent{
+				ID:          persistence.StreamID{StreamName: fmt.Sprintf("aggregate-%d", i%3), StreamVersion: uint64(i / 3)},
+				EventID:     domain.EventID(fmt.Sprintf("event%d", i)),
+				EventName:   "eventName",
+				EventData:   []byte(fmt.Sprintf("data%d", i)),
+				ContentType: "application/octet-stream",
+				HappenedOn:  startTime.Add(time.Duration(i) * time.Minute),
+			})).To(Succeed())
+		}
+	})
+
+	eventIDs := func(ctx context.Context, store persistence.ReadOnlyStore) []domain.EventID {
+		records, err := store.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		ids := make([]domain.EventID, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.EventID)
+		}
+		return ids
+	}
+
+	It("restores the whole log as it is stored", func(ctx context.Context) {
+		Expect(persistence.Restore(ctx, source, target, persistence.RestorePoint{})).To(Equal(10))
+
+		sourceRecords, err := source.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.ReadAllRecords(ctx)).To(Equal(sourceRecords))
+	})
+
+	It("restores up to a global position", func(ctx context.Context) {
+		Expect(persistence.Restore(ctx, source, target, persistence.RestorePoint{Position: 4})).To(Equal(4))
+
+		Expect(eventIDs(ctx, target)).To(HaveExactElements(domain.EventID("event0"), domain.EventID("event1"), domain.EventID("event2"), domain.EventID("event3")))
+	})
+
+	It("restores up to a time, inclusive", func(ctx context.Context) {
+		Expect(persistence.Restore(ctx, source, target, persistence.RestorePoint{Until: startTime.Add(2 * time.Minute)})).To(Equal(3))
+
+		Expect(eventIDs(ctx, target)).To(HaveExactElements(domain.EventID("event0"), domain.EventID("event1"), domain.EventID("event2")))
+	})
+
+	It("fails if the target store is not empty", func(ctx context.Context) {
+		Expect(persistence.Restore(ctx, source, target, persistence.RestorePoint{Position: 1})).To(Equal(1))
+
+		_, err := persistence.Restore(ctx, source, target, persistence.RestorePoint{})
+		Expect(err).To(MatchError(persistence.ErrTargetStoreNotEmpty))
+	})
+})
@@ -0,0 +1,115 @@
+package serializer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// EventRegistry holds the types of the events the serializers can deserialize, by event name.
+// Every store can be given its own registry, so stores with different events can run in the same process.
+type EventRegistry struct {
+	types   map[string]reflect.Type
+	aliases map[string]string
+	mutex   sync.RWMutex
+}
+
+// RegisteredEvent describes an event of an EventRegistry.
+type RegisteredEvent struct {
+	Name    string
+	Type    reflect.Type
+	Aliases []string
+}
+
+var defaultEventRegistry = NewEventRegistry()
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		types:   map[string]reflect.Type{},
+		aliases: map[string]string{},
+	}
+}
+
+// DefaultEventRegistry returns the registry filled by RegisterSerializableEvent,
+// which is used by the serializers created without a registry.
+func DefaultEventRegistry() *EventRegistry {
+	return defaultEventRegistry
+}
+
+// Register adds the events to the registry by their name.
+// It panics if another type is already registered with the name of an event.
+func (r *EventRegistry) Register(events ...domain.Event) *EventRegistry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, event := range events {
+		eventType := reflect.TypeOf(event)
+		for eventType.Kind() == reflect.Pointer {
+			eventType = eventType.Elem()
+		}
+
+		name := event.EventName()
+		if registered, ok := r.types[name]; ok && registered != eventType {
+			panic(fmt.Sprintf("event %s is already registered with type %s", name, registered))
+		}
+		if _, ok := r.aliases[name]; ok {
+			panic(fmt.Sprintf("event %s is already registered as an alias", name))
+		}
+		r.types[name] = eventType
+	}
+	return r
+}
+
+// RegisterAlias deserializes the events stored with the alias name as the registered event with the given name,
+// so the events can be renamed without rewriting the ones already stored.
+// It panics if the event is not registered or if the alias is the name of a registered event.
+func (r *EventRegistry) RegisterAlias(alias, eventName string) *EventRegistry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.types[eventName]; !ok {
+		panic(fmt.Sprintf("cannot alias %s to the unregistered event %s", alias, eventName))
+	}
+	if _, ok := r.types[alias]; ok {
+		panic(fmt.Sprintf("cannot use the registered event %s as an alias", alias))
+	}
+	r.aliases[alias] = eventName
+	return r
+}
+
+// NewEvent returns a new empty event of the type registered with the name or alias.
+func (r *EventRegistry) NewEvent(eventName string) (domain.Event, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if name, ok := r.aliases[eventName]; ok {
+		eventName = name
+	}
+	eventType, ok := r.types[eventName]
+	if !ok {
+		return nil, fmt.Errorf("type not registered: %s", eventName)
+	}
+	return reflect.New(eventType).Interface().(domain.Event), nil
+}
+
+// Events returns the registered events sorted by name.
+func (r *EventRegistry) Events() []RegisteredEvent {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	aliases := map[string][]string{}
+	for alias, name := range r.aliases {
+		aliases[name] = append(aliases[name], alias)
+	}
+
+	events := make([]RegisteredEvent, 0, len(r.types))
+	for name, eventType := range r.types {
+		sort.Strings(aliases[name])
+		events = append(events, RegisteredEvent{Name: name, Type: eventType, Aliases: aliases[name]})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events
+}
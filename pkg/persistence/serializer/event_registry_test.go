@@ -0,0 +1,58 @@
+package serializer_test
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+)
+
+var _ = Describe("EventRegistry", func() {
+	var registry *serializer.EventRegistry
+
+	BeforeEach(func() {
+		registry = serializer.NewEventRegistry().Register(&account.AmountDeposited{}, &account.AccountOpened{})
+	})
+
+	It("deserializes only the events of the registry", func() {
+		data, err := serializer.NewJSON(registry).SerializeDomainEvent(anEvent())
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(serializer.NewJSON(registry).DeserializeDomainEvent("AmountDeposited", data)).To(Equal(anEvent()))
+		_, err = serializer.NewJSON(serializer.NewEventRegistry()).DeserializeDomainEvent("AmountDeposited", data)
+		Expect(err).To(MatchError(ContainSubstring("type not registered: AmountDeposited")))
+	})
+
+	It("deserializes the events stored with an alias as the registered event", func() {
+		registry.RegisterAlias("AmountAdded", "AmountDeposited")
+		data, err := serializer.NewMsgpack(registry).SerializeDomainEvent(anEvent())
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(serializer.NewMsgpack(registry).DeserializeDomainEvent("AmountAdded", data)).To(Equal(anEvent()))
+
+		data, err = serializer.NewProtobuf(registry).SerializeDomainEvent(anEvent())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(serializer.NewProtobuf(registry).DeserializeDomainEvent("AmountAdded", data)).To(Equal(anEvent()))
+	})
+
+	It("lists the registered events sorted by name with their aliases", func() {
+		registry.RegisterAlias("MoneyDeposited", "AmountDeposited").RegisterAlias("AmountAdded", "AmountDeposited")
+
+		Expect(registry.Events()).To(Equal([]serializer.RegisteredEvent{
+			{Name: "AccountOpened", Type: reflect.TypeOf(account.AccountOpened{})},
+			{Name: "AmountDeposited", Type: reflect.TypeOf(account.AmountDeposited{}), Aliases: []string{"AmountAdded", "MoneyDeposited"}},
+		}))
+	})
+
+	It("accepts registering the same event again", func() {
+		Expect(func() { registry.Register(&account.AmountDeposited{}) }).ToNot(Panic())
+	})
+
+	It("panics when an alias is not of a registered event or is a registered event", func() {
+		Expect(func() { registry.RegisterAlias("AmountAdded", "UnknownEvent") }).To(Panic())
+		Expect(func() { registry.RegisterAlias("AccountOpened", "AmountDeposited") }).To(Panic())
+	})
+})
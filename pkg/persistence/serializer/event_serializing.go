@@ -6,7 +6,11 @@ import (
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
+// RegisterSerializableEvent registers the event in the DefaultEventRegistry and in the registry of gob,
+// so the serializers created without a registry and the events stored by previous versions of the
+// GoBinarySerializer can be deserialized.
+// Deprecated: register the events in an EventRegistry given to the serializers instead.
 func RegisterSerializableEvent(event domain.Event) {
 	gob.RegisterName(event.EventName(), event)
-	structMapSerializer.register(event)
+	defaultEventRegistry.Register(event)
 }
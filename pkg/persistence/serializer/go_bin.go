@@ -8,15 +8,26 @@ import (
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
-type GoBinarySerializer struct{}
+// GoBinarySerializer serializes the events with gob.
+// The events stored before it was given an EventRegistry were encoded as a domain.Event, which
+// can only be decoded with the events registered in gob by RegisterSerializableEvent.
+type GoBinarySerializer struct {
+	registry *EventRegistry
+}
 
 func init() {
 	gob.Register(map[string]string{})
 }
 
-func (g *GoBinarySerializer) SerializeDomainEvent(events domain.Event) ([]byte, error) {
+// NewGoBinarySerializer returns a serializer of the events of the registry.
+// The zero value uses the DefaultEventRegistry.
+func NewGoBinarySerializer(registry *EventRegistry) *GoBinarySerializer {
+	return &GoBinarySerializer{registry: registry}
+}
+
+func (g *GoBinarySerializer) SerializeDomainEvent(event domain.Event) ([]byte, error) {
 	var buf bytes.Buffer
-	err := gob.NewEncoder(&buf).Encode(&events)
+	err := gob.NewEncoder(&buf).Encode(event)
 	if err != nil {
 		return nil, fmt.Errorf("error serializing events: %w", err)
 	}
@@ -27,11 +38,20 @@ func (g *GoBinarySerializer) ContentType() string {
 	return "application/x-gob"
 }
 
-func (g *GoBinarySerializer) DeserializeDomainEvent(_ string, data []byte) (domain.Event, error) {
-	var event domain.Event
-	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&event)
+func (g *GoBinarySerializer) DeserializeDomainEvent(eventName string, data []byte) (domain.Event, error) {
+	event, err := registryOrDefault(g.registry).NewEvent(eventName)
 	if err != nil {
 		return nil, fmt.Errorf("error deserializing event: %w", err)
 	}
-	return event, nil
+
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(event)
+	if err == nil {
+		return event, nil
+	}
+
+	var legacyEvent domain.Event
+	if legacyErr := gob.NewDecoder(bytes.NewReader(data)).Decode(&legacyEvent); legacyErr == nil {
+		return legacyEvent, nil
+	}
+	return nil, fmt.Errorf("error deserializing event: %w", err)
 }
@@ -1,6 +1,8 @@
 package serializer_test
 
 import (
+	"bytes"
+	"encoding/gob"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -23,7 +25,7 @@ var _ = Describe("GoBinary", func() {
 		Expect(serialize).ToNot(BeEmpty())
 		Expect(serialize).To(BeAssignableToTypeOf([]byte{}))
 
-		deserialize, err := ser.DeserializeDomainEvent("AmountAdded", serialize)
+		deserialize, err := ser.DeserializeDomainEvent("AmountDeposited", serialize)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(deserialize).To(Equal(anEvent()))
@@ -39,6 +41,14 @@ var _ = Describe("GoBinary", func() {
 		Expect(first).To(Equal(second))
 	})
 
+	It("deserializes the events encoded as a domain event by previous versions", func() {
+		var buf bytes.Buffer
+		event := anEvent()
+		Expect(gob.NewEncoder(&buf).Encode(&event)).To(Succeed())
+
+		Expect(ser.DeserializeDomainEvent("AmountDeposited", buf.Bytes())).To(Equal(anEvent()))
+	})
+
 	It("returns the content type", func() {
 		Expect(ser.ContentType()).To(Equal("application/x-gob"))
 	})
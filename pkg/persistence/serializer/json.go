@@ -7,10 +7,18 @@ import (
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
-type JSON struct{}
+type JSON struct {
+	registry *EventRegistry
+}
+
+// NewJSON returns a serializer of the events of the registry.
+// The zero value uses the DefaultEventRegistry.
+func NewJSON(registry *EventRegistry) *JSON {
+	return &JSON{registry: registry}
+}
 
 func (m *JSON) SerializeDomainEvent(event domain.Event) ([]byte, error) {
-	eventData, err := structMapSerializer.serializeToMap(event)
+	eventData, err := serializeToMap(event)
 	if err != nil {
 		return nil, fmt.Errorf("error serializing event to map: %w", err)
 	}
@@ -34,7 +42,7 @@ func (m *JSON) DeserializeDomainEvent(eventName string, data []byte) (domain.Eve
 		return nil, fmt.Errorf("error deserializing event data map: %w", err)
 	}
 
-	event, err := structMapSerializer.deserializeFromMap(eventName, eventDataAsMap)
+	event, err := deserializeFromMap(registryOrDefault(m.registry), eventName, eventDataAsMap)
 	if err != nil {
 		return nil, fmt.Errorf("error deserializing event from map: %w", err)
 	}
@@ -8,10 +8,18 @@ import (
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
-type Msgpack struct{}
+type Msgpack struct {
+	registry *EventRegistry
+}
+
+// NewMsgpack returns a serializer of the events of the registry.
+// The zero value uses the DefaultEventRegistry.
+func NewMsgpack(registry *EventRegistry) *Msgpack {
+	return &Msgpack{registry: registry}
+}
 
 func (m *Msgpack) SerializeDomainEvent(event domain.Event) ([]byte, error) {
-	eventData, err := structMapSerializer.serializeToMap(event)
+	eventData, err := serializeToMap(event)
 	if err != nil {
 		return nil, fmt.Errorf("error serializing event to map: %w", err)
 	}
@@ -35,7 +43,7 @@ func (m *Msgpack) DeserializeDomainEvent(eventName string, data []byte) (domain.
 		return nil, fmt.Errorf("error deserializing event data map: %w", err)
 	}
 
-	event, err := structMapSerializer.deserializeFromMap(eventName, eventDataAsMap)
+	event, err := deserializeFromMap(registryOrDefault(m.registry), eventName, eventDataAsMap)
 	if err != nil {
 		return nil, fmt.Errorf("error deserializing event from map: %w", err)
 	}
@@ -0,0 +1,177 @@
+package serializer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer/eventpb"
+)
+
+// protobufEventsPackage is the package of the messages in eventpb, which have the same names as the events.
+var protobufEventsPackage = eventpb.File_events_proto.Package()
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Protobuf serializes the events with the message of the same name defined in eventpb/events.proto.
+// The fields of the event are matched by name with the fields of the message, ignoring the case and underscores,
+// so serializing an event fails if it has a field the message does not define.
+type Protobuf struct {
+	registry *EventRegistry
+}
+
+// NewProtobuf returns a serializer of the events of the registry.
+// The zero value uses the DefaultEventRegistry.
+func NewProtobuf(registry *EventRegistry) *Protobuf {
+	return &Protobuf{registry: registry}
+}
+
+func (p *Protobuf) SerializeDomainEvent(event domain.Event) ([]byte, error) {
+	messageType, err := protobufMessageType(event.EventName())
+	if err != nil {
+		return nil, err
+	}
+
+	eventValue := reflect.ValueOf(event)
+	for eventValue.Kind() == reflect.Pointer {
+		eventValue = eventValue.Elem()
+	}
+
+	message := messageType.New()
+	fields := message.Descriptor().Fields()
+	for i := range eventValue.NumField() {
+		structField := eventValue.Type().Field(i)
+		field := protobufFieldByName(fields, structField.Name)
+		if field == nil {
+			return nil, fmt.Errorf("error serializing event %s: the message has no field for %s", event.EventName(), structField.Name)
+		}
+
+		value, err := goToProtobufValue(message, field, eventValue.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("error serializing field %s of event %s: %w", structField.Name, event.EventName(), err)
+		}
+		message.Set(field, value)
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(message.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("error serializing event %s: %w", event.EventName(), err)
+	}
+	return data, nil
+}
+
+func (p *Protobuf) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (p *Protobuf) DeserializeDomainEvent(eventName string, data []byte) (domain.Event, error) {
+	event, err := registryOrDefault(p.registry).NewEvent(eventName)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing event: %w", err)
+	}
+
+	// The message is the one of the registered event, which may have been stored with an alias.
+	messageType, err := protobufMessageType(event.EventName())
+	if err != nil {
+		return nil, err
+	}
+
+	message := messageType.New()
+	if err := proto.Unmarshal(data, message.Interface()); err != nil {
+		return nil, fmt.Errorf("error deserializing event %s: %w", eventName, err)
+	}
+
+	eventValue := reflect.ValueOf(event).Elem()
+	fields := message.Descriptor().Fields()
+	for i := range eventValue.NumField() {
+		structField := eventValue.Type().Field(i)
+		field := protobufFieldByName(fields, structField.Name)
+		if field == nil {
+			// The field was added to the event after the message, so it keeps its zero value.
+			continue
+		}
+
+		if err := protobufToGoValue(message.Get(field), eventValue.Field(i)); err != nil {
+			return nil, fmt.Errorf("error deserializing field %s of event %s: %w", structField.Name, eventName, err)
+		}
+	}
+	return event, nil
+}
+
+func protobufMessageType(eventName string) (protoreflect.MessageType, error) {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protobufEventsPackage.Append(protoreflect.Name(eventName)))
+	if err != nil {
+		return nil, fmt.Errorf("no protobuf message defined for event %s: %w", eventName, err)
+	}
+	return messageType, nil
+}
+
+func protobufFieldByName(fields protoreflect.FieldDescriptors, structFieldName string) protoreflect.FieldDescriptor {
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if strings.EqualFold(strings.ReplaceAll(string(field.Name()), "_", ""), structFieldName) {
+			return field
+		}
+	}
+	return nil
+}
+
+func goToProtobufValue(message protoreflect.Message, field protoreflect.FieldDescriptor, value reflect.Value) (protoreflect.Value, error) {
+	switch {
+	case value.Type() == timeType && field.Message() != nil && field.Message().FullName() == "google.protobuf.Timestamp":
+		return protoreflect.ValueOfMessage(timestamppb.New(value.Interface().(time.Time)).ProtoReflect()), nil
+	case value.Kind() == reflect.String && field.Kind() == protoreflect.StringKind:
+		return protoreflect.ValueOfString(value.String()), nil
+	case value.CanInt() && field.Kind() == protoreflect.Int64Kind:
+		return protoreflect.ValueOfInt64(value.Int()), nil
+	case value.CanUint() && field.Kind() == protoreflect.Uint64Kind:
+		return protoreflect.ValueOfUint64(value.Uint()), nil
+	case value.Kind() == reflect.Bool && field.Kind() == protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(value.Bool()), nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("cannot store a %s in the %s field %s of %s", value.Type(), field.Kind(), field.Name(), message.Descriptor().Name())
+	}
+}
+
+func protobufToGoValue(value protoreflect.Value, target reflect.Value) error {
+	switch protobufValue := value.Interface().(type) {
+	case protoreflect.Message:
+		timestamp, ok := protobufValue.Interface().(*timestamppb.Timestamp)
+		if !ok || target.Type() != timeType {
+			break
+		}
+		// An unset timestamp is the zero time of the event, not the Unix epoch.
+		if protobufValue.IsValid() {
+			target.Set(reflect.ValueOf(timestamp.AsTime()))
+		}
+		return nil
+	case string:
+		if target.Kind() == reflect.String {
+			target.SetString(protobufValue)
+			return nil
+		}
+	case int64:
+		if target.CanInt() {
+			target.SetInt(protobufValue)
+			return nil
+		}
+	case uint64:
+		if target.CanUint() {
+			target.SetUint(protobufValue)
+			return nil
+		}
+	case bool:
+		if target.Kind() == reflect.Bool {
+			target.SetBool(protobufValue)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot read a protobuf %T into a %s", value.Interface(), target.Type())
+}
@@ -0,0 +1,90 @@
+package serializer_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+	"github.com/tembleking/myBankSourcing/pkg/transfer"
+)
+
+var _ = Describe("Protobuf", func() {
+	var ser *serializer.Protobuf
+	BeforeEach(func() {
+		ser = &serializer.Protobuf{}
+	})
+
+	It("serializes and deserializes the event", func() {
+		serialize, err := ser.SerializeDomainEvent(anEvent())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(serialize).ToNot(BeEmpty())
+
+		deserialize, err := ser.DeserializeDomainEvent("AmountDeposited", serialize)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deserialize).To(Equal(anEvent()))
+	})
+
+	It("serializes consistently", func() {
+		first, err := ser.SerializeDomainEvent(anEvent())
+		Expect(err).ToNot(HaveOccurred())
+
+		second, err := ser.SerializeDomainEvent(anEvent())
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(first).To(Equal(second))
+	})
+
+	It("keeps the zero time of the events", func() {
+		event := &account.AccountOpened{ID: "event0", AccountID: "some-account"}
+		serialize, err := ser.SerializeDomainEvent(event)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ser.DeserializeDomainEvent("AccountOpened", serialize)).To(Equal(event))
+	})
+
+	DescribeTable("has a message for every registered event",
+		func(event domain.Event) {
+			serialize, err := ser.SerializeDomainEvent(event)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ser.DeserializeDomainEvent(event.EventName(), serialize)).To(Equal(event))
+		},
+		Entry("AccountOpened", &account.AccountOpened{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", AccountVersion: 1}),
+		Entry("AmountDeposited", &account.AmountDeposited{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", Quantity: 10, Balance: 20, AccountVersion: 2}),
+		Entry("AmountWithdrawn", &account.AmountWithdrawn{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", Quantity: 10, Balance: -20, AccountVersion: 3}),
+		Entry("AccountClosed", &account.AccountClosed{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", AccountVersion: 4}),
+		Entry("TransferSent", &account.TransferSent{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", TransferID: "transfer", AccountOrigin: "some-account", AccountDestination: "other-account", Amount: 5, AccountVersion: 5}),
+		Entry("TransferReceived", &account.TransferReceived{Timestamp: aTimestamp(), ID: "event0", AccountID: "other-account", TransferID: "transfer", AccountOrigin: "some-account", AccountDestination: "other-account", Amount: 5, AccountVersion: 6}),
+		Entry("TransferSentRolledBack", &account.TransferSentRolledBack{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", TransferID: "transfer", AccountOrigin: "some-account", AccountDestination: "other-account", Reason: "destination account is closed", Amount: 5, AccountVersion: 7}),
+		Entry("TransferCompleted", &account.TransferCompleted{Timestamp: aTimestamp(), ID: "event0", AccountID: "some-account", TransferID: "transfer", AccountOrigin: "some-account", AccountDestination: "other-account", Amount: 5, AccountVersion: 8}),
+		Entry("TransferRequested", &transfer.TransferRequested{Timestamp: aTimestamp(), ID: "event0", TransferID: "transfer", FromAccount: "some-account", ToAccount: "other-account", Amount: 5, TransferVersion: 1}),
+		Entry("TransferDelivered", &transfer.TransferDelivered{Timestamp: aTimestamp(), ID: "event0", TransferID: "transfer", TransferVersion: 2}),
+		Entry("TransferCancelled", &transfer.TransferCancelled{Timestamp: aTimestamp(), ID: "event0", TransferID: "transfer", FromAccount: "origin", Reason: "wrong destination", TransferVersion: 2}),
+	)
+
+	It("fails to serialize an event without message", func() {
+		_, err := ser.SerializeDomainEvent(&eventWithoutMessage{})
+
+		Expect(err).To(MatchError(ContainSubstring("no protobuf message defined for event EventWithoutMessage")))
+	})
+
+	It("returns the content type", func() {
+		Expect(ser.ContentType()).To(Equal("application/x-protobuf"))
+	})
+})
+
+func aTimestamp() time.Time {
+	return time.Date(2024, time.February, 29, 23, 59, 59, 999999999, time.UTC)
+}
+
+type eventWithoutMessage struct{}
+
+func (e *eventWithoutMessage) AggregateID() string     { return "" }
+func (e *eventWithoutMessage) Version() uint64         { return 0 }
+func (e *eventWithoutMessage) EventID() domain.EventID { return "" }
+func (e *eventWithoutMessage) EventName() string       { return "EventWithoutMessage" }
+func (e *eventWithoutMessage) HappenedOn() time.Time   { return time.Time{} }
@@ -3,22 +3,11 @@ package serializer
 import (
 	"encoding/json"
 	"fmt"
-	"reflect"
 
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
-type structMapSerializing struct {
-	registeredTypes map[string]any
-}
-
-var structMapSerializer *structMapSerializing
-
-func init() {
-	structMapSerializer = &structMapSerializing{registeredTypes: map[string]any{}}
-}
-
-func (s *structMapSerializing) serializeToMap(event domain.Event) (map[string]any, error) {
+func serializeToMap(event domain.Event) (map[string]any, error) {
 	result := map[string]any{}
 	err := decode(event, &result)
 	if err != nil {
@@ -27,29 +16,25 @@ func (s *structMapSerializing) serializeToMap(event domain.Event) (map[string]an
 	return result, nil
 }
 
-func (s *structMapSerializing) deserializeFromMap(eventName string, data map[string]any) (domain.Event, error) {
-	if _, ok := s.registeredTypes[eventName]; !ok {
-		return nil, fmt.Errorf("error deserializing from map, type not registered: %s", eventName)
+func deserializeFromMap(registry *EventRegistry, eventName string, data map[string]any) (domain.Event, error) {
+	event, err := registry.NewEvent(eventName)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing from map: %w", err)
 	}
 
-	event := reflect.New(reflect.TypeOf(s.registeredTypes[eventName])).Interface()
-	err := decode(data, event)
+	err = decode(data, event)
 	if err != nil {
 		return nil, fmt.Errorf("error deserializing type %s from map: %w", eventName, err)
 	}
-	return event.(domain.Event), nil
+	return event, nil
 }
 
-func (s *structMapSerializing) register(value domain.Event) {
-	reflectValue := reflect.ValueOf(value)
-	for {
-		if reflectValue.Kind() == reflect.Ptr {
-			reflectValue = reflectValue.Elem()
-			continue
-		}
-		break
+// registryOrDefault returns the DefaultEventRegistry for the serializers created without a registry.
+func registryOrDefault(registry *EventRegistry) *EventRegistry {
+	if registry == nil {
+		return defaultEventRegistry
 	}
-	s.registeredTypes[value.EventName()] = reflectValue.Interface()
+	return registry
 }
 
 func decode(input any, output any) error {
@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+const (
+	defaultBackupInterval  = time.Hour
+	defaultBackupRetention = 24
+
+	backupFilePrefix = "backup-"
+	backupFileSuffix = ".sqlite"
+	// backupTimeLayout sorts the names of the backups in the order they were taken.
+	backupTimeLayout = "20060102T150405.000000000Z"
+)
+
+// Backup copies the database to a new file at path with the online backup API of sqlite,
+// so the copy is consistent while the store keeps serving reads and writes.
+func (a *AppendOnlyStore) Backup(ctx context.Context, path string) error {
+	sourceDB, err := a.db.DB()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve database connection: %w", err)
+	}
+	sourceConn, err := sourceDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve database connection: %w", err)
+	}
+	defer sourceConn.Close()
+
+	targetDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("unable to open the backup database: %w", err)
+	}
+	defer targetDB.Close()
+	targetConn, err := targetDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to open the backup database: %w", err)
+	}
+	defer targetConn.Close()
+
+	return targetConn.Raw(func(targetDriverConn any) error {
+		return sourceConn.Raw(func(sourceDriverConn any) error {
+			backup, err := targetDriverConn.(*sqlite3.SQLiteConn).Backup("main", sourceDriverConn.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return fmt.Errorf("unable to start the backup: %w", err)
+			}
+
+			// Copying all the pages in a single step keeps the source locked for writes until it ends,
+			// instead of restarting the backup every time another connection writes to it.
+			_, stepErr := backup.Step(-1)
+			finishErr := backup.Finish()
+			if err := errors.Join(stepErr, finishErr); err != nil {
+				return fmt.Errorf("unable to copy the database: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// Backups takes backups of an AppendOnlyStore to a directory, keeping only the newest ones.
+type Backups struct {
+	store     *AppendOnlyStore
+	dir       string
+	interval  time.Duration
+	retention int
+	mutex     sync.Mutex
+}
+
+var _ persistence.Backuper = &Backups{}
+
+func NewBackups(store *AppendOnlyStore, dir string) *Backups {
+	return &Backups{
+		store:     store,
+		dir:       dir,
+		interval:  defaultBackupInterval,
+		retention: defaultBackupRetention,
+	}
+}
+
+// WithInterval sets how often Run takes a backup.
+func (b *Backups) WithInterval(interval time.Duration) *Backups {
+	b.interval = interval
+	return b
+}
+
+// WithRetention sets how many backups are kept, removing the oldest ones after taking a new one.
+func (b *Backups) WithRetention(retention int) *Backups {
+	b.retention = retention
+	return b
+}
+
+// Run takes a backup every interval until the context is cancelled.
+func (b *Backups) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		backup, err := b.TakeBackup(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Default().ErrorContext(ctx, "error taking the scheduled backup", "error", err.Error())
+			}
+			continue
+		}
+		slog.Default().InfoContext(ctx, "took the scheduled backup", "path", backup.Path, "size", backup.Size)
+	}
+}
+
+func (b *Backups) TakeBackup(ctx context.Context) (persistence.Backup, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := os.MkdirAll(b.dir, 0o750); err != nil {
+		return persistence.Backup{}, fmt.Errorf("unable to create the backup directory: %w", err)
+	}
+
+	createdOn := time.Now().UTC()
+	path := filepath.Join(b.dir, backupFilePrefix+createdOn.Format(backupTimeLayout)+backupFileSuffix)
+	// The backup is written to a temporary file first, so a failed backup is never listed.
+	temporaryPath := path + ".tmp"
+	if err := b.store.Backup(ctx, temporaryPath); err != nil {
+		_ = os.Remove(temporaryPath)
+		return persistence.Backup{}, err
+	}
+	if err := os.Rename(temporaryPath, path); err != nil {
+		return persistence.Backup{}, fmt.Errorf("unable to move the backup into place: %w", err)
+	}
+
+	if err := b.removeExpired(ctx); err != nil {
+		return persistence.Backup{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return persistence.Backup{}, fmt.Errorf("unable to read the backup: %w", err)
+	}
+	return persistence.Backup{Path: path, CreatedOn: createdOn, Size: info.Size()}, nil
+}
+
+func (b *Backups) ListBackups(_ context.Context) ([]persistence.Backup, error) {
+	entries, err := os.ReadDir(b.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the backup directory: %w", err)
+	}
+
+	var backups []persistence.Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupFilePrefix) || !strings.HasSuffix(name, backupFileSuffix) {
+			continue
+		}
+		createdOn, err := time.Parse(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the backup %s: %w", name, err)
+		}
+		backups = append(backups, persistence.Backup{Path: filepath.Join(b.dir, name), CreatedOn: createdOn, Size: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedOn.Before(backups[j].CreatedOn) })
+	return backups, nil
+}
+
+func (b *Backups) removeExpired(ctx context.Context) error {
+	backups, err := b.ListBackups(ctx)
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > b.retention {
+		if err := os.Remove(backups[0].Path); err != nil {
+			return fmt.Errorf("unable to remove the expired backup %s: %w", backups[0].Path, err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
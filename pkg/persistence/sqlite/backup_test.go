@@ -0,0 +1,95 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("Sqlite backups", func() {
+	var (
+		store     *sqlite.AppendOnlyStore
+		backupDir string
+	)
+
+	appendEvent := func(ctx context.Context, i int) {
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{
+			ID:         persistence.StreamID{StreamName: "aggregate-0", StreamVersion: uint64(i)},
+			EventID:    domain.EventID(fmt.Sprintf("event%d", i)),
+			EventName:  "eventName",
+			EventData:  []byte(fmt.Sprintf("data%d", i)),
+			HappenedOn: time.Now(),
+		})).To(Succeed())
+	}
+
+	BeforeEach(func(ctx context.Context) {
+		var err error
+		store, err = sqlite.New("file:" + filepath.Join(GinkgoT().TempDir(), "events.sqlite"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.MigrateDB()).To(Succeed())
+		backupDir = filepath.Join(GinkgoT().TempDir(), "backups")
+
+		for i := range 3 {
+			appendEvent(ctx, i)
+		}
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("takes a backup with the events of the store", func(ctx context.Context) {
+		backup, err := sqlite.NewBackups(store, backupDir).TakeBackup(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		appendEvent(ctx, 3)
+
+		Expect(backup.Path).To(HavePrefix(backupDir))
+		Expect(backup.Size).To(BeNumerically(">", 0))
+		backupStore, err := sqlite.New("file:" + backup.Path + "?mode=ro")
+		Expect(err).ToNot(HaveOccurred())
+		defer backupStore.Close()
+		Expect(backupStore.ReadAllRecords(ctx)).To(HaveLen(3))
+	})
+
+	It("keeps only the newest backups", func(ctx context.Context) {
+		backups := sqlite.NewBackups(store, backupDir).WithRetention(2)
+		var taken []persistence.Backup
+		for range 3 {
+			backup, err := backups.TakeBackup(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			taken = append(taken, backup)
+		}
+
+		Expect(backups.ListBackups(ctx)).To(Equal(taken[1:]))
+		Expect(taken[0].Path).ToNot(BeAnExistingFile())
+	})
+
+	It("lists no backups before taking any", func(ctx context.Context) {
+		Expect(sqlite.NewBackups(store, backupDir).ListBackups(ctx)).To(BeEmpty())
+	})
+
+	It("ignores the other files of the backup directory", func(ctx context.Context) {
+		Expect(os.MkdirAll(backupDir, 0o750)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(backupDir, "notes.txt"), []byte("notes"), 0o600)).To(Succeed())
+
+		Expect(sqlite.NewBackups(store, backupDir).ListBackups(ctx)).To(BeEmpty())
+	})
+
+	It("takes backups periodically until the context is cancelled", func(ctx context.Context) {
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		backups := sqlite.NewBackups(store, backupDir).WithInterval(10 * time.Millisecond)
+		go backups.Run(runCtx)
+
+		Eventually(func() ([]persistence.Backup, error) { return backups.ListBackups(ctx) }).ShouldNot(BeEmpty())
+	})
+})
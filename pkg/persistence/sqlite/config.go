@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JournalMode is how sqlite keeps the changes of a transaction until it is committed, see https://www.sqlite.org/pragma.html#pragma_journal_mode
+type JournalMode string
+
+const (
+	JournalModeDelete JournalMode = "DELETE"
+	// JournalModeWAL lets the readers run concurrently with the writer.
+	JournalModeWAL JournalMode = "WAL"
+)
+
+// SynchronousLevel is how often sqlite waits for the changes to reach the disk, see https://www.sqlite.org/pragma.html#pragma_synchronous
+type SynchronousLevel string
+
+const (
+	SynchronousOff SynchronousLevel = "OFF"
+	// SynchronousNormal is durable in WAL mode, except for the last commits before a power loss.
+	SynchronousNormal SynchronousLevel = "NORMAL"
+	SynchronousFull   SynchronousLevel = "FULL"
+	SynchronousExtra  SynchronousLevel = "EXTRA"
+)
+
+// Config tunes the connections to the sqlite database.
+// The zero value of every field keeps the default of sqlite or database/sql.
+type Config struct {
+	JournalMode JournalMode
+	// BusyTimeout is how long a connection waits for another one to release the lock of the database before failing with SQLITE_BUSY.
+	BusyTimeout time.Duration
+	Synchronous SynchronousLevel
+	// CacheSizeKiB is the size of the page cache of each connection.
+	CacheSizeKiB int
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// MaxGroupCommit is how many of the appends queued while the previous commit was running are committed together.
+	// Values below 2 commit every append in its own transaction.
+	MaxGroupCommit int
+}
+
+// DefaultConfig returns the configuration for a database written by one process and read concurrently by many.
+func DefaultConfig() Config {
+	return Config{
+		JournalMode:    JournalModeWAL,
+		BusyTimeout:    5 * time.Second,
+		Synchronous:    SynchronousNormal,
+		MaxGroupCommit: 100,
+	}
+}
+
+// connectionString adds the pragmas to the connection string, so they are set in every connection of the pool.
+func (c Config) connectionString(connectionString string) string {
+	params := url.Values{}
+	if c.JournalMode != "" {
+		params.Set("_journal_mode", string(c.JournalMode))
+	}
+	if c.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(c.BusyTimeout.Milliseconds(), 10))
+	}
+	if c.Synchronous != "" {
+		params.Set("_synchronous", string(c.Synchronous))
+	}
+	if c.CacheSizeKiB > 0 {
+		// A negative cache size is in KiB instead of in pages.
+		params.Set("_cache_size", strconv.Itoa(-c.CacheSizeKiB))
+	}
+	if len(params) == 0 {
+		return connectionString
+	}
+
+	separator := "?"
+	if strings.Contains(connectionString, "?") {
+		separator = "&"
+	}
+	return connectionString + separator + params.Encode()
+}
+
+func (c Config) configurePool(db *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+}
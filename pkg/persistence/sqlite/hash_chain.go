@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite/internal/model"
+)
+
+const chainVerificationBatchSize = 1000
+
+// tenantStream identifies a stream in the whole log, since every tenant has its own streams.
+type tenantStream struct {
+	tenantID   string
+	streamName string
+}
+
+// chainEvents links the inserted events to the previous ones in their stream and in the whole log.
+// It runs after inserting them, when the transaction already holds the write lock of the database,
+// so no other append can be linked to the same previous events.
+func chainEvents(ctx context.Context, tx *gorm.DB, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	globalHash, err := previousHash(ctx, tx.Where("row_id < ?", events[0].RowID), "global_hash")
+	if err != nil {
+		return err
+	}
+
+	streamHashes := map[tenantStream]string{}
+	for i := range events {
+		event := &events[i]
+		stream := tenantStream{tenantID: event.TenantID, streamName: event.StreamName}
+		streamHash, known := streamHashes[stream]
+		if !known {
+			streamHash, err = previousHash(ctx, tx.Where("row_id < ? AND tenant_id = ? AND stream_name = ?", events[0].RowID, event.TenantID, event.StreamName), "stream_hash")
+			if err != nil {
+				return err
+			}
+		}
+
+		storedEvent, err := modelEventToPersistence(*event)
+		if err != nil {
+			return err
+		}
+		event.StreamHash = persistence.ChainHash(streamHash, storedEvent)
+		event.GlobalHash = persistence.ChainHash(globalHash, storedEvent)
+		streamHashes[stream] = event.StreamHash
+		globalHash = event.GlobalHash
+
+		err = tx.WithContext(ctx).Model(&model.Event{}).Where("row_id = ?", event.RowID).Updates(map[string]any{
+			"stream_hash": event.StreamHash,
+			"global_hash": event.GlobalHash,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("unable to save the hashes of the event '%s': %w", event.EventID, err)
+		}
+	}
+	return nil
+}
+
+func previousHash(ctx context.Context, query *gorm.DB, column string) (string, error) {
+	var previous model.Event
+	err := query.WithContext(ctx).Select(column).Order("row_id DESC").Take(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the hash of the previous event: %w", err)
+	}
+	if column == "stream_hash" {
+		return previous.StreamHash, nil
+	}
+	return previous.GlobalHash, nil
+}
+
+// VerifyChain recomputes the hashes of all the events in the order they were appended.
+// The events appended before the hashes were introduced have none, and are skipped. Where the chain starts is
+// recorded when the hashes are introduced, so any event without hashes after it is reported as a broken link.
+func (a *AppendOnlyStore) VerifyChain(ctx context.Context) (persistence.ChainVerification, error) {
+	var (
+		verification persistence.ChainVerification
+		globalHash   string
+		streamHashes = map[tenantStream]string{}
+		chainStart   model.HashChainStart
+		lastRowID    int32
+		db           = a.db.Session(&gorm.Session{NewDB: true})
+	)
+
+	if err := db.WithContext(ctx).Take(&chainStart).Error; err != nil {
+		return persistence.ChainVerification{}, fmt.Errorf("unable to retrieve where the hash chain starts: %w", err)
+	}
+
+	for {
+		var events []model.Event
+		err := db.WithContext(ctx).Where("row_id > ?", lastRowID).Order("row_id").Limit(chainVerificationBatchSize).Find(&events).Error
+		if err != nil {
+			return persistence.ChainVerification{}, fmt.Errorf("unable to retrieve the events to verify: %w", err)
+		}
+		if len(events) == 0 {
+			return verification, nil
+		}
+
+		for _, event := range events {
+			lastRowID = event.RowID
+			if event.RowID <= chainStart.LastUnchainedRowID {
+				verification.UnchainedEvents++
+				continue
+			}
+
+			storedEvent, err := modelEventToPersistence(event)
+			if err != nil {
+				return persistence.ChainVerification{}, err
+			}
+			if event.StreamHash == "" || event.GlobalHash == "" {
+				verification.BrokenLink = brokenLink(storedEvent, "the event has no hash, but it was appended after the hashes were introduced")
+				return verification, nil
+			}
+
+			stream := tenantStream{tenantID: event.TenantID, streamName: event.StreamName}
+			expectedStreamHash := persistence.ChainHash(streamHashes[stream], storedEvent)
+			expectedGlobalHash := persistence.ChainHash(globalHash, storedEvent)
+			switch {
+			case event.GlobalHash != expectedGlobalHash:
+				verification.BrokenLink = brokenLink(storedEvent, "the event does not match its hash or is not linked to the previous event of the log")
+				return verification, nil
+			case event.StreamHash != expectedStreamHash:
+				verification.BrokenLink = brokenLink(storedEvent, "the event is not linked to the previous event of its stream")
+				return verification, nil
+			}
+
+			streamHashes[stream] = event.StreamHash
+			globalHash = event.GlobalHash
+			verification.VerifiedEvents++
+		}
+	}
+}
+
+func brokenLink(event persistence.StoredStreamEvent, reason string) *persistence.BrokenLink {
+	return &persistence.BrokenLink{
+		EventID: event.EventID,
+		ID:      event.ID,
+		Reason:  reason,
+	}
+}
@@ -0,0 +1,98 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("Sqlite hash chain", func() {
+	var (
+		store *sqlite.AppendOnlyStore
+		// db is a direct connection to the database, to tamper with the events.
+		db *sql.DB
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		databaseFile := filepath.Join(GinkgoT().TempDir(), "events.sqlite")
+		var err error
+		store, err = sqlite.New("file:" + databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.MigrateDB()).To(Succeed())
+		db, err = sql.Open("sqlite3", databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+
+		happenedOn := time.Date(2024, time.January, 1, 10, 0, 0, 123456789, time.Local)
+		Expect(store.Append(ctx,
+			persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 0}, EventID: "event0", EventName: "eventName", EventData: []byte("data0"), HappenedOn: happenedOn},
+			persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 0}, EventID: "event1", EventName: "eventName", EventData: []byte("data1"), HappenedOn: happenedOn},
+		)).To(Succeed())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event2", EventName: "eventName", EventData: []byte("data2"), HappenedOn: happenedOn})).To(Succeed())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-1", StreamVersion: 1}, EventID: "event3", EventName: "eventName", EventData: []byte("data3"), HappenedOn: happenedOn})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+		store.Close()
+	})
+
+	It("verifies an untouched log", func(ctx context.Context) {
+		Expect(store.VerifyChain(ctx)).To(Equal(persistence.ChainVerification{VerifiedEvents: 4}))
+	})
+
+	It("reports the event whose data was modified", func(ctx context.Context) {
+		Expect(db.ExecContext(ctx, "UPDATE event SET event_data = ? WHERE event_id = ?", []byte("forged"), "event2")).Error().ToNot(HaveOccurred())
+
+		Expect(store.VerifyChain(ctx)).To(Equal(persistence.ChainVerification{
+			VerifiedEvents: 2,
+			BrokenLink: &persistence.BrokenLink{
+				EventID: "event2",
+				ID:      persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1},
+				Reason:  "the event does not match its hash or is not linked to the previous event of the log",
+			},
+		}))
+	})
+
+	It("reports the event after a removed one", func(ctx context.Context) {
+		Expect(db.ExecContext(ctx, "DELETE FROM event WHERE event_id = ?", "event1")).Error().ToNot(HaveOccurred())
+
+		Expect(store.VerifyChain(ctx)).To(HaveField("BrokenLink.EventID", BeEquivalentTo("event2")))
+	})
+
+	It("reports the event whose hash was recomputed without its stream", func(ctx context.Context) {
+		records, err := store.ReadRecords(ctx, "aggregate-1")
+		Expect(err).ToNot(HaveOccurred())
+		// The global link is kept, but the event is moved to the start of its stream.
+		var previousGlobalHash string
+		Expect(db.QueryRowContext(ctx, "SELECT global_hash FROM event WHERE event_id = ?", "event2").Scan(&previousGlobalHash)).To(Succeed())
+		Expect(db.ExecContext(ctx, "UPDATE event SET stream_hash = ?, global_hash = ? WHERE event_id = ?",
+			persistence.ChainHash("", records[1]), persistence.ChainHash(previousGlobalHash, records[1]), "event3")).Error().ToNot(HaveOccurred())
+
+		Expect(store.VerifyChain(ctx)).To(HaveField("BrokenLink", PointTo(HaveField("Reason", "the event is not linked to the previous event of its stream"))))
+	})
+
+	It("skips the events appended before the hashes were introduced", func(ctx context.Context) {
+		Expect(db.ExecContext(ctx, "UPDATE event SET stream_hash = '', global_hash = '' WHERE event_id IN (?, ?)", "event0", "event1")).Error().ToNot(HaveOccurred())
+		Expect(db.ExecContext(ctx, "DELETE FROM event WHERE event_id IN (?, ?)", "event2", "event3")).Error().ToNot(HaveOccurred())
+		Expect(db.ExecContext(ctx, "UPDATE hash_chain_start SET last_unchained_row_id = (SELECT row_id FROM event WHERE event_id = ?)", "event1")).Error().ToNot(HaveOccurred())
+		Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 1}, EventID: "event4", EventName: "eventName", EventData: []byte("data4")})).To(Succeed())
+
+		Expect(store.VerifyChain(ctx)).To(Equal(persistence.ChainVerification{VerifiedEvents: 1, UnchainedEvents: 2}))
+	})
+	It("reports the events without hashes appended after the hashes were introduced", func(ctx context.Context) {
+		Expect(db.ExecContext(ctx, "UPDATE event SET stream_hash = '', global_hash = '' WHERE event_id IN (?, ?)", "event0", "event1")).Error().ToNot(HaveOccurred())
+
+		Expect(store.VerifyChain(ctx)).To(HaveField("BrokenLink", PointTo(MatchFields(IgnoreExtras, Fields{
+			"EventID": BeEquivalentTo("event0"),
+			"Reason":  Equal("the event has no hash, but it was appended after the hashes were introduced"),
+		}))))
+	})
+})
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS subject_key
+(
+    subject_id TEXT PRIMARY KEY,
+    key        BLOB,
+    created_on TIMESTAMP NOT NULL,
+    deleted_on TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS outbox_dead_letter;
+DROP INDEX IF EXISTS outbox_next_attempt_at_idx;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    row_id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id        TEXT    NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS outbox_next_attempt_at_idx ON outbox (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS outbox_dead_letter
+(
+    row_id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id         TEXT      NOT NULL,
+    attempts         INTEGER   NOT NULL,
+    last_error       TEXT      NOT NULL,
+    dead_lettered_on TIMESTAMP NOT NULL
+);
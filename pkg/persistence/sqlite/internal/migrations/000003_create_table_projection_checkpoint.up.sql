@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS projection_checkpoint
+(
+    name          TEXT PRIMARY KEY,
+    version       UNSIGNED BIG INT NOT NULL,
+    last_event_id TEXT             NOT NULL,
+    state         BLOB             NOT NULL,
+    updated_on    TIMESTAMP        NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE event DROP COLUMN global_hash;
+ALTER TABLE event DROP COLUMN stream_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE event ADD COLUMN stream_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE event ADD COLUMN global_hash TEXT NOT NULL DEFAULT '';
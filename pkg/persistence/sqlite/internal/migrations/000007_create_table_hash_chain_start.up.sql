@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS hash_chain_start
+(
+    id                    INTEGER PRIMARY KEY CHECK (id = 1),
+    last_unchained_row_id INTEGER NOT NULL
+);
+
+-- The events stored before the hashes were introduced are the ones before the first hashed event.
+-- If there is no hashed event yet, the chain starts after the last stored event.
+INSERT INTO hash_chain_start (id, last_unchained_row_id)
+VALUES (1, COALESCE((SELECT MIN(row_id) FROM event WHERE global_hash <> '') - 1, (SELECT MAX(row_id) FROM event), 0));
@@ -0,0 +1,7 @@
+ALTER TABLE projection_checkpoint ADD COLUMN state BLOB NOT NULL DEFAULT x'';
+
+INSERT OR REPLACE INTO projection_checkpoint (name, version, last_event_id, state, updated_on)
+SELECT name, version, last_event_id, state, taken_on
+FROM projection_snapshot;
+
+DROP TABLE IF EXISTS projection_snapshot;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS projection_snapshot
+(
+    name          TEXT PRIMARY KEY,
+    version       UNSIGNED BIG INT NOT NULL,
+    last_event_id TEXT             NOT NULL,
+    state         BLOB             NOT NULL,
+    taken_on      TIMESTAMP        NOT NULL
+);
+
+-- The checkpoints with a state were saved by the projections kept in memory, which now resume from their snapshot.
+INSERT INTO projection_snapshot (name, version, last_event_id, state, taken_on)
+SELECT name, version, last_event_id, state, updated_on
+FROM projection_checkpoint
+WHERE length(state) > 0;
+
+DELETE FROM projection_checkpoint WHERE length(state) > 0;
+
+ALTER TABLE projection_checkpoint DROP COLUMN state;
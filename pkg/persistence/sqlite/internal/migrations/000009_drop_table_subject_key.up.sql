@@ -0,0 +1,2 @@
+-- The keys of the subjects are saved in their own database, so the backups of the events do not contain them.
+DROP TABLE IF EXISTS subject_key;
@@ -20,6 +20,9 @@ type Event struct {
 	EventData     []byte    `gorm:"column:event_data;not null" json:"event_data"`
 	HappenedOn    time.Time `gorm:"column:happened_on;not null" json:"happened_on"`
 	ContentType   string    `gorm:"column:content_type;not null" json:"content_type"`
+	StreamHash    string    `gorm:"column:stream_hash;not null" json:"stream_hash"`
+	GlobalHash    string    `gorm:"column:global_hash;not null" json:"global_hash"`
+	TenantID      string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
 }
 
 // TableName Event's table name
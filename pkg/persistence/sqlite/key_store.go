@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite/internal/model"
+)
+
+const keySize = 32
+
+// KeyStore saves the keys of the subjects in its own database, which must not be the one of the events,
+// so the backups of the events do not contain the keys to decrypt them.
+type KeyStore struct {
+	db *gorm.DB
+}
+
+// OpenKeyStore opens the key database with the DefaultConfig, and creates the table of the keys if it does not exist.
+func OpenKeyStore(connectionString string) (*KeyStore, error) {
+	db, err := openDB(connectionString, DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateDB(db, keyMigrations, "internal/key_migrations", keyMigrationsTable); err != nil {
+		return nil, err
+	}
+	return &KeyStore{db: db}, nil
+}
+
+func (k *KeyStore) CreateKey(ctx context.Context, subjectID string) ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("unable to generate the key of the subject '%s': %w", subjectID, err)
+	}
+
+	// If another key was created concurrently, or it was deleted, the existing row is kept and returned.
+	err := k.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.SubjectKey{
+		SubjectID: subjectID,
+		Key:       key,
+		CreatedOn: time.Now().UTC(),
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("unable to save the key of the subject '%s': %w", subjectID, err)
+	}
+
+	return k.Key(ctx, subjectID)
+}
+
+func (k *KeyStore) Key(ctx context.Context, subjectID string) ([]byte, error) {
+	var subjectKey model.SubjectKey
+	err := k.db.WithContext(ctx).Where("subject_id = ?", subjectID).Take(&subjectKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, persistence.ErrSubjectKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the key of the subject '%s': %w", subjectID, err)
+	}
+	if subjectKey.DeletedOn != nil {
+		return nil, persistence.ErrSubjectKeyDeleted
+	}
+	return subjectKey.Key, nil
+}
+
+// DeleteKey removes the key but keeps the row of the subject, so it is known to be deleted.
+func (k *KeyStore) DeleteKey(ctx context.Context, subjectID string) error {
+	now := time.Now().UTC()
+	err := k.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"key", "deleted_on"}),
+	}).Create(&model.SubjectKey{
+		SubjectID: subjectID,
+		CreatedOn: now,
+		DeletedOn: &now,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("unable to delete the key of the subject '%s': %w", subjectID, err)
+	}
+	return nil
+}
+
+func (k *KeyStore) Close() error {
+	db, err := k.db.DB()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve database connection: %w", err)
+	}
+	return db.Close()
+}
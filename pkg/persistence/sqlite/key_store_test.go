@@ -0,0 +1,111 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("Sqlite KeyStore", func() {
+	var (
+		databaseFile string
+		keyStore     *sqlite.KeyStore
+	)
+
+	BeforeEach(func() {
+		databaseFile = filepath.Join(GinkgoT().TempDir(), "keys.sqlite")
+		var err error
+		keyStore, err = sqlite.OpenKeyStore("file:" + databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		keyStore.Close()
+	})
+
+	It("returns an error if the subject has no key", func(ctx context.Context) {
+		_, err := keyStore.Key(ctx, "some-subject")
+
+		Expect(err).To(MatchError(persistence.ErrSubjectKeyNotFound))
+	})
+
+	It("creates a key only once per subject", func(ctx context.Context) {
+		key, err := keyStore.CreateKey(ctx, "some-subject")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key).To(HaveLen(32))
+
+		Expect(keyStore.CreateKey(ctx, "some-subject")).To(Equal(key))
+		Expect(keyStore.Key(ctx, "some-subject")).To(Equal(key))
+		Expect(keyStore.CreateKey(ctx, "other-subject")).ToNot(Equal(key))
+	})
+
+	It("never returns the key again once deleted", func(ctx context.Context) {
+		_, err := keyStore.CreateKey(ctx, "some-subject")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(keyStore.DeleteKey(ctx, "some-subject")).To(Succeed())
+
+		_, err = keyStore.Key(ctx, "some-subject")
+		Expect(err).To(MatchError(persistence.ErrSubjectKeyDeleted))
+		_, err = keyStore.CreateKey(ctx, "some-subject")
+		Expect(err).To(MatchError(persistence.ErrSubjectKeyDeleted))
+	})
+
+	It("does not create keys for subjects deleted before having one", func(ctx context.Context) {
+		Expect(keyStore.DeleteKey(ctx, "some-subject")).To(Succeed())
+
+		_, err := keyStore.CreateKey(ctx, "some-subject")
+		Expect(err).To(MatchError(persistence.ErrSubjectKeyDeleted))
+	})
+	It("keeps only the table of the keys in its database", func(ctx context.Context) {
+		db, err := sql.Open("sqlite3", databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		var tables []string
+		rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+		Expect(err).ToNot(HaveOccurred())
+		defer rows.Close()
+		for rows.Next() {
+			var table string
+			Expect(rows.Scan(&table)).To(Succeed())
+			tables = append(tables, table)
+		}
+		Expect(tables).To(ConsistOf("key_schema_migrations", "subject_key"))
+	})
+
+	It("keeps the keys of a database created with the migrations of the events", func(ctx context.Context) {
+		Expect(keyStore.Close()).To(Succeed())
+		legacyFile := filepath.Join(GinkgoT().TempDir(), "legacy-keys.sqlite")
+		legacy, err := sqlite.New("file:" + legacyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(legacy.MigrateDB()).To(Succeed())
+		Expect(legacy.Close()).To(Succeed())
+
+		keyStore, err = sqlite.OpenKeyStore("file:" + legacyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keyStore.CreateKey(ctx, "some-subject")).To(HaveLen(32))
+	})
+
+	It("leaves no table of the keys in the database of the events", func(ctx context.Context) {
+		eventsFile := filepath.Join(GinkgoT().TempDir(), "events.sqlite")
+		events, err := sqlite.New("file:" + eventsFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events.MigrateDB()).To(Succeed())
+		Expect(events.Close()).To(Succeed())
+
+		db, err := sql.Open("sqlite3", eventsFile)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		var tables int
+		Expect(db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'subject_key'").Scan(&tables)).To(Succeed())
+		Expect(tables).To(BeZero())
+	})
+})
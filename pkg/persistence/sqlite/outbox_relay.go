@@ -0,0 +1,264 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite/internal/model"
+)
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxMaxAttempts  = 10
+	defaultOutboxRetryBackoff = time.Second
+	maxOutboxRetryBackoff     = 5 * time.Minute
+	outboxCopyBatchSize       = 500
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// OutboxRelay publishes to an EventBus the events committed to the outbox of the AppendOnlyStore.
+// Delivery is at-least-once: an event is only removed from the outbox after the EventBus accepts it,
+// so listeners must be idempotent.
+// Events that fail to be published are retried with an exponential backoff, and are moved to the
+// dead-letter table once they reach the maximum number of attempts or if they cannot be deserialized.
+type OutboxRelay struct {
+	db            *gorm.DB
+	deserializers *persistence.DeserializerRegistry
+	eventBus      domain.EventBus
+	pollInterval  time.Duration
+	batchSize     int
+	maxAttempts   int
+	retryBackoff  time.Duration
+}
+
+type DeadLetter struct {
+	DeadLetteredOn time.Time
+	EventID        domain.EventID
+	LastError      string
+	Attempts       int
+}
+
+type pendingOutboxEvent struct {
+	EventID     string
+	EventName   string
+	ContentType string
+	TenantID    string
+	EventData   []byte
+	RowID       int32
+	Attempts    int32
+}
+
+func NewOutboxRelay(store *AppendOnlyStore, deserializers *persistence.DeserializerRegistry, eventBus domain.EventBus) *OutboxRelay {
+	return &OutboxRelay{
+		db:            store.db.Session(&gorm.Session{NewDB: true}),
+		deserializers: deserializers,
+		eventBus:      eventBus,
+		pollInterval:  defaultOutboxPollInterval,
+		batchSize:     defaultOutboxBatchSize,
+		maxAttempts:   defaultOutboxMaxAttempts,
+		retryBackoff:  defaultOutboxRetryBackoff,
+	}
+}
+
+func (r *OutboxRelay) WithPollInterval(pollInterval time.Duration) *OutboxRelay {
+	r.pollInterval = pollInterval
+	return r
+}
+
+func (r *OutboxRelay) WithMaxAttempts(maxAttempts int) *OutboxRelay {
+	r.maxAttempts = maxAttempts
+	return r
+}
+
+// WithRetryBackoff sets the delay before the first retry of a failed event, which doubles on every following attempt.
+func (r *OutboxRelay) WithRetryBackoff(retryBackoff time.Duration) *OutboxRelay {
+	r.retryBackoff = retryBackoff
+	return r
+}
+
+// Run relays the pending events until the context is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		relayed, err := r.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Default().ErrorContext(ctx, "error relaying the outbox events", "error", err.Error())
+		}
+
+		// If the batch was full there may be more events waiting, so we don't wait for the next tick.
+		if relayed == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending tries to publish a batch of the events that are due in the outbox,
+// returning how many events were processed, whether they were published or not.
+// If the batch fails midway, it returns how many events were processed before the failure with the error.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	var pendingEvents []pendingOutboxEvent
+	err := r.db.WithContext(ctx).
+		Table(model.TableNameOutbox).
+		Select("outbox.row_id, outbox.event_id, outbox.attempts, event.event_name, event.content_type, event.event_data, event.tenant_id").
+		Joins("JOIN event ON event.event_id = outbox.event_id").
+		Where("outbox.next_attempt_at <= ?", time.Now().UnixNano()).
+		Order("outbox.row_id").
+		Limit(r.batchSize).
+		Scan(&pendingEvents).Error
+	if err != nil {
+		return 0, fmt.Errorf("unable to retrieve pending events from the outbox: %w", err)
+	}
+
+	for i, pendingEvent := range pendingEvents {
+		if err := r.relay(ctx, pendingEvent); err != nil {
+			return i, err
+		}
+	}
+
+	return len(pendingEvents), nil
+}
+
+func (r *OutboxRelay) relay(ctx context.Context, pendingEvent pendingOutboxEvent) error {
+	event, err := r.deserializers.Deserialize(pendingEvent.ContentType, pendingEvent.EventName, pendingEvent.EventData)
+	if err != nil {
+		// Retrying will not fix an event that cannot be deserialized.
+		return r.moveToDeadLetter(ctx, pendingEvent, pendingEvent.Attempts+1, fmt.Errorf("error deserializing event: %w", err))
+	}
+
+	// The listeners are scoped to the tenant of the event, so they only touch its data.
+	publishErr := r.eventBus.Publish(domain.WithTenant(ctx, domain.TenantID(pendingEvent.TenantID)), event)
+	if publishErr == nil {
+		err = r.db.WithContext(ctx).Delete(&model.Outbox{}, pendingEvent.RowID).Error
+		if err != nil {
+			return fmt.Errorf("unable to remove published event '%s' from the outbox: %w", pendingEvent.EventID, err)
+		}
+		return nil
+	}
+
+	attempts := pendingEvent.Attempts + 1
+	if int(attempts) >= r.maxAttempts {
+		return r.moveToDeadLetter(ctx, pendingEvent, attempts, publishErr)
+	}
+
+	err = r.db.WithContext(ctx).Model(&model.Outbox{RowID: pendingEvent.RowID}).Updates(map[string]any{
+		"attempts":        attempts,
+		"next_attempt_at": time.Now().Add(r.backoffFor(attempts)).UnixNano(),
+		"last_error":      publishErr.Error(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("unable to schedule the retry of event '%s' in the outbox: %w", pendingEvent.EventID, err)
+	}
+	return nil
+}
+
+func (r *OutboxRelay) backoffFor(attempts int32) time.Duration {
+	backoff := r.retryBackoff
+	for i := int32(1); i < attempts && backoff < maxOutboxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxOutboxRetryBackoff)
+}
+
+func (r *OutboxRelay) moveToDeadLetter(ctx context.Context, pendingEvent pendingOutboxEvent, attempts int32, cause error) error {
+	slog.Default().WarnContext(ctx, "moving outbox event to the dead-letter table", "eventID", pendingEvent.EventID, "attempts", attempts, "error", cause.Error())
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("row_id").Create(&model.OutboxDeadLetter{
+			EventID:        pendingEvent.EventID,
+			Attempts:       attempts,
+			LastError:      cause.Error(),
+			DeadLetteredOn: time.Now().UTC(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&model.Outbox{}, pendingEvent.RowID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("unable to move event '%s' to the dead-letter table: %w", pendingEvent.EventID, err)
+	}
+	return nil
+}
+
+// DeadLetters returns the events that could not be published by the OutboxRelay.
+func (a *AppendOnlyStore) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	var dbDeadLetters []model.OutboxDeadLetter
+	err := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Order("row_id").Find(&dbDeadLetters).Error
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve dead letters: %w", err)
+	}
+
+	deadLetters := make([]DeadLetter, 0, len(dbDeadLetters))
+	for _, deadLetter := range dbDeadLetters {
+		deadLetters = append(deadLetters, DeadLetter{
+			EventID:        domain.EventID(deadLetter.EventID),
+			Attempts:       int(deadLetter.Attempts),
+			LastError:      deadLetter.LastError,
+			DeadLetteredOn: deadLetter.DeadLetteredOn,
+		})
+	}
+	return deadLetters, nil
+}
+
+// RequeueDeadLetter moves a dead-lettered event back to the outbox, so it is published again by the OutboxRelay.
+func (a *AppendOnlyStore) RequeueDeadLetter(ctx context.Context, eventID domain.EventID) error {
+	return a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("event_id = ?", string(eventID)).Delete(&model.OutboxDeadLetter{})
+		if result.Error != nil {
+			return fmt.Errorf("unable to remove dead letter '%s': %w", eventID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrDeadLetterNotFound
+		}
+
+		err := tx.Omit("row_id").Create(&model.Outbox{EventID: string(eventID)}).Error
+		if err != nil {
+			return fmt.Errorf("unable to requeue dead letter '%s': %w", eventID, err)
+		}
+		return nil
+	})
+}
+
+// ReplaceOutboxWith replaces the outbox with the events still pending in the outbox of the source store,
+// so the events copied from it are not published again by the OutboxRelay.
+// The pending events that were not copied to this store are left out.
+func (a *AppendOnlyStore) ReplaceOutboxWith(ctx context.Context, source *AppendOnlyStore) error {
+	var pendingEventIDs []string
+	err := source.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Model(&model.Outbox{}).Order("row_id").Pluck("event_id", &pendingEventIDs).Error
+	if err != nil {
+		return fmt.Errorf("unable to retrieve the pending events of the source outbox: %w", err)
+	}
+
+	return a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("1 = 1").Delete(&model.Outbox{}).Error
+		if err != nil {
+			return fmt.Errorf("unable to clear the outbox: %w", err)
+		}
+
+		for start := 0; start < len(pendingEventIDs); start += outboxCopyBatchSize {
+			batch := pendingEventIDs[start:min(start+outboxCopyBatchSize, len(pendingEventIDs))]
+			err = tx.Exec("INSERT INTO outbox (event_id) SELECT event_id FROM event WHERE event_id IN ? ORDER BY row_id", batch).Error
+			if err != nil {
+				return fmt.Errorf("unable to copy the pending events to the outbox: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,252 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/serializer"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("OutboxRelay", func() {
+	var (
+		store    *sqlite.AppendOnlyStore
+		eventBus *fakeEventBus
+		relay    *sqlite.OutboxRelay
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		log.SetOutput(GinkgoWriter)
+		store = setupStore()
+		eventBus = &fakeEventBus{}
+		relay = sqlite.NewOutboxRelay(store, persistence.NewDeserializerRegistry(), eventBus).WithRetryBackoff(0).WithMaxAttempts(3)
+
+		acc, err := account.OpenAccount("some-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acc.DepositMoney(10)).To(Succeed())
+		Expect(persistence.NewEventStoreBuilder(store).Build().AppendToStream(ctx, acc)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("publishes the committed events in order", func(ctx context.Context) {
+		relayed, err := relay.RelayPending(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(relayed).To(Equal(2))
+		Expect(eventBus.published()).To(HaveExactElements(
+			BeAssignableToTypeOf(&account.AccountOpened{}),
+			BeAssignableToTypeOf(&account.AmountDeposited{}),
+		))
+	})
+
+	It("publishes every event only once when it succeeds", func(ctx context.Context) {
+		Expect(relay.RelayPending(ctx)).To(Equal(2))
+		Expect(relay.RelayPending(ctx)).To(Equal(0))
+
+		Expect(eventBus.published()).To(HaveLen(2))
+	})
+
+	It("does not write to the outbox if the events are not committed", func(ctx context.Context) {
+		Expect(relay.RelayPending(ctx)).To(Equal(2))
+
+		err := store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "some-account", StreamVersion: 1}, EventID: "conflicting-event", EventName: "AmountDeposited", EventData: []byte("{}"), ContentType: "application/json"})
+		Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+
+		Expect(relay.RelayPending(ctx)).To(Equal(0))
+	})
+
+	It("returns the events relayed before a failure in the middle of the batch", func(ctx context.Context) {
+		relayCtx, cancel := context.WithCancel(ctx)
+		eventBus.onPublished = func(published int) {
+			if published == 2 {
+				cancel()
+			}
+		}
+
+		relayed, err := relay.RelayPending(relayCtx)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(relayed).To(Equal(1))
+
+		// The event whose publication was not recorded is published again.
+		Expect(relay.RelayPending(ctx)).To(Equal(1))
+	})
+
+	It("does not relay the events appended without outbox", func(ctx context.Context) {
+		Expect(relay.RelayPending(ctx)).To(Equal(2))
+
+		acc, err := account.OpenAccount("replicated-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(store.WithoutOutbox()).Build().AppendToStream(ctx, acc)).To(Succeed())
+
+		Expect(relay.RelayPending(ctx)).To(Equal(0))
+		Expect(store.ReadRecords(ctx, "replicated-account")).To(HaveLen(1))
+	})
+
+	When("the event bus fails", func() {
+		BeforeEach(func() {
+			eventBus.failuresLeft = 1
+		})
+
+		It("retries the event until it is published", func(ctx context.Context) {
+			Expect(relay.RelayPending(ctx)).To(Equal(2))
+			Expect(eventBus.published()).To(HaveLen(1))
+
+			Expect(relay.RelayPending(ctx)).To(Equal(1))
+			Expect(eventBus.published()).To(HaveLen(2))
+			Expect(store.DeadLetters(ctx)).To(BeEmpty())
+		})
+	})
+
+	When("the event bus keeps failing", func() {
+		BeforeEach(func() {
+			eventBus.failuresLeft = 100
+		})
+
+		It("moves the events to the dead-letter table after the max attempts", func(ctx context.Context) {
+			for range 3 {
+				Expect(relay.RelayPending(ctx)).To(Equal(2))
+			}
+			Expect(relay.RelayPending(ctx)).To(Equal(0))
+
+			deadLetters, err := store.DeadLetters(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deadLetters).To(HaveLen(2))
+			Expect(deadLetters[0].Attempts).To(Equal(3))
+			Expect(deadLetters[0].LastError).To(Equal("event bus is down"))
+		})
+
+		It("publishes a dead letter again once it is requeued", func(ctx context.Context) {
+			for range 3 {
+				Expect(relay.RelayPending(ctx)).To(Equal(2))
+			}
+			deadLetters, err := store.DeadLetters(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			eventBus.failuresLeft = 0
+
+			Expect(store.RequeueDeadLetter(ctx, deadLetters[0].EventID)).To(Succeed())
+
+			Expect(relay.RelayPending(ctx)).To(Equal(1))
+			Expect(eventBus.published()).To(HaveExactElements(BeAssignableToTypeOf(&account.AccountOpened{})))
+			Expect(store.DeadLetters(ctx)).To(HaveLen(1))
+		})
+	})
+
+	When("the event cannot be deserialized", func() {
+		BeforeEach(func(ctx context.Context) {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "other-stream", StreamVersion: 0}, EventID: "poison-event", EventName: "UnknownEvent", EventData: []byte("{}"), ContentType: "application/json", HappenedOn: time.Now()})).To(Succeed())
+		})
+
+		It("moves it to the dead-letter table without retrying", func(ctx context.Context) {
+			Expect(relay.RelayPending(ctx)).To(Equal(3))
+
+			deadLetters, err := store.DeadLetters(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deadLetters).To(HaveLen(1))
+			Expect(deadLetters[0].EventID).To(Equal(domain.EventID("poison-event")))
+			Expect(deadLetters[0].Attempts).To(Equal(1))
+			Expect(eventBus.published()).To(HaveLen(2))
+		})
+	})
+
+	When("the event has a content type without deserializer", func() {
+		BeforeEach(func(ctx context.Context) {
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{ID: persistence.StreamID{StreamName: "other-stream", StreamVersion: 0}, EventID: "unknown-content-type", EventName: "AccountOpened", EventData: []byte("{}"), ContentType: "application/unknown", HappenedOn: time.Now()})).To(Succeed())
+		})
+
+		It("moves it to the dead-letter table with the content type in the error", func(ctx context.Context) {
+			Expect(relay.RelayPending(ctx)).To(Equal(3))
+
+			deadLetters, err := store.DeadLetters(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deadLetters).To(HaveLen(1))
+			Expect(deadLetters[0].LastError).To(ContainSubstring(persistence.ErrUnknownContentType.Error()))
+			Expect(deadLetters[0].LastError).To(ContainSubstring("application/unknown"))
+		})
+	})
+
+	It("publishes the events stored with other serializers", func(ctx context.Context) {
+		acc, err := account.OpenAccount("other-account")
+		Expect(err).ToNot(HaveOccurred())
+		msgpackEventStore := persistence.NewEventStoreBuilder(store).WithSerializer(&serializer.Msgpack{}).Build()
+		Expect(msgpackEventStore.AppendToStream(ctx, acc)).To(Succeed())
+
+		Expect(relay.RelayPending(ctx)).To(Equal(3))
+		Expect(eventBus.published()).To(HaveExactElements(
+			BeAssignableToTypeOf(&account.AccountOpened{}),
+			BeAssignableToTypeOf(&account.AmountDeposited{}),
+			BeAssignableToTypeOf(&account.AccountOpened{}),
+		))
+	})
+
+	It("replaces the outbox with the events pending in another store", func(ctx context.Context) {
+		Expect(relay.RelayPending(ctx)).To(Equal(2))
+		acc, err := account.OpenAccount("other-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistence.NewEventStoreBuilder(store).Build().AppendToStream(ctx, acc)).To(Succeed())
+
+		copyStore := setupStore()
+		defer copyStore.Close()
+		records, err := store.ReadAllRecords(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(copyStore.Append(ctx, records...)).To(Succeed())
+		Expect(copyStore.ReplaceOutboxWith(ctx, store)).To(Succeed())
+
+		copyEventBus := &fakeEventBus{}
+		Expect(sqlite.NewOutboxRelay(copyStore, persistence.NewDeserializerRegistry(), copyEventBus).RelayPending(ctx)).To(Equal(1))
+		Expect(copyEventBus.published()).To(HaveExactElements(HaveField("AccountID", "other-account")))
+	})
+
+	It("relays the events until the context is cancelled", func(ctx context.Context) {
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go relay.WithPollInterval(10 * time.Millisecond).Run(runCtx)
+
+		Eventually(eventBus.published).Should(HaveLen(2))
+	})
+})
+
+type fakeEventBus struct {
+	events       []domain.Event
+	failuresLeft int
+	// onPublished is called with the number of events published so far after every successful publish.
+	onPublished func(published int)
+	mutex       sync.Mutex
+}
+
+func (f *fakeEventBus) Publish(_ context.Context, events ...domain.Event) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.failuresLeft > 0 {
+		f.failuresLeft--
+		return errors.New("event bus is down")
+	}
+	f.events = append(f.events, events...)
+	if f.onPublished != nil {
+		f.onPublished(len(f.events))
+	}
+	return nil
+}
+
+func (f *fakeEventBus) Subscribe(_ context.Context, _ domain.EventListener) error {
+	return nil
+}
+
+func (f *fakeEventBus) published() []domain.Event {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]domain.Event(nil), f.events...)
+}
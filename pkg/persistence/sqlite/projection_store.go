@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite/internal/model"
+)
+
+// ProjectionStore saves the projection checkpoints and snapshots in the same database as the events.
+type ProjectionStore struct {
+	db *gorm.DB
+}
+
+func NewProjectionStore(store *AppendOnlyStore) *ProjectionStore {
+	return &ProjectionStore{db: store.db.Session(&gorm.Session{NewDB: true})}
+}
+
+func (p *ProjectionStore) LoadCheckpoint(ctx context.Context, projectionName string) (persistence.ProjectionCheckpoint, error) {
+	var dbCheckpoint model.ProjectionCheckpoint
+	err := p.db.WithContext(ctx).Where("name = ?", projectionName).Take(&dbCheckpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return persistence.ProjectionCheckpoint{}, persistence.ErrProjectionCheckpointNotFound
+	}
+	if err != nil {
+		return persistence.ProjectionCheckpoint{}, fmt.Errorf("unable to retrieve checkpoint for projection '%s': %w", projectionName, err)
+	}
+
+	version, err := strconv.ParseUint(dbCheckpoint.Version, 10, 64)
+	if err != nil {
+		return persistence.ProjectionCheckpoint{}, fmt.Errorf("error parsing projection version '%s' to uint64: %w", dbCheckpoint.Version, err)
+	}
+
+	return persistence.ProjectionCheckpoint{
+		Name:        dbCheckpoint.Name,
+		Version:     version,
+		LastEventID: domain.EventID(dbCheckpoint.LastEventID),
+		UpdatedOn:   dbCheckpoint.UpdatedOn,
+	}, nil
+}
+
+func (p *ProjectionStore) SaveCheckpoint(ctx context.Context, checkpoint persistence.ProjectionCheckpoint) error {
+	err := p.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.ProjectionCheckpoint{
+		Name:        checkpoint.Name,
+		Version:     strconv.FormatUint(checkpoint.Version, 10),
+		LastEventID: string(checkpoint.LastEventID),
+		UpdatedOn:   checkpoint.UpdatedOn,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("unable to save checkpoint for projection '%s': %w", checkpoint.Name, err)
+	}
+	return nil
+}
+
+func (p *ProjectionStore) LoadSnapshot(ctx context.Context, projectionName string) (persistence.ProjectionSnapshot, error) {
+	var dbSnapshot model.ProjectionSnapshot
+	err := p.db.WithContext(ctx).Where("name = ?", projectionName).Take(&dbSnapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return persistence.ProjectionSnapshot{}, persistence.ErrProjectionSnapshotNotFound
+	}
+	if err != nil {
+		return persistence.ProjectionSnapshot{}, fmt.Errorf("unable to retrieve snapshot for projection '%s': %w", projectionName, err)
+	}
+
+	version, err := strconv.ParseUint(dbSnapshot.Version, 10, 64)
+	if err != nil {
+		return persistence.ProjectionSnapshot{}, fmt.Errorf("error parsing projection version '%s' to uint64: %w", dbSnapshot.Version, err)
+	}
+
+	return persistence.ProjectionSnapshot{
+		Name:        dbSnapshot.Name,
+		Version:     version,
+		LastEventID: domain.EventID(dbSnapshot.LastEventID),
+		State:       dbSnapshot.State,
+		TakenOn:     dbSnapshot.TakenOn,
+	}, nil
+}
+
+func (p *ProjectionStore) SaveSnapshot(ctx context.Context, snapshot persistence.ProjectionSnapshot) error {
+	err := p.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.ProjectionSnapshot{
+		Name:        snapshot.Name,
+		Version:     strconv.FormatUint(snapshot.Version, 10),
+		LastEventID: string(snapshot.LastEventID),
+		State:       snapshot.State,
+		TakenOn:     snapshot.TakenOn,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("unable to save snapshot for projection '%s': %w", snapshot.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("Sqlite ProjectionStore", func() {
+	var (
+		store           *sqlite.AppendOnlyStore
+		projectionStore *sqlite.ProjectionStore
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(GinkgoWriter)
+		store = setupStore()
+		projectionStore = sqlite.NewProjectionStore(store)
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("returns an error if the projection has never been saved", func(ctx context.Context) {
+		_, err := projectionStore.LoadCheckpoint(ctx, "some-projection")
+		Expect(err).To(MatchError(persistence.ErrProjectionCheckpointNotFound))
+
+		_, err = projectionStore.LoadSnapshot(ctx, "some-projection")
+		Expect(err).To(MatchError(persistence.ErrProjectionSnapshotNotFound))
+	})
+
+	It("saves and loads a checkpoint", func(ctx context.Context) {
+		checkpoint := persistence.ProjectionCheckpoint{Name: "some-projection", Version: 3, LastEventID: "event3", UpdatedOn: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+
+		Expect(projectionStore.SaveCheckpoint(ctx, checkpoint)).To(Succeed())
+
+		Expect(projectionStore.LoadCheckpoint(ctx, "some-projection")).To(Equal(checkpoint))
+	})
+
+	It("overwrites the previous checkpoint of the same projection", func(ctx context.Context) {
+		Expect(projectionStore.SaveCheckpoint(ctx, persistence.ProjectionCheckpoint{Name: "some-projection", Version: 1, LastEventID: "event1"})).To(Succeed())
+		Expect(projectionStore.SaveCheckpoint(ctx, persistence.ProjectionCheckpoint{Name: "other-projection", Version: 1, LastEventID: "event1"})).To(Succeed())
+		Expect(projectionStore.SaveCheckpoint(ctx, persistence.ProjectionCheckpoint{Name: "some-projection", Version: 2, LastEventID: "event2"})).To(Succeed())
+
+		checkpoint, err := projectionStore.LoadCheckpoint(ctx, "some-projection")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.Version).To(Equal(uint64(2)))
+		Expect(checkpoint.LastEventID).To(BeEquivalentTo("event2"))
+
+		Expect(projectionStore.LoadCheckpoint(ctx, "other-projection")).To(HaveField("LastEventID", BeEquivalentTo("event1")))
+	})
+
+	It("saves and loads a snapshot", func(ctx context.Context) {
+		snapshot := persistence.ProjectionSnapshot{Name: "some-projection", Version: 3, LastEventID: "event3", State: []byte("state"), TakenOn: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+
+		Expect(projectionStore.SaveSnapshot(ctx, snapshot)).To(Succeed())
+
+		Expect(projectionStore.LoadSnapshot(ctx, "some-projection")).To(Equal(snapshot))
+	})
+
+	It("overwrites the previous snapshot of the same projection", func(ctx context.Context) {
+		Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{Name: "some-projection", Version: 1, LastEventID: "event1", State: []byte("old-state")})).To(Succeed())
+		Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{Name: "other-projection", Version: 1, LastEventID: "event1", State: []byte("other-state")})).To(Succeed())
+		Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{Name: "some-projection", Version: 2, LastEventID: "event2", State: []byte("new-state")})).To(Succeed())
+
+		snapshot, err := projectionStore.LoadSnapshot(ctx, "some-projection")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot.Version).To(Equal(uint64(2)))
+		Expect(snapshot.LastEventID).To(BeEquivalentTo("event2"))
+		Expect(snapshot.State).To(Equal([]byte("new-state")))
+
+		Expect(projectionStore.LoadSnapshot(ctx, "other-projection")).To(HaveField("State", []byte("other-state")))
+	})
+})
+
+var _ = Describe("Sqlite ProjectionStore migrations", func() {
+	It("moves the state of the checkpoints saved before the snapshots to the snapshots", func(ctx context.Context) {
+		databaseFile := filepath.Join(GinkgoT().TempDir(), "events.sqlite")
+		source, err := iofs.New(os.DirFS("internal/migrations"), ".")
+		Expect(err).ToNot(HaveOccurred())
+		migrations, err := migrate.NewWithSourceInstance("iofs", source, "sqlite3://"+databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations.Migrate(7)).To(Succeed())
+		db, err := sql.Open("sqlite3", databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.ExecContext(ctx, "INSERT INTO projection_checkpoint (name, version, last_event_id, state, updated_on) VALUES (?, ?, ?, ?, ?)",
+			"some-projection", 2, "event2", []byte("state"), time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))).Error().ToNot(HaveOccurred())
+		Expect(db.Close()).To(Succeed())
+		Expect(migrations.Close()).To(Succeed())
+
+		store, err := sqlite.New("file:" + databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+		defer store.Close()
+		Expect(store.MigrateDB()).To(Succeed())
+
+		projectionStore := sqlite.NewProjectionStore(store)
+		Expect(projectionStore.LoadSnapshot(ctx, "some-projection")).To(Equal(persistence.ProjectionSnapshot{
+			Name:        "some-projection",
+			Version:     2,
+			LastEventID: "event2",
+			State:       []byte("state"),
+			TakenOn:     time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		}))
+		_, err = projectionStore.LoadCheckpoint(ctx, "some-projection")
+		Expect(err).To(MatchError(persistence.ErrProjectionCheckpointNotFound))
+	})
+})
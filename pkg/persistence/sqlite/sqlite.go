@@ -25,15 +25,23 @@ const (
 )
 
 type AppendOnlyStore struct {
-	db *gorm.DB
+	db            *gorm.DB
+	writer        *writer
+	withoutOutbox bool
 }
 
 func (a *AppendOnlyStore) AfterEventID(eventID domain.EventID) persistence.ReadOnlyStore {
-	return &AppendOnlyStore{db: a.db.Where("row_id > (select row_id from event where event_id = ?)", eventID)}
+	return &AppendOnlyStore{db: a.db.Where("row_id > (select row_id from event where event_id = ?)", eventID), writer: a.writer, withoutOutbox: a.withoutOutbox}
 }
 
 func (a *AppendOnlyStore) Limit(limit int) persistence.ReadOnlyStore {
-	return &AppendOnlyStore{db: a.db.Limit(limit)}
+	return &AppendOnlyStore{db: a.db.Limit(limit), writer: a.writer, withoutOutbox: a.withoutOutbox}
+}
+
+// WithoutOutbox returns the store appending the events without outbox entries, so they are never relayed to the event bus.
+// It is meant for the events replicated from a leader, which already published them.
+func (a *AppendOnlyStore) WithoutOutbox() *AppendOnlyStore {
+	return &AppendOnlyStore{db: a.db, writer: a.writer, withoutOutbox: true}
 }
 
 func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.StoredStreamEvent) error {
@@ -42,8 +50,9 @@ func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.Stor
 	}
 
 	eventsToInsert := make([]model.Event, 0, len(events))
-	for _, event := range events {
+	for _, event := range persistence.WithTenantOf(ctx, events) {
 		eventsToInsert = append(eventsToInsert, model.Event{
+			TenantID:      string(event.TenantID),
 			StreamName:    event.ID.StreamName,
 			StreamVersion: strconv.FormatUint(event.ID.StreamVersion, 10),
 			EventName:     event.EventName,
@@ -54,9 +63,8 @@ func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.Stor
 		})
 	}
 
-	err := a.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Omit("row_id").CreateInBatches(eventsToInsert, 1000).Error
-	})
+	// The appends are serialized by the writer, which may commit them together with other appends.
+	err := a.writer.append(ctx, eventsToInsert, !a.withoutOutbox)
 	if isErrorUniqueConstraintViolation(err) {
 		return persistence.ErrUnexpectedVersion
 	}
@@ -66,6 +74,29 @@ func (a *AppendOnlyStore) Append(ctx context.Context, events ...persistence.Stor
 	return nil
 }
 
+// insertEvents inserts the events in the transaction with their outbox entries, if withOutbox is set.
+// The outbox is written in the same transaction as the events, so every committed event
+// is eventually published by the OutboxRelay, and no event is published without being committed.
+func insertEvents(ctx context.Context, tx *gorm.DB, events []model.Event, withOutbox bool) error {
+	err := tx.WithContext(ctx).Omit("row_id").CreateInBatches(events, 1000).Error
+	if err != nil {
+		return err
+	}
+	err = chainEvents(ctx, tx, events)
+	if err != nil {
+		return err
+	}
+	if !withOutbox {
+		return nil
+	}
+
+	outboxToInsert := make([]model.Outbox, 0, len(events))
+	for _, event := range events {
+		outboxToInsert = append(outboxToInsert, model.Outbox{EventID: event.EventID})
+	}
+	return tx.WithContext(ctx).Omit("row_id").CreateInBatches(outboxToInsert, 1000).Error
+}
+
 func isErrorUniqueConstraintViolation(err error) bool {
 	var sqliteError sqlite3.Error
 	return errors.As(err, &sqliteError) &&
@@ -74,11 +105,30 @@ func isErrorUniqueConstraintViolation(err error) bool {
 }
 
 func (a *AppendOnlyStore) ReadAllRecords(ctx context.Context) ([]persistence.StoredStreamEvent, error) {
-	return readRecodsWithQuery(ctx, a.db.WithContext(ctx))
+	return readRecodsWithQuery(ctx, scopedToTenant(ctx, a.db.WithContext(ctx)))
+}
+
+// CountRecords counts the events in a subquery, so the limit of the store is applied before counting them.
+func (a *AppendOnlyStore) CountRecords(ctx context.Context) (int, error) {
+	var count int
+	filtered := scopedToTenant(ctx, a.db.WithContext(ctx)).Model(&model.Event{}).Select("row_id")
+	err := a.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Raw("SELECT count(*) FROM (?) AS filtered", filtered).Scan(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("unable to count the records: %w", err)
+	}
+	return count, nil
 }
 
 func (a *AppendOnlyStore) ReadRecords(ctx context.Context, streamName string) ([]persistence.StoredStreamEvent, error) {
-	return readRecodsWithQuery(ctx, a.db.WithContext(ctx).Where("stream_name = ?", streamName))
+	return readRecodsWithQuery(ctx, scopedToTenant(ctx, a.db.WithContext(ctx)).Where("stream_name = ?", streamName))
+}
+
+// scopedToTenant restricts the query to the events of the tenant of the context, unless it is for all the tenants.
+func scopedToTenant(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tenant, scoped := persistence.TenantFilter(ctx); scoped {
+		return db.Where("tenant_id = ?", tenant)
+	}
+	return db
 }
 
 func readRecodsWithQuery(ctx context.Context, db *gorm.DB) ([]persistence.StoredStreamEvent, error) {
@@ -115,11 +165,29 @@ func modelEventToPersistence(dbEvent model.Event) (persistence.StoredStreamEvent
 		EventData:   dbEvent.EventData,
 		HappenedOn:  dbEvent.HappenedOn,
 		ContentType: dbEvent.ContentType,
+		TenantID:    domain.TenantID(dbEvent.TenantID),
 	}, nil
 }
 
+// New opens the sqlite database with the DefaultConfig.
 func New(connectionString string) (*AppendOnlyStore, error) {
-	db, err := gorm.Open(sqlite.Open(connectionString), &gorm.Config{
+	return NewWithConfig(connectionString, DefaultConfig())
+}
+
+func NewWithConfig(connectionString string, config Config) (*AppendOnlyStore, error) {
+	db, err := openDB(connectionString, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppendOnlyStore{
+		db:     db,
+		writer: newWriter(db, config.MaxGroupCommit),
+	}, nil
+}
+
+func openDB(connectionString string, config Config) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(config.connectionString(connectionString)), &gorm.Config{
 		Logger: logger.New(log.Default(), logger.Config{
 			Colorful:                  false,
 			IgnoreRecordNotFoundError: true,
@@ -130,10 +198,12 @@ func New(connectionString string) (*AppendOnlyStore, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to open sqlite database connection: %w", err)
 	}
-
-	return &AppendOnlyStore{
-		db: db,
-	}, nil
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve database connection: %w", err)
+	}
+	config.configurePool(sqlDB)
+	return db, nil
 }
 
 func InMemory() *AppendOnlyStore {
@@ -142,6 +212,13 @@ func InMemory() *AppendOnlyStore {
 		panic(fmt.Errorf("this should not have happened: %w", err))
 	}
 
+	// Every connection to ":memory:" opens a different database, so all the queries must share the same one.
+	sqlDB, err := db.db.DB()
+	if err != nil {
+		panic(fmt.Errorf("this should not have happened: %w", err))
+	}
+	sqlDB.SetMaxOpenConns(1)
+
 	err = db.MigrateDB()
 	if err != nil {
 		panic(fmt.Errorf("this should not have happened: %w", err))
@@ -151,6 +228,7 @@ func InMemory() *AppendOnlyStore {
 }
 
 func (a *AppendOnlyStore) Close() error {
+	a.writer.close()
 	db, err := a.db.DB()
 	if err != nil {
 		return fmt.Errorf("unable to retrieve database connection: %w", err)
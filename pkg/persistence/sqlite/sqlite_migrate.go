@@ -8,23 +8,35 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
 )
 
 //go:embed internal/migrations
 var migrations embed.FS
 
+//go:embed internal/key_migrations
+var keyMigrations embed.FS
+
+// keyMigrationsTable keeps the version of the schema of the key database apart from the one of the events,
+// since the older key databases were created with the migrations of the events.
+const keyMigrationsTable = "key_schema_migrations"
+
 func (a *AppendOnlyStore) MigrateDB() (err error) {
-	db, err := a.db.DB()
+	return migrateDB(a.db, migrations, "internal/migrations", sqlite3.DefaultMigrationsTable)
+}
+
+func migrateDB(gormDB *gorm.DB, migrations embed.FS, directory string, migrationsTable string) error {
+	db, err := gormDB.DB()
 	if err != nil {
 		return fmt.Errorf("unable to retrieve database connection: %w", err)
 	}
 
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{MigrationsTable: migrationsTable})
 	if err != nil {
 		return fmt.Errorf("unable to create migration driver: %w", err)
 	}
 
-	fs, err := iofs.New(migrations, "internal/migrations")
+	fs, err := iofs.New(migrations, directory)
 	if err != nil {
 		return fmt.Errorf("unable to create migration fs: %w", err)
 	}
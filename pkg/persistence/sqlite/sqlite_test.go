@@ -9,8 +9,17 @@ import (
 
 	"github.com/tembleking/myBankSourcing/pkg/persistence"
 	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/test/conformance"
 )
 
+var _ = Describe("Sqlite AppendOnlyStore conformance", func() {
+	conformance.AppendOnlyStore(func(context.Context) persistence.AppendOnlyStore {
+		store := setupStore()
+		DeferCleanup(store.Close)
+		return store
+	})
+})
+
 var _ = Describe("Sqlite AppendOnlyStore", func() {
 	var (
 		ctx   context.Context
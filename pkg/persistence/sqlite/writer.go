@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite/internal/model"
+)
+
+// appendSavepoint isolates every append of a group commit, so a failing one does not roll back the others.
+const appendSavepoint = "append"
+
+var errStoreClosed = errors.New("the store is closed")
+
+type appendRequest struct {
+	ctx        context.Context
+	events     []model.Event
+	withOutbox bool
+	err        chan error
+}
+
+// writer serializes the appends in a single goroutine, so they never compete for the lock of the database,
+// and commits together the appends queued while the previous commit was running.
+// Every append waits for its own commit, so a group commit saves fsyncs under load without delaying a lone append.
+type writer struct {
+	db             *gorm.DB
+	maxGroupCommit int
+
+	requests  chan *appendRequest
+	closed    chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+func newWriter(db *gorm.DB, maxGroupCommit int) *writer {
+	w := &writer{
+		db:             db,
+		maxGroupCommit: max(maxGroupCommit, 1),
+		requests:       make(chan *appendRequest),
+		closed:         make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// append inserts the events, returning the error of their own append even if they were committed with others.
+func (w *writer) append(ctx context.Context, events []model.Event, withOutbox bool) error {
+	request := &appendRequest{ctx: ctx, events: events, withOutbox: withOutbox, err: make(chan error, 1)}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-w.closed:
+		return errStoreClosed
+	case w.requests <- request:
+	}
+
+	// Once the writer has taken the request, the events may be committed, so we wait for the outcome.
+	return <-request.err
+}
+
+func (w *writer) run() {
+	defer close(w.stopped)
+
+	for {
+		select {
+		case <-w.closed:
+			return
+		case request := <-w.requests:
+			w.commit(w.groupWith(request))
+		}
+	}
+}
+
+// groupWith returns the request with the ones already waiting to be taken, up to the maxGroupCommit.
+func (w *writer) groupWith(request *appendRequest) []*appendRequest {
+	group := []*appendRequest{request}
+	for len(group) < w.maxGroupCommit {
+		select {
+		case request := <-w.requests:
+			group = append(group, request)
+		default:
+			return group
+		}
+	}
+	return group
+}
+
+func (w *writer) commit(group []*appendRequest) {
+	errs := make([]error, len(group))
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		for i, request := range group {
+			if err := request.ctx.Err(); err != nil {
+				errs[i] = err
+				continue
+			}
+			if err := tx.SavePoint(appendSavepoint).Error; err != nil {
+				return err
+			}
+			if err := insertEvents(request.ctx, tx, request.events, request.withOutbox); err != nil {
+				errs[i] = err
+				if err := tx.RollbackTo(appendSavepoint).Error; err != nil {
+					return err
+				}
+			}
+			// Rolling back to the savepoint keeps it, so it is released either way, not to stack one per append.
+			if err := tx.Exec("RELEASE SAVEPOINT " + appendSavepoint).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for i, request := range group {
+		if err != nil {
+			errs[i] = err
+		}
+		request.err <- errs[i]
+	}
+}
+
+// close stops the writer once the commit in progress, if any, is done.
+func (w *writer) close() {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+	})
+	<-w.stopped
+}
@@ -0,0 +1,55 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+// BenchmarkAppend measures the throughput of concurrent appends to a database file,
+// committing every append on its own and in group commits, which save the most when every commit waits for the disk.
+// Every writer appends a few events to a stream before moving to a new one, like the aggregates do.
+//
+//	go test ./pkg/persistence/sqlite -run '^$' -bench Append -cpu 1,8,32
+func BenchmarkAppend(b *testing.B) {
+	const eventsPerStream = 10
+
+	for _, synchronous := range []sqlite.SynchronousLevel{sqlite.SynchronousNormal, sqlite.SynchronousFull} {
+		for _, maxGroupCommit := range []int{1, sqlite.DefaultConfig().MaxGroupCommit} {
+			b.Run(fmt.Sprintf("synchronous-%s/max-group-commit-%d", synchronous, maxGroupCommit), func(b *testing.B) {
+				config := sqlite.DefaultConfig()
+				config.Synchronous = synchronous
+				config.MaxGroupCommit = maxGroupCommit
+				store, err := sqlite.NewWithConfig("file:"+filepath.Join(b.TempDir(), "events.sqlite"), config)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer store.Close()
+				if err := store.MigrateDB(); err != nil {
+					b.Fatal(err)
+				}
+
+				ctx := context.Background()
+				var streams atomic.Int64
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					var streamName string
+					for appended := uint64(0); pb.Next(); appended++ {
+						if appended%eventsPerStream == 0 {
+							streamName = fmt.Sprintf("aggregate-%d", streams.Add(1))
+						}
+						if err := store.Append(ctx, storedEvent(streamName, appended%eventsPerStream)); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+				b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "appends/s")
+			})
+		}
+	}
+}
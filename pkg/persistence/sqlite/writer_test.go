@@ -0,0 +1,115 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+)
+
+var _ = Describe("Sqlite concurrent writers", func() {
+	var (
+		databaseFile string
+		store        *sqlite.AppendOnlyStore
+	)
+
+	BeforeEach(func() {
+		databaseFile = filepath.Join(GinkgoT().TempDir(), "events.sqlite")
+		var err error
+		store, err = sqlite.NewWithConfig("file:"+databaseFile, sqlite.Config{
+			JournalMode:    sqlite.JournalModeWAL,
+			BusyTimeout:    time.Second,
+			Synchronous:    sqlite.SynchronousNormal,
+			CacheSizeKiB:   4096,
+			MaxOpenConns:   4,
+			MaxGroupCommit: 50,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.MigrateDB()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		store.Close()
+	})
+
+	It("opens the database in the configured journal mode", func(ctx context.Context) {
+		db, err := sql.Open("sqlite3", databaseFile)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		var journalMode string
+		Expect(db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode)).To(Succeed())
+		Expect(journalMode).To(Equal("wal"))
+	})
+
+	It("commits every concurrent append once", func(ctx context.Context) {
+		const writers = 20
+		wg := sync.WaitGroup{}
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for version := range 10 {
+					Expect(store.Append(ctx, storedEvent(fmt.Sprintf("aggregate-%d", i), uint64(version)))).To(Succeed())
+				}
+			}()
+		}
+		wg.Wait()
+
+		Expect(store.ReadAllRecords(ctx)).To(HaveLen(writers * 10))
+		Expect(store.VerifyChain(ctx)).To(Equal(persistence.ChainVerification{VerifiedEvents: writers * 10}))
+	})
+
+	It("only rejects the conflicting appends of a group commit", func(ctx context.Context) {
+		const writers = 20
+		errs := make(chan error, writers)
+		wg := sync.WaitGroup{}
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Every pair of writers appends the same version of the same stream.
+				errs <- store.Append(ctx, storedEvent(fmt.Sprintf("aggregate-%d", i/2), 0))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		failed := 0
+		for err := range errs {
+			if err != nil {
+				Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+				failed++
+			}
+		}
+		Expect(failed).To(Equal(writers / 2))
+		Expect(store.ReadAllRecords(ctx)).To(HaveLen(writers / 2))
+	})
+
+	It("rejects the appends once it is closed", func(ctx context.Context) {
+		Expect(store.Close()).To(Succeed())
+
+		Expect(store.Append(ctx, storedEvent("aggregate-0", 0))).ToNot(Succeed())
+	})
+})
+
+func storedEvent(streamName string, version uint64) persistence.StoredStreamEvent {
+	return persistence.StoredStreamEvent{
+		ID:          persistence.StreamID{StreamName: streamName, StreamVersion: version},
+		EventID:     domain.NewEventID(),
+		EventName:   "eventName",
+		EventData:   []byte("data"),
+		HappenedOn:  time.Now(),
+		ContentType: "some-content-type",
+	}
+}
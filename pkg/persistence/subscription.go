@@ -0,0 +1,165 @@
+package persistence
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+const (
+	defaultSubscriptionPollInterval = time.Second
+	subscriptionBatchSize           = 100
+)
+
+// Subscription delivers the events of a ReadOnlyEventStore in order.
+// It first catches up on the stored history and then follows new appends as soon as they are committed.
+// Events are pulled from the store in batches only when the subscriber is ready to receive them,
+// so a slow subscriber never blocks writers, it only falls behind and catches up later.
+// The events are delivered with their tenant, so a subscription with a context for all the tenants can tell them apart.
+type Subscription struct {
+	events chan TenantEvent
+	err    error
+}
+
+// Events returns the channel the events are delivered on.
+// The channel is closed when the subscription ends, after which Err reports the reason.
+func (s *Subscription) Events() <-chan TenantEvent {
+	return s.events
+}
+
+// Err returns the reason the subscription ended. It must only be called once the Events channel is closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Subscribe starts a subscription that delivers every event stored after the given eventID.
+// An empty eventID subscribes from the beginning of the store.
+// The subscription ends when the context is cancelled or when an event cannot be deserialized.
+func (e *ReadOnlyEventStore) Subscribe(ctx context.Context, afterEventID domain.EventID) *Subscription {
+	subscription := &Subscription{events: make(chan TenantEvent)}
+	go subscription.run(ctx, e, afterEventID)
+	return subscription
+}
+
+func (s *Subscription) run(ctx context.Context, store *ReadOnlyEventStore, lastEventID domain.EventID) {
+	defer close(s.events)
+
+	// We register before reading so no append committed during the catch-up is missed.
+	appended, unregister := store.notifier.register()
+	defer unregister()
+
+	// Appends made by other processes are only notified if the store is an AppendListener,
+	// and even then the notifications may be lost if its connection drops, so we also poll.
+	ticker := time.NewTicker(store.pollInterval)
+	defer ticker.Stop()
+
+	remoteAppended := make(chan struct{}, 1)
+	if listener, ok := store.readOnlyStore.(AppendListener); ok {
+		go listenAppends(ctx, listener, remoteAppended)
+	}
+
+	for {
+		caughtUp, err := s.deliverNextBatch(ctx, store, &lastEventID)
+		if err != nil {
+			s.err = err
+			return
+		}
+		if !caughtUp {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			s.err = ctx.Err()
+			return
+		case <-appended:
+		case <-remoteAppended:
+		case <-ticker.C:
+		}
+	}
+}
+
+func listenAppends(ctx context.Context, listener AppendListener, appended chan<- struct{}) {
+	err := listener.ListenAppends(ctx, func() {
+		select {
+		case appended <- struct{}{}:
+		default:
+			// There is already a pending notification.
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		slog.Default().ErrorContext(ctx, "stopped listening to the appends of the store, falling back to polling", "error", err.Error())
+	}
+}
+
+func (s *Subscription) deliverNextBatch(ctx context.Context, store *ReadOnlyEventStore, lastEventID *domain.EventID) (caughtUp bool, err error) {
+	readOnlyStore := store.readOnlyStore
+	if *lastEventID != "" {
+		readOnlyStore = readOnlyStore.AfterEventID(*lastEventID)
+	}
+
+	records, err := readOnlyStore.Limit(subscriptionBatchSize).ReadAllRecords(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Default().ErrorContext(ctx, "error reading records for subscription, retrying", "error", err.Error())
+		}
+		return true, nil
+	}
+
+	for _, record := range records {
+		event, err := store.deserializeRecord(record)
+		if err != nil {
+			return false, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case s.events <- TenantEvent{Tenant: record.TenantID, Event: event}:
+		}
+		*lastEventID = record.EventID
+	}
+
+	return len(records) < subscriptionBatchSize, nil
+}
+
+// appendNotifier wakes up the subscriptions when new events are committed.
+// Notifications are coalesced, so a notify never blocks on a subscription that is busy.
+type appendNotifier struct {
+	listeners map[chan struct{}]struct{}
+	mutex     sync.Mutex
+}
+
+func newAppendNotifier() *appendNotifier {
+	return &appendNotifier{listeners: map[chan struct{}]struct{}{}}
+}
+
+func (n *appendNotifier) register() (<-chan struct{}, func()) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	listener := make(chan struct{}, 1)
+	n.listeners[listener] = struct{}{}
+
+	return listener, func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		delete(n.listeners, listener)
+	}
+}
+
+func (n *appendNotifier) notify() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for listener := range n.listeners {
+		select {
+		case listener <- struct{}{}:
+		default:
+			// There is already a pending notification for this listener.
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+)
+
+var _ = Describe("Subscription", func() {
+	var eventStore *persistence.EventStore
+
+	BeforeEach(func() {
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).
+			WithSubscriptionPollInterval(time.Hour).
+			Build()
+	})
+
+	It("catches up on the stored history", func(ctx context.Context) {
+		openAccountWithDeposits(ctx, eventStore, 2)
+
+		subscription := eventStore.Subscribe(ctx, "")
+
+		Eventually(subscription.Events()).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AccountOpened{}))))
+		Eventually(subscription.Events()).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AmountDeposited{}))))
+		Eventually(subscription.Events()).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AmountDeposited{}))))
+		Consistently(subscription.Events(), 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("starts after the given event", func(ctx context.Context) {
+		acc := openAccountWithDeposits(ctx, eventStore, 1)
+
+		subscription := eventStore.Subscribe(ctx, acc.UncommittedEvents()[0].EventID())
+
+		var event persistence.TenantEvent
+		Eventually(subscription.Events()).Should(Receive(&event))
+		Expect(event.Event.EventID()).To(Equal(acc.UncommittedEvents()[1].EventID()))
+		Consistently(subscription.Events(), 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("delivers the events appended after catching up without waiting for the poll interval", func(ctx context.Context) {
+		openAccountWithDeposits(ctx, eventStore, 0)
+		subscription := eventStore.Subscribe(ctx, "")
+		Eventually(subscription.Events()).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AccountOpened{}))))
+
+		openAccountWithDeposits(ctx, eventStore, 0)
+
+		Eventually(subscription.Events()).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AccountOpened{}))))
+	})
+
+	It("does not block writers when the subscriber is slower than them", func(ctx context.Context) {
+		subscription := eventStore.Subscribe(ctx, "")
+
+		for range 3 {
+			openAccountWithDeposits(ctx, eventStore, 100)
+		}
+
+		for range 3 * 101 {
+			Eventually(subscription.Events()).Should(Receive())
+		}
+		Consistently(subscription.Events(), 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	When("the store notifies the appends of other processes", func() {
+		var (
+			store             *listeningStore
+			otherProcessStore *persistence.EventStore
+		)
+
+		BeforeEach(func() {
+			memoryStore := inmemory.NewAppendOnlyStore()
+			store = &listeningStore{AppendOnlyStore: memoryStore, notifications: make(chan struct{})}
+			eventStore = persistence.NewEventStoreBuilder(store).WithSubscriptionPollInterval(time.Hour).Build()
+			otherProcessStore = persistence.NewEventStoreBuilder(memoryStore).Build()
+		})
+
+		It("delivers the events without waiting for the poll interval", func(ctx context.Context) {
+			openAccountWithDeposits(ctx, otherProcessStore, 0)
+			subscription := eventStore.Subscribe(ctx, "")
+			Eventually(subscription.Events()).Should(Receive())
+
+			openAccountWithDeposits(ctx, otherProcessStore, 0)
+			Consistently(subscription.Events(), 100*time.Millisecond).ShouldNot(Receive())
+
+			store.notifications <- struct{}{}
+
+			Eventually(subscription.Events()).Should(Receive(HaveField("Event", BeAssignableToTypeOf(&account.AccountOpened{}))))
+		})
+	})
+
+	It("ends the subscription when the context is cancelled", func(ctx context.Context) {
+		subscriptionCtx, cancel := context.WithCancel(ctx)
+		subscription := eventStore.Subscribe(subscriptionCtx, "")
+
+		cancel()
+
+		Eventually(subscription.Events()).Should(BeClosed())
+		Expect(subscription.Err()).To(MatchError(context.Canceled))
+	})
+})
+
+func openAccountWithDeposits(ctx context.Context, eventStore *persistence.EventStore, deposits int) *account.Account {
+	acc, err := account.OpenAccount(domain.NewUUID())
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	for range deposits {
+		ExpectWithOffset(1, acc.DepositMoney(10)).To(Succeed())
+	}
+	ExpectWithOffset(1, eventStore.AppendToStream(ctx, acc)).To(Succeed())
+	return acc
+}
+
+// listeningStore is a persistence.AppendListener that notifies an append every time it receives from notifications.
+type listeningStore struct {
+	*inmemory.AppendOnlyStore
+	notifications chan struct{}
+}
+
+func (l *listeningStore) ListenAppends(ctx context.Context, notify func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.notifications:
+			notify()
+		}
+	}
+}
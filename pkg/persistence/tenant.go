@@ -0,0 +1,32 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// TenantFilter returns the tenant the reads with the context must be restricted to,
+// and false if the context can read every tenant.
+func TenantFilter(ctx context.Context) (domain.TenantID, bool) {
+	if domain.IsAllTenants(ctx) {
+		return "", false
+	}
+	return domain.TenantFromContext(ctx), true
+}
+
+// WithTenantOf returns the events with the tenant they must be appended to with the context.
+// A context scoped to a tenant always appends to it, whatever tenant the events carry.
+func WithTenantOf(ctx context.Context, events []StoredStreamEvent) []StoredStreamEvent {
+	tenant, scoped := TenantFilter(ctx)
+	if !scoped {
+		return events
+	}
+
+	scopedEvents := make([]StoredStreamEvent, len(events))
+	for i, event := range events {
+		event.TenantID = tenant
+		scopedEvents[i] = event
+	}
+	return scopedEvents
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// UnitOfWork commits the new events of several aggregates of an EventStore in a single append,
+// which every AppendOnlyStore stores in one transaction. The first new event of every aggregate is appended
+// with the version right after the one the aggregate was loaded at, so the append fails with ErrUnexpectedVersion,
+// and nothing is stored, if any of their streams changed since they were loaded.
+// See domain.UnitOfWork for when it is allowed to change several aggregates atomically.
+type UnitOfWork struct {
+	eventStore *EventStore
+	aggregates []domain.Aggregate
+	streams    map[string]domain.Aggregate
+	committed  bool
+}
+
+// NewUnitOfWork starts a unit of work committing to this event store.
+func (e *EventStore) NewUnitOfWork() domain.UnitOfWork {
+	return &UnitOfWork{
+		eventStore: e,
+		streams:    map[string]domain.Aggregate{},
+	}
+}
+
+func (u *UnitOfWork) Register(aggregates ...domain.Aggregate) error {
+	if u.committed {
+		return domain.ErrUnitOfWorkCommitted
+	}
+
+	for _, aggregate := range aggregates {
+		registered, exists := u.streams[aggregate.ID()]
+		if exists && registered != aggregate {
+			return fmt.Errorf("%w: '%s'", domain.ErrAggregateAlreadyRegistered, aggregate.ID())
+		}
+		if exists {
+			continue
+		}
+		u.streams[aggregate.ID()] = aggregate
+		u.aggregates = append(u.aggregates, aggregate)
+	}
+	return nil
+}
+
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	if u.committed {
+		return domain.ErrUnitOfWorkCommitted
+	}
+
+	if err := u.eventStore.append(ctx, u.aggregates...); err != nil {
+		return fmt.Errorf("error committing the unit of work of %d aggregates: %w", len(u.aggregates), err)
+	}
+	u.committed = true
+	return nil
+}
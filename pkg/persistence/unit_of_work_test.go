@@ -0,0 +1,102 @@
+package persistence_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+)
+
+var _ = Describe("UnitOfWork", func() {
+	var (
+		eventStore  *persistence.EventStore
+		repository  *account.Repository
+		origin      *account.Account
+		destination *account.Account
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		repository = account.NewRepository(eventStore)
+
+		for _, id := range []string{"origin", "destination"} {
+			opened, err := account.OpenAccount(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opened.DepositMoney(100)).To(Succeed())
+			Expect(repository.Save(ctx, opened)).To(Succeed())
+		}
+
+		var err error
+		origin, err = repository.GetByID(ctx, "origin")
+		Expect(err).ToNot(HaveOccurred())
+		destination, err = repository.GetByID(ctx, "destination")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("commits the changes of all the aggregates", func(ctx context.Context) {
+		Expect(origin.WithdrawMoney(30)).To(Succeed())
+		Expect(destination.DepositMoney(30)).To(Succeed())
+
+		unitOfWork := eventStore.NewUnitOfWork()
+		Expect(unitOfWork.Register(origin, destination)).To(Succeed())
+		Expect(unitOfWork.Commit(ctx)).To(Succeed())
+
+		Expect(repository.GetByID(ctx, "origin")).To(HaveField("Balance()", 70))
+		Expect(repository.GetByID(ctx, "destination")).To(HaveField("Balance()", 130))
+	})
+
+	It("commits none of the changes if an aggregate changed since it was loaded", func(ctx context.Context) {
+		concurrent, err := repository.GetByID(ctx, "destination")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(concurrent.WithdrawMoney(100)).To(Succeed())
+		Expect(repository.Save(ctx, concurrent)).To(Succeed())
+
+		Expect(origin.WithdrawMoney(30)).To(Succeed())
+		Expect(destination.DepositMoney(30)).To(Succeed())
+		unitOfWork := eventStore.NewUnitOfWork()
+		Expect(unitOfWork.Register(origin, destination)).To(Succeed())
+
+		Expect(unitOfWork.Commit(ctx)).To(MatchError(persistence.ErrUnexpectedVersion))
+		Expect(repository.GetByID(ctx, "origin")).To(HaveField("Balance()", 100))
+		Expect(repository.GetByID(ctx, "destination")).To(HaveField("Balance()", 0))
+	})
+
+	It("rejects another instance of an aggregate already registered", func(ctx context.Context) {
+		other, err := repository.GetByID(ctx, "origin")
+		Expect(err).ToNot(HaveOccurred())
+
+		unitOfWork := eventStore.NewUnitOfWork()
+		Expect(unitOfWork.Register(origin, origin)).To(Succeed())
+
+		Expect(unitOfWork.Register(other)).To(MatchError(domain.ErrAggregateAlreadyRegistered))
+	})
+
+	It("can only be committed once", func(ctx context.Context) {
+		Expect(origin.WithdrawMoney(30)).To(Succeed())
+		unitOfWork := eventStore.NewUnitOfWork()
+		Expect(unitOfWork.Register(origin)).To(Succeed())
+		Expect(unitOfWork.Commit(ctx)).To(Succeed())
+
+		Expect(unitOfWork.Commit(ctx)).To(MatchError(domain.ErrUnitOfWorkCommitted))
+		Expect(unitOfWork.Register(destination)).To(MatchError(domain.ErrUnitOfWorkCommitted))
+	})
+
+	It("commits the aggregates in the tenant of the context", func(ctx context.Context) {
+		tenantCtx := domain.WithTenant(ctx, "tenant-a")
+		opened, err := account.OpenAccount("tenant-account")
+		Expect(err).ToNot(HaveOccurred())
+
+		unitOfWork := eventStore.NewUnitOfWork()
+		Expect(unitOfWork.Register(opened)).To(Succeed())
+		Expect(unitOfWork.Commit(tenantCtx)).To(Succeed())
+
+		Expect(repository.GetByID(tenantCtx, "tenant-account")).ToNot(BeNil())
+		_, err = repository.GetByID(ctx, "tenant-account")
+		Expect(err).To(MatchError(account.ErrAccountNotFound))
+	})
+})
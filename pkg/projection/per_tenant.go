@@ -0,0 +1,148 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// TenantProjector is a projector that keeps its read model in memory and can be rebuilt,
+// which is what PerTenant needs to snapshot and rebuild the read model of every tenant.
+type TenantProjector interface {
+	Rebuildable
+	Snapshotter
+}
+
+// PerTenant partitions a projector by tenant, so every tenant has its own read model.
+// The Runtime applies every event with a context scoped to its tenant, which is routed to the projector of the tenant,
+// and the readers get the read model of the tenant of their context with For.
+type PerTenant[P TenantProjector] struct {
+	newProjector func() P
+	template     P
+	projectors   map[domain.TenantID]P
+	mutex        sync.RWMutex
+}
+
+// NewPerTenant returns an empty projector partitioned by tenant, newProjector returns the empty projector of a tenant.
+func NewPerTenant[P TenantProjector](newProjector func() P) *PerTenant[P] {
+	return &PerTenant[P]{
+		newProjector: newProjector,
+		template:     newProjector(),
+		projectors:   map[domain.TenantID]P{},
+	}
+}
+
+// For returns the read model of the tenant of the context, which is empty if the tenant has no events.
+func (p *PerTenant[P]) For(ctx context.Context) P {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	projector, exists := p.projectors[domain.TenantFromContext(ctx)]
+	if !exists {
+		return p.newProjector()
+	}
+	return projector
+}
+
+// Tenants returns the tenants with a read model.
+func (p *PerTenant[P]) Tenants() []domain.TenantID {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	tenants := make([]domain.TenantID, 0, len(p.projectors))
+	for tenant := range p.projectors {
+		tenants = append(tenants, tenant)
+	}
+	return tenants
+}
+
+func (p *PerTenant[P]) Name() string {
+	return p.template.Name()
+}
+
+func (p *PerTenant[P]) Version() uint64 {
+	return p.template.Version()
+}
+
+func (p *PerTenant[P]) HandledEvents() []string {
+	return p.template.HandledEvents()
+}
+
+func (p *PerTenant[P]) Apply(ctx context.Context, event domain.Event) error {
+	return p.projectorOf(domain.TenantFromContext(ctx)).Apply(ctx, event)
+}
+
+// projectorOf returns the projector of the tenant, creating it on its first event.
+func (p *PerTenant[P]) projectorOf(tenant domain.TenantID) P {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	projector, exists := p.projectors[tenant]
+	if !exists {
+		projector = p.newProjector()
+		p.projectors[tenant] = projector
+	}
+	return projector
+}
+
+// Snapshot returns the snapshots of every tenant by tenant.
+func (p *PerTenant[P]) Snapshot() ([]byte, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	snapshots := make(map[domain.TenantID]json.RawMessage, len(p.projectors))
+	for tenant, projector := range p.projectors {
+		snapshot, err := projector.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("error taking the snapshot of tenant '%s': %w", tenant, err)
+		}
+		snapshots[tenant] = snapshot
+	}
+	return json.Marshal(snapshots)
+}
+
+func (p *PerTenant[P]) Restore(state []byte) error {
+	snapshots := map[domain.TenantID]json.RawMessage{}
+	if err := json.Unmarshal(state, &snapshots); err != nil {
+		return fmt.Errorf("error deserializing the snapshots of the tenants: %w", err)
+	}
+
+	projectors := make(map[domain.TenantID]P, len(snapshots))
+	for tenant, snapshot := range snapshots {
+		projector := p.newProjector()
+		if err := projector.Restore(snapshot); err != nil {
+			return fmt.Errorf("error restoring the snapshot of tenant '%s': %w", tenant, err)
+		}
+		projectors[tenant] = projector
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.projectors = projectors
+	return nil
+}
+
+func (p *PerTenant[P]) NewShadow() Projector {
+	return NewPerTenant(p.newProjector)
+}
+
+func (p *PerTenant[P]) Swap(shadow Projector) error {
+	rebuilt, ok := shadow.(*PerTenant[P])
+	if !ok {
+		return fmt.Errorf("unexpected shadow projection type %T", shadow)
+	}
+
+	rebuilt.mutex.RLock()
+	projectors := rebuilt.projectors
+	rebuilt.mutex.RUnlock()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.projectors = projectors
+	return nil
+}
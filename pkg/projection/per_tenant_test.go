@@ -0,0 +1,91 @@
+package projection_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+)
+
+var _ = Describe("PerTenant", func() {
+	var (
+		eventStore      *persistence.EventStore
+		projectionStore *inmemory.ProjectionStore
+		accounts        *projection.PerTenant[*account.Projection]
+		runtime         *projection.Runtime
+		tenantA         context.Context
+		tenantB         context.Context
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		projectionStore = inmemory.NewProjectionStore()
+		accounts = projection.NewPerTenant(account.NewProjection)
+		runtime = projection.NewRuntime(eventStore.ReadOnlyEventStore, projectionStore)
+		Expect(runtime.Register(accounts, projection.ErrorPolicyHalt)).To(Succeed())
+
+		tenantA = domain.WithTenant(context.Background(), "tenant-a")
+		tenantB = domain.WithTenant(context.Background(), "tenant-b")
+		openAccount(tenantA, eventStore, "some-account", 10)
+		openAccount(tenantB, eventStore, "some-account", 20)
+		openAccount(tenantB, eventStore, "other-account", 30)
+
+		runtimeCtx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+	})
+
+	It("builds a read model for every tenant", func() {
+		Expect(accounts.For(tenantA).Accounts()).To(HaveExactElements(HaveField("Balance", 10)))
+		Expect(accounts.For(tenantB).Accounts()).To(HaveExactElements(
+			HaveField("AccountID", "other-account"),
+			HaveField("Balance", 20),
+		))
+		Expect(accounts.Tenants()).To(ConsistOf(domain.TenantID("tenant-a"), domain.TenantID("tenant-b")))
+	})
+
+	It("returns an empty read model for a tenant without events", func(ctx context.Context) {
+		Expect(accounts.For(domain.WithTenant(ctx, "tenant-c")).Accounts()).To(BeEmpty())
+		Expect(accounts.For(ctx).Accounts()).To(BeEmpty())
+	})
+
+	It("keeps following the events of every tenant", func() {
+		openAccount(tenantA, eventStore, "other-account", 40)
+
+		Eventually(func() []account.ProjectedAccount { return accounts.For(tenantA).Accounts() }).Should(HaveLen(2))
+		Expect(accounts.For(tenantB).Accounts()).To(HaveLen(2))
+	})
+
+	It("restores the read model of every tenant from the checkpoint", func(ctx context.Context) {
+		restored := projection.NewPerTenant(account.NewProjection)
+		restoredRuntime := projection.NewRuntime(eventStore.ReadOnlyEventStore, projectionStore)
+		Expect(restoredRuntime.Register(restored, projection.ErrorPolicyHalt)).To(Succeed())
+
+		Expect(restoredRuntime.Start(ctx)).To(Succeed())
+
+		Expect(restored.For(tenantA).Accounts()).To(HaveExactElements(HaveField("Balance", 10)))
+		Expect(restored.For(tenantB).Accounts()).To(HaveLen(2))
+	})
+
+	It("rebuilds the read model of every tenant", func(ctx context.Context) {
+		Expect(runtime.Rebuild(ctx, accounts.Name())).To(Succeed())
+
+		Expect(accounts.For(tenantA).Accounts()).To(HaveExactElements(HaveField("Balance", 10)))
+		Expect(accounts.For(tenantB).Accounts()).To(HaveLen(2))
+	})
+})
+
+func openAccount(ctx context.Context, eventStore *persistence.EventStore, accountID string, amount int) {
+	GinkgoHelper()
+
+	acc, err := account.OpenAccount(accountID)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(acc.DepositMoney(amount)).To(Succeed())
+	Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+}
@@ -0,0 +1,70 @@
+package projection
+
+import (
+	"context"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+// Projector builds a read model from the events of the event store.
+// The Runtime calls Apply from a single goroutine, in the order the events were stored,
+// so a Projector only needs to synchronize Apply with its own readers.
+type Projector interface {
+	// Name identifies the projection, its checkpoints and snapshots are saved under this name.
+	Name() string
+
+	// Version must be increased every time the handling of the events changes,
+	// so the checkpoints and snapshots saved by the previous logic are discarded and the projection is rebuilt.
+	Version() uint64
+
+	// HandledEvents returns the names of the events passed to Apply, any other event is skipped.
+	HandledEvents() []string
+
+	// Apply updates the read model with the given event.
+	Apply(ctx context.Context, event domain.Event) error
+}
+
+// Snapshotter is implemented by the projectors that keep their read model in memory,
+// so the Runtime saves snapshots of their state instead of checkpoints, and restores the last one on start.
+// Projectors that do not implement it are expected to persist their read model by themselves.
+type Snapshotter interface {
+	Snapshot() ([]byte, error)
+	Restore(state []byte) error
+}
+
+// Rebuildable is implemented by the projectors that can be rebuilt from the beginning of the store.
+// The Runtime rebuilds a shadow copy in the background, so the projector keeps serving reads until it is swapped.
+type Rebuildable interface {
+	Projector
+
+	// NewShadow returns an empty copy of the projector.
+	NewShadow() Projector
+
+	// Swap replaces atomically the read model of the projector with the one built by the shadow.
+	Swap(shadow Projector) error
+}
+
+// ErrorPolicy decides what the Runtime does when a Projector fails to apply an event.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyHalt stops the projection at the failing event until it is restarted.
+	ErrorPolicyHalt ErrorPolicy = iota
+	// ErrorPolicySkip logs the failing event and continues with the next one.
+	ErrorPolicySkip
+	// ErrorPolicyRetry retries the failing event with a backoff, and halts the projection if it keeps failing.
+	ErrorPolicyRetry
+)
+
+func (e ErrorPolicy) String() string {
+	switch e {
+	case ErrorPolicyHalt:
+		return "halt"
+	case ErrorPolicySkip:
+		return "skip"
+	case ErrorPolicyRetry:
+		return "retry"
+	default:
+		return "unknown"
+	}
+}
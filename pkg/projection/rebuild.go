@@ -0,0 +1,88 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrProjectionNotRebuildable = errors.New("projection cannot be rebuilt")
+
+// Rebuild replays the projection with the given name from the beginning of the event store.
+// The events are applied to a shadow copy while the projection keeps being updated and read,
+// and once the shadow has caught up, it is swapped with the projection atomically.
+func (r *Runtime) Rebuild(ctx context.Context, name string) error {
+	projection, rebuildable, err := r.rebuildableProjection(name)
+	if err != nil {
+		return err
+	}
+
+	projection.rebuildMutex.Lock()
+	defer projection.rebuildMutex.Unlock()
+
+	shadow := newRunningProjection(rebuildable.NewShadow(), projection.errorPolicy)
+	shadow.isShadow = true
+	if err := r.catchUp(ctx, shadow); err != nil {
+		return fmt.Errorf("error rebuilding the projection '%s': %w", name, err)
+	}
+
+	// The shadow catches up again with the projection stopped, so no event is applied to the projection after
+	// the shadow has started from where the projection was.
+	projection.stopFollowing()
+	defer r.startFollowing(projection)
+
+	if err := r.catchUp(ctx, shadow); err != nil {
+		return fmt.Errorf("error rebuilding the projection '%s': %w", name, err)
+	}
+
+	if err := rebuildable.Swap(shadow.projector); err != nil {
+		return fmt.Errorf("error swapping the rebuilt projection '%s': %w", name, err)
+	}
+
+	projection.replaceStatus(shadow.currentStatus())
+	r.save(ctx, projection)
+	return nil
+}
+
+// Reset discards the state and the snapshot of the projection with the given name,
+// and replays it from the beginning of the event store in place,
+// so unlike Rebuild, the projection is incomplete until Reset returns.
+func (r *Runtime) Reset(ctx context.Context, name string) error {
+	projection, rebuildable, err := r.rebuildableProjection(name)
+	if err != nil {
+		return err
+	}
+
+	projection.rebuildMutex.Lock()
+	defer projection.rebuildMutex.Unlock()
+
+	projection.stopFollowing()
+	defer r.startFollowing(projection)
+
+	empty := newRunningProjection(rebuildable.NewShadow(), projection.errorPolicy)
+	if err := rebuildable.Swap(empty.projector); err != nil {
+		return fmt.Errorf("error resetting the projection '%s': %w", name, err)
+	}
+
+	empty.status.State = StateCatchingUp
+	projection.replaceStatus(empty.status)
+	r.save(ctx, projection)
+
+	if err := r.catchUp(ctx, projection); err != nil {
+		return fmt.Errorf("error replaying the projection '%s': %w", name, err)
+	}
+	return nil
+}
+
+func (r *Runtime) rebuildableProjection(name string) (*runningProjection, Rebuildable, error) {
+	projection, err := r.projection(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rebuildable, ok := projection.projector.(Rebuildable)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrProjectionNotRebuildable, name)
+	}
+	return projection, rebuildable, nil
+}
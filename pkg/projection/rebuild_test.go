@@ -0,0 +1,136 @@
+package projection_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/test/mother"
+)
+
+var _ = Describe("Rebuild", func() {
+	var (
+		eventStore      *persistence.EventStore
+		projectionStore *inmemory.ProjectionStore
+		projector       *rebuildableFakeProjector
+		runtime         *projection.Runtime
+		runtimeCtx      context.Context
+		storedEvents    []domain.Event
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		projectionStore = inmemory.NewProjectionStore()
+		projector = newRebuildableFakeProjector()
+		runtime = projection.NewRuntime(eventStore.ReadOnlyEventStore, projectionStore).WithBatchSize(2)
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+		var cancel context.CancelFunc
+		runtimeCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		Expect(eventStore.AppendToStream(ctx, mother.AccountOpenWithMovements())).To(Succeed())
+		var err error
+		storedEvents, err = eventStore.LoadAllEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{
+			Name:        projector.Name(),
+			Version:     projector.Version(),
+			LastEventID: storedEvents[len(storedEvents)-1].EventID(),
+			State:       []byte("40"),
+		})).To(Succeed())
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+	})
+
+	It("replays the projection from the beginning and swaps it", func(ctx context.Context) {
+		Expect(projector.restoredCount()).To(Equal(40))
+
+		Expect(runtime.Rebuild(ctx, projector.Name())).To(Succeed())
+
+		Expect(projector.restoredCount()).To(BeZero())
+		Expect(projector.applied()).To(HaveLen(len(storedEvents)))
+		snapshot, err := projectionStore.LoadSnapshot(ctx, projector.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot.State).To(Equal([]byte("4")))
+		Expect(snapshot.LastEventID).To(Equal(storedEvents[len(storedEvents)-1].EventID()))
+	})
+
+	It("keeps following the store after the swap", func(ctx context.Context) {
+		Expect(runtime.Rebuild(ctx, projector.Name())).To(Succeed())
+
+		acc, err := account.OpenAccount("other-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+
+		Eventually(projector.applied).Should(HaveLen(len(storedEvents) + 1))
+	})
+
+	It("resumes a halted projection once it applies the events again", func(ctx context.Context) {
+		projector.failuresLeft[(&account.AccountOpened{}).EventName()] = 1
+		acc, err := account.OpenAccount("other-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+		Eventually(func() projection.State { return runtime.Status(ctx)[0].State }).Should(Equal(projection.StateHalted))
+
+		Expect(runtime.Rebuild(ctx, projector.Name())).To(Succeed())
+
+		Expect(runtime.Status(ctx)).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+			"State":       Equal(projection.StateLive),
+			"LastEventID": Equal(acc.UncommittedEvents()[0].EventID()),
+			"LastError":   BeEmpty(),
+		})))
+		Expect(projector.applied()).To(HaveLen(len(storedEvents) + 1))
+	})
+
+	It("discards the state and replays the projection in place when it is reset", func(ctx context.Context) {
+		Expect(runtime.Reset(ctx, projector.Name())).To(Succeed())
+
+		Expect(projector.restoredCount()).To(BeZero())
+		Expect(projector.applied()).To(HaveLen(len(storedEvents)))
+		Expect(runtime.Status(ctx)[0].State).To(Equal(projection.StateLive))
+	})
+
+	It("fails if the projection does not exist", func(ctx context.Context) {
+		Expect(runtime.Rebuild(ctx, "nonexistent")).To(MatchError(projection.ErrProjectionNotFound))
+		Expect(runtime.Reset(ctx, "nonexistent")).To(MatchError(projection.ErrProjectionNotFound))
+	})
+
+	It("fails if the projection cannot be rebuilt", func(ctx context.Context) {
+		notRebuildable := newFakeProjector()
+		notRebuildable.name = "not-rebuildable"
+		Expect(runtime.Register(notRebuildable, projection.ErrorPolicyHalt)).To(Succeed())
+
+		Expect(runtime.Rebuild(ctx, notRebuildable.Name())).To(MatchError(projection.ErrProjectionNotRebuildable))
+	})
+})
+
+type rebuildableFakeProjector struct {
+	*fakeProjector
+}
+
+func newRebuildableFakeProjector() *rebuildableFakeProjector {
+	return &rebuildableFakeProjector{fakeProjector: newFakeProjector((&account.AccountOpened{}).EventName(), (&account.AmountDeposited{}).EventName(), (&account.AmountWithdrawn{}).EventName())}
+}
+
+func (f *rebuildableFakeProjector) NewShadow() projection.Projector {
+	return newRebuildableFakeProjector()
+}
+
+func (f *rebuildableFakeProjector) Swap(shadow projection.Projector) error {
+	rebuilt := shadow.(*rebuildableFakeProjector)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.events = rebuilt.applied()
+	f.restored = rebuilt.restoredCount()
+	return nil
+}
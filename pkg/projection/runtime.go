@@ -0,0 +1,647 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultMaxRetries   = 5
+	defaultRetryBackoff = 100 * time.Millisecond
+	resubscribeDelay    = time.Second
+	// defaultCheckpointEvents and defaultCheckpointInterval bound how many events are replayed after a restart.
+	defaultCheckpointEvents   = 1000
+	defaultCheckpointInterval = 10 * time.Second
+	// defaultSnapshotEvents and defaultSnapshotInterval are larger, since a snapshot writes the whole read model.
+	defaultSnapshotEvents   = 10000
+	defaultSnapshotInterval = 5 * time.Minute
+)
+
+var (
+	ErrProjectionAlreadyRegistered = errors.New("projection already registered")
+	ErrProjectionNotFound          = errors.New("projection not found")
+	ErrProjectionNotHalted         = errors.New("projection is not halted")
+)
+
+// Runtime feeds the events of the event store to the registered projectors.
+// It resumes every projection from its last checkpoint, or from its last snapshot if it is a Snapshotter,
+// catches up with the store, and then follows it, saving its progress every number of events or interval,
+// see WithCheckpointEvery and WithSnapshotEvery.
+// It reads the events of every tenant, and applies each one with a context scoped to its tenant, see PerTenant.
+type Runtime struct {
+	// ctx is the context given to Start, the projections follow the store until it is cancelled.
+	ctx             context.Context
+	eventStore      *persistence.ReadOnlyEventStore
+	projectionStore persistence.ProjectionStore
+	projections     map[string]*runningProjection
+	batchSize       int
+	maxRetries      int
+	retryBackoff    time.Duration
+	// checkpointEvents and checkpointInterval are how often the checkpoint is saved while the projection follows the store.
+	checkpointEvents   int
+	checkpointInterval time.Duration
+	// snapshotEvents and snapshotInterval are how often the snapshot of a Snapshotter is saved instead.
+	snapshotEvents   int
+	snapshotInterval time.Duration
+	mutex            sync.RWMutex
+}
+
+type runningProjection struct {
+	projector     Projector
+	handledEvents map[string]struct{}
+	status        Status
+	errorPolicy   ErrorPolicy
+	// eventsSinceSave and lastSavedOn tell when the next checkpoint or snapshot is due.
+	eventsSinceSave int
+	lastSavedOn     time.Time
+	// isShadow is set for the copies rebuilt in the background, which must not overwrite the checkpoint or snapshot.
+	isShadow     bool
+	cancelFollow context.CancelFunc
+	followDone   chan struct{}
+	rebuildMutex sync.Mutex
+	mutex        sync.RWMutex
+}
+
+func NewRuntime(eventStore *persistence.ReadOnlyEventStore, projectionStore persistence.ProjectionStore) *Runtime {
+	return &Runtime{
+		eventStore:      eventStore,
+		projectionStore: projectionStore,
+		projections:     map[string]*runningProjection{},
+		batchSize:       defaultBatchSize,
+		maxRetries:      defaultMaxRetries,
+		retryBackoff:    defaultRetryBackoff,
+
+		checkpointEvents:   defaultCheckpointEvents,
+		checkpointInterval: defaultCheckpointInterval,
+		snapshotEvents:     defaultSnapshotEvents,
+		snapshotInterval:   defaultSnapshotInterval,
+	}
+}
+
+// WithBatchSize sets the maximum number of events loaded and applied at once.
+func (r *Runtime) WithBatchSize(batchSize int) *Runtime {
+	r.batchSize = batchSize
+	return r
+}
+
+// WithRetries sets how many times an event is retried by the projections with ErrorPolicyRetry,
+// and the delay before the first retry, which doubles on every following attempt.
+func (r *Runtime) WithRetries(maxRetries int, retryBackoff time.Duration) *Runtime {
+	r.maxRetries = maxRetries
+	r.retryBackoff = retryBackoff
+	return r
+}
+
+// WithCheckpointEvery sets how often the checkpoint of a projection is saved: once the given number of events
+// has been processed since the last one, or once the interval has passed, whichever comes first.
+// The checkpoint is also saved when a projection catches up, stops following the store or is halted,
+// so only the events processed since the last checkpoint are replayed after a crash.
+// The Snapshotters save snapshots instead, see WithSnapshotEvery.
+func (r *Runtime) WithCheckpointEvery(events int, interval time.Duration) *Runtime {
+	r.checkpointEvents = events
+	r.checkpointInterval = interval
+	return r
+}
+
+// WithSnapshotEvery sets how often the snapshot of a Snapshotter is saved, like WithCheckpointEvery does
+// for the checkpoints. A snapshot writes the whole read model, so it is usually saved less often,
+// at the cost of replaying more events after a crash.
+func (r *Runtime) WithSnapshotEvery(events int, interval time.Duration) *Runtime {
+	r.snapshotEvents = events
+	r.snapshotInterval = interval
+	return r
+}
+
+// Register adds a projector to the runtime. It must be called before Start.
+func (r *Runtime) Register(projector Projector, errorPolicy ErrorPolicy) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.projections[projector.Name()]; exists {
+		return fmt.Errorf("%w: %s", ErrProjectionAlreadyRegistered, projector.Name())
+	}
+
+	r.projections[projector.Name()] = newRunningProjection(projector, errorPolicy)
+	return nil
+}
+
+func newRunningProjection(projector Projector, errorPolicy ErrorPolicy) *runningProjection {
+	handledEvents := make(map[string]struct{}, len(projector.HandledEvents()))
+	for _, eventName := range projector.HandledEvents() {
+		handledEvents[eventName] = struct{}{}
+	}
+
+	return &runningProjection{
+		projector:     projector,
+		handledEvents: handledEvents,
+		errorPolicy:   errorPolicy,
+		status: Status{
+			Name:        projector.Name(),
+			Version:     projector.Version(),
+			ErrorPolicy: errorPolicy.String(),
+			State:       StateStopped,
+		},
+	}
+}
+
+// Start restores the registered projections from their checkpoints and catches up with the event store,
+// so they can be read as soon as it returns. Then it keeps them up to date until the context is cancelled.
+func (r *Runtime) Start(ctx context.Context) error {
+	r.mutex.Lock()
+	r.ctx = ctx
+	projections := make([]*runningProjection, 0, len(r.projections))
+	for _, projection := range r.projections {
+		projections = append(projections, projection)
+	}
+	r.mutex.Unlock()
+
+	for _, projection := range projections {
+		if err := r.start(ctx, projection); err != nil {
+			return fmt.Errorf("error restoring projection '%s': %w", projection.projector.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) start(ctx context.Context, projection *runningProjection) error {
+	projection.rebuildMutex.Lock()
+	defer projection.rebuildMutex.Unlock()
+
+	if err := r.restore(ctx, projection); err != nil {
+		return err
+	}
+
+	if err := r.catchUp(ctx, projection); err != nil {
+		slog.Default().ErrorContext(ctx, "projection halted while catching up", "projection", projection.projector.Name(), "error", err.Error())
+		return nil
+	}
+
+	r.startFollowing(projection)
+	return nil
+}
+
+// Restart resumes the halted projection with the given name from the last event it processed,
+// once the cause of the failure has been fixed, so it does not need to be replayed from the beginning like with Reset.
+// It returns an error if the projection halts again while catching up with the store.
+func (r *Runtime) Restart(ctx context.Context, name string) error {
+	projection, err := r.projection(name)
+	if err != nil {
+		return err
+	}
+
+	projection.rebuildMutex.Lock()
+	defer projection.rebuildMutex.Unlock()
+
+	if projection.currentStatus().State != StateHalted {
+		return fmt.Errorf("%w: %s", ErrProjectionNotHalted, name)
+	}
+
+	projection.stopFollowing()
+	projection.resume()
+	if err := r.catchUp(ctx, projection); err != nil {
+		return fmt.Errorf("error restarting the projection '%s': %w", name, err)
+	}
+
+	r.startFollowing(projection)
+	return nil
+}
+
+// startFollowing keeps the projection up to date in the background, unless it is halted or the runtime is not started.
+func (r *Runtime) startFollowing(projection *runningProjection) {
+	r.mutex.RLock()
+	runtimeCtx := r.ctx
+	r.mutex.RUnlock()
+
+	if runtimeCtx == nil || projection.currentStatus().State == StateHalted {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(runtimeCtx)
+	done := make(chan struct{})
+
+	projection.mutex.Lock()
+	projection.cancelFollow = cancel
+	projection.followDone = done
+	projection.mutex.Unlock()
+
+	go func() {
+		defer close(done)
+		r.follow(ctx, projection)
+	}()
+}
+
+func (r *Runtime) restore(ctx context.Context, projection *runningProjection) error {
+	projection.setState(StateCatchingUp)
+
+	if snapshotter, ok := projection.projector.(Snapshotter); ok {
+		return r.restoreSnapshot(ctx, projection, snapshotter)
+	}
+
+	checkpoint, err := r.projectionStore.LoadCheckpoint(ctx, projection.projector.Name())
+	if errors.Is(err, persistence.ErrProjectionCheckpointNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading the projection checkpoint: %w", err)
+	}
+	if r.outdated(ctx, projection, checkpoint.Version) {
+		return nil
+	}
+
+	projection.resumeFrom(checkpoint.LastEventID, checkpoint.UpdatedOn)
+	return nil
+}
+
+// restoreSnapshot restores the state of the Snapshotter, which resumes after the last event of its snapshot.
+func (r *Runtime) restoreSnapshot(ctx context.Context, projection *runningProjection, snapshotter Snapshotter) error {
+	snapshot, err := r.projectionStore.LoadSnapshot(ctx, projection.projector.Name())
+	if errors.Is(err, persistence.ErrProjectionSnapshotNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading the projection snapshot: %w", err)
+	}
+	if r.outdated(ctx, projection, snapshot.Version) {
+		return nil
+	}
+
+	if err := snapshotter.Restore(snapshot.State); err != nil {
+		return fmt.Errorf("error restoring the projection state: %w", err)
+	}
+	projection.resumeFrom(snapshot.LastEventID, snapshot.TakenOn)
+	return nil
+}
+
+// outdated returns whether the checkpoint or snapshot of the given version was saved by another version of the projection,
+// which must then be rebuilt.
+func (r *Runtime) outdated(ctx context.Context, projection *runningProjection, version uint64) bool {
+	if version == projection.projector.Version() {
+		return false
+	}
+	slog.Default().InfoContext(ctx, "discarding the checkpoint of an outdated projection version, rebuilding it", "projection", projection.projector.Name(), "checkpointVersion", version, "currentVersion", projection.projector.Version())
+	return true
+}
+
+func (r *Runtime) catchUp(ctx context.Context, projection *runningProjection) error {
+	for {
+		store := r.eventStore
+		if lastEventID := projection.lastEventID(); lastEventID != "" {
+			store = store.AfterEventID(lastEventID)
+		}
+
+		events, err := store.Limit(r.batchSize).LoadAllTenantEvents(domain.WithAllTenants(ctx))
+		if err != nil {
+			return fmt.Errorf("error loading events from store: %w", err)
+		}
+
+		if err := r.processBatch(ctx, projection, events); err != nil {
+			return err
+		}
+
+		if len(events) < r.batchSize {
+			if projection.unsaved() {
+				r.save(ctx, projection)
+			}
+			projection.setState(StateLive)
+			return nil
+		}
+	}
+}
+
+func (r *Runtime) follow(ctx context.Context, projection *runningProjection) {
+	for ctx.Err() == nil {
+		subscriptionCtx, cancel := context.WithCancel(ctx)
+		subscription := r.eventStore.Subscribe(domain.WithAllTenants(subscriptionCtx), projection.lastEventID())
+
+		for event := range subscription.Events() {
+			batch := receiveBatch(event, subscription.Events(), r.batchSize)
+			if err := r.processBatch(ctx, projection, batch); err != nil {
+				cancel()
+				slog.Default().ErrorContext(ctx, "projection halted", "projection", projection.projector.Name(), "error", err.Error())
+				return
+			}
+		}
+		cancel()
+
+		if ctx.Err() != nil {
+			break
+		}
+		slog.Default().ErrorContext(ctx, "projection subscription ended, resubscribing", "projection", projection.projector.Name(), "error", subscription.Err())
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(resubscribeDelay):
+		}
+	}
+	// The context is already cancelled, but the events processed since the last save must not be replayed.
+	if projection.unsaved() {
+		r.save(context.WithoutCancel(ctx), projection)
+	}
+	projection.setState(StateStopped)
+}
+
+// receiveBatch returns the given event and the events already waiting in the subscription, up to batchSize.
+func receiveBatch(event persistence.TenantEvent, pending <-chan persistence.TenantEvent, batchSize int) []persistence.TenantEvent {
+	batch := []persistence.TenantEvent{event}
+	for len(batch) < batchSize {
+		select {
+		case event, ok := <-pending:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// processBatch applies the events to the projection, and saves its progress if it is due or the projection is halted.
+// It returns an error if the projection is halted.
+func (r *Runtime) processBatch(ctx context.Context, projection *runningProjection, events []persistence.TenantEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var haltErr error
+	for _, event := range events {
+		if haltErr = r.process(ctx, projection, event); haltErr != nil {
+			break
+		}
+	}
+
+	if haltErr != nil || r.saveDue(projection) {
+		r.save(ctx, projection)
+	}
+
+	if haltErr != nil {
+		projection.halt(haltErr)
+	}
+	return haltErr
+}
+
+func (r *Runtime) process(ctx context.Context, projection *runningProjection, tenantEvent persistence.TenantEvent) error {
+	event := tenantEvent.Event
+	if _, handled := projection.handledEvents[event.EventName()]; handled {
+		err := r.apply(domain.WithTenant(ctx, tenantEvent.Tenant), projection, event)
+		if err != nil && projection.errorPolicy != ErrorPolicySkip {
+			return fmt.Errorf("error applying event '%s' with id '%s': %w", event.EventName(), event.EventID(), err)
+		}
+		if err != nil {
+			slog.Default().WarnContext(ctx, "projection skipped an event it failed to apply", "projection", projection.projector.Name(), "eventID", event.EventID(), "error", err.Error())
+			projection.recordSkipped(err)
+		}
+	}
+
+	projection.recordProcessed(event)
+	return nil
+}
+
+func (r *Runtime) apply(ctx context.Context, projection *runningProjection, event domain.Event) error {
+	err := projection.projector.Apply(ctx, event)
+	if err == nil || projection.errorPolicy != ErrorPolicyRetry {
+		return err
+	}
+
+	backoff := r.retryBackoff
+	for retry := 0; retry < r.maxRetries; retry++ {
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		if err = projection.projector.Apply(ctx, event); err == nil {
+			return nil
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// saveDue returns whether the snapshot of a Snapshotter or the checkpoint of any other projection is due.
+func (r *Runtime) saveDue(projection *runningProjection) bool {
+	if _, ok := projection.projector.(Snapshotter); ok {
+		return projection.saveDue(r.snapshotEvents, r.snapshotInterval)
+	}
+	return projection.saveDue(r.checkpointEvents, r.checkpointInterval)
+}
+
+// save saves the snapshot of a Snapshotter, which resumes from its state, or the checkpoint of any other projection,
+// which only needs the position of the last event it processed.
+func (r *Runtime) save(ctx context.Context, projection *runningProjection) {
+	if projection.isShadow {
+		return
+	}
+
+	savedOn := time.Now().UTC()
+	if snapshotter, ok := projection.projector.(Snapshotter); ok {
+		state, err := snapshotter.Snapshot()
+		if err != nil {
+			slog.Default().ErrorContext(ctx, "error taking the projection snapshot", "projection", projection.projector.Name(), "error", err.Error())
+			return
+		}
+		snapshot := persistence.ProjectionSnapshot{
+			Name:        projection.projector.Name(),
+			Version:     projection.projector.Version(),
+			LastEventID: projection.lastEventID(),
+			State:       state,
+			TakenOn:     savedOn,
+		}
+		if err := r.projectionStore.SaveSnapshot(ctx, snapshot); err != nil {
+			slog.Default().ErrorContext(ctx, "error saving the projection snapshot", "projection", projection.projector.Name(), "error", err.Error())
+			return
+		}
+	} else {
+		checkpoint := persistence.ProjectionCheckpoint{
+			Name:        projection.projector.Name(),
+			Version:     projection.projector.Version(),
+			LastEventID: projection.lastEventID(),
+			UpdatedOn:   savedOn,
+		}
+		if err := r.projectionStore.SaveCheckpoint(ctx, checkpoint); err != nil {
+			slog.Default().ErrorContext(ctx, "error saving the projection checkpoint", "projection", projection.projector.Name(), "error", err.Error())
+			return
+		}
+	}
+	projection.saved(savedOn)
+}
+
+// ProjectionStatus returns the status of the projection with the given name.
+func (r *Runtime) ProjectionStatus(ctx context.Context, name string) (Status, error) {
+	projection, err := r.projection(name)
+	if err != nil {
+		return Status{}, err
+	}
+	return r.withLag(ctx, projection.currentStatus()), nil
+}
+
+func (r *Runtime) projection(name string) (*runningProjection, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	projection, exists := r.projections[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrProjectionNotFound, name)
+	}
+	return projection, nil
+}
+
+// Status returns the status of every registered projection, sorted by name.
+func (r *Runtime) Status(ctx context.Context) []Status {
+	r.mutex.RLock()
+	statuses := make([]Status, 0, len(r.projections))
+	for _, projection := range r.projections {
+		statuses = append(statuses, projection.currentStatus())
+	}
+	r.mutex.RUnlock()
+
+	for i, status := range statuses {
+		statuses[i] = r.withLag(ctx, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// withLag sets the lag of the status to the number of events in the store after the last event processed.
+// The lag is left unknown as zero if the events cannot be counted.
+func (r *Runtime) withLag(ctx context.Context, status Status) Status {
+	store := r.eventStore
+	if status.LastEventID != "" {
+		store = store.AfterEventID(status.LastEventID)
+	}
+
+	lag, err := store.CountEvents(domain.WithAllTenants(ctx))
+	if err != nil {
+		slog.Default().ErrorContext(ctx, "error counting the events the projection has not processed", "projection", status.Name, "error", err.Error())
+		return status
+	}
+	status.Lag = uint64(lag)
+	return status
+}
+
+func (p *runningProjection) lastEventID() domain.EventID {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.status.LastEventID
+}
+
+func (p *runningProjection) currentStatus() Status {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.status
+}
+
+func (p *runningProjection) setState(state State) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.status.State != StateHalted {
+		p.status.State = state
+	}
+}
+
+func (p *runningProjection) recordProcessed(event domain.Event) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.status.LastEventID = event.EventID()
+	p.status.LastProcessedOn = time.Now().UTC()
+	p.status.ProcessedEvents++
+	p.eventsSinceSave++
+}
+
+// saveDue returns whether the given number of events has been processed or the interval has passed since the last save.
+func (p *runningProjection) saveDue(events int, interval time.Duration) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.eventsSinceSave >= events || time.Since(p.lastSavedOn) >= interval
+}
+
+// unsaved returns whether events have been processed since the last save.
+func (p *runningProjection) unsaved() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.eventsSinceSave > 0
+}
+
+func (p *runningProjection) saved(savedOn time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.eventsSinceSave = 0
+	p.lastSavedOn = savedOn
+}
+
+// resumeFrom sets the position the projection was saved at, so it resumes after the last event it processed.
+func (p *runningProjection) resumeFrom(lastEventID domain.EventID, savedOn time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.status.LastEventID = lastEventID
+	p.status.LastProcessedOn = savedOn
+	p.lastSavedOn = savedOn
+}
+
+func (p *runningProjection) recordSkipped(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.status.SkippedEvents++
+	p.status.LastError = err.Error()
+}
+
+// stopFollowing stops following the store and waits until the batch being processed is finished.
+func (p *runningProjection) stopFollowing() {
+	p.mutex.Lock()
+	cancel, done := p.cancelFollow, p.followDone
+	p.cancelFollow, p.followDone = nil, nil
+	p.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (p *runningProjection) replaceStatus(status Status) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.status = status
+}
+
+// resume clears the halted state, so the projection can catch up with the store again.
+func (p *runningProjection) resume() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.status.State = StateCatchingUp
+	p.status.LastError = ""
+}
+
+func (p *runningProjection) halt(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.status.State = StateHalted
+	p.status.LastError = err.Error()
+}
@@ -0,0 +1,420 @@
+package projection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/tembleking/myBankSourcing/pkg/account"
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
+	"github.com/tembleking/myBankSourcing/pkg/projection"
+	"github.com/tembleking/myBankSourcing/test/mother"
+)
+
+var _ = Describe("Runtime", func() {
+	var (
+		eventStore      *persistence.EventStore
+		projectionStore *inmemory.ProjectionStore
+		projector       *fakeProjector
+		runtime         *projection.Runtime
+		runtimeCtx      context.Context
+		runtimeCancel   context.CancelFunc
+		storedEvents    []domain.Event
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		log.SetOutput(GinkgoWriter)
+		eventStore = persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build()
+		projectionStore = inmemory.NewProjectionStore()
+		projector = newFakeProjector((&account.AccountOpened{}).EventName(), (&account.AmountDeposited{}).EventName())
+		runtime = projection.NewRuntime(eventStore.ReadOnlyEventStore, projectionStore).WithBatchSize(2).WithRetries(3, 0)
+
+		runtimeCtx, runtimeCancel = context.WithCancel(context.Background())
+		DeferCleanup(runtimeCancel)
+
+		Expect(eventStore.AppendToStream(ctx, mother.AccountOpenWithMovements())).To(Succeed())
+		var err error
+		storedEvents, err = eventStore.LoadAllEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("applies only the handled events of the store before Start returns", func() {
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		Expect(projector.applied()).To(HaveExactElements(
+			BeAssignableToTypeOf(&account.AccountOpened{}),
+			BeAssignableToTypeOf(&account.AmountDeposited{}),
+		))
+	})
+
+	It("rejects two projections with the same name", func() {
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+		err := runtime.Register(newFakeProjector(), projection.ErrorPolicySkip)
+
+		Expect(err).To(MatchError(projection.ErrProjectionAlreadyRegistered))
+	})
+
+	It("saves a snapshot with the state and the last processed event", func(ctx context.Context) {
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		snapshot, err := projectionStore.LoadSnapshot(ctx, projector.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot.LastEventID).To(Equal(storedEvents[len(storedEvents)-1].EventID()))
+		Expect(snapshot.Version).To(Equal(projector.Version()))
+		Expect(snapshot.State).To(Equal([]byte("2")))
+		_, err = projectionStore.LoadCheckpoint(ctx, projector.Name())
+		Expect(err).To(MatchError(persistence.ErrProjectionCheckpointNotFound))
+	})
+
+	It("keeps applying the events appended after starting", func(ctx context.Context) {
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		acc, err := account.OpenAccount("other-account")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+
+		Eventually(projector.applied).Should(HaveLen(3))
+		Eventually(runtime.Status).WithArguments(ctx).Should(ConsistOf(MatchFields(IgnoreExtras, Fields{
+			"State":           Equal(projection.StateLive),
+			"LastEventID":     Equal(acc.UncommittedEvents()[0].EventID()),
+			"ProcessedEvents": BeEquivalentTo(5),
+		})))
+	})
+
+	When("the snapshot is saved every number of events", func() {
+		var snapshottedEventID domain.EventID
+
+		BeforeEach(func(ctx context.Context) {
+			runtime.WithSnapshotEvery(2, time.Hour)
+			Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+			snapshottedEventID = storedEvents[len(storedEvents)-1].EventID()
+		})
+
+		lastSnapshottedEventID := func(ctx context.Context) domain.EventID {
+			snapshot, err := projectionStore.LoadSnapshot(ctx, projector.Name())
+			Expect(err).ToNot(HaveOccurred())
+			return snapshot.LastEventID
+		}
+
+		openAccount := func(ctx context.Context, id string) domain.EventID {
+			acc, err := account.OpenAccount(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+			return acc.UncommittedEvents()[0].EventID()
+		}
+
+		It("saves the snapshot once the number of events has been processed", func(ctx context.Context) {
+			Expect(lastSnapshottedEventID(ctx)).To(Equal(snapshottedEventID))
+
+			firstEventID := openAccount(ctx, "first-account")
+			Eventually(func() domain.EventID { return runtime.Status(ctx)[0].LastEventID }).Should(Equal(firstEventID))
+			Consistently(lastSnapshottedEventID).WithArguments(ctx).Should(Equal(snapshottedEventID))
+
+			secondEventID := openAccount(ctx, "second-account")
+			Eventually(lastSnapshottedEventID).WithArguments(ctx).Should(Equal(secondEventID))
+		})
+
+		It("saves the snapshot when the runtime is stopped", func(ctx context.Context) {
+			eventID := openAccount(ctx, "first-account")
+			Eventually(func() domain.EventID { return runtime.Status(ctx)[0].LastEventID }).Should(Equal(eventID))
+
+			runtimeCancel()
+
+			Eventually(lastSnapshottedEventID).WithArguments(ctx).Should(Equal(eventID))
+			Eventually(func() projection.State { return runtime.Status(ctx)[0].State }).Should(Equal(projection.StateStopped))
+		})
+	})
+
+	When("there is a snapshot saved", func() {
+		BeforeEach(func(ctx context.Context) {
+			Expect(projectionStore.SaveSnapshot(ctx, persistence.ProjectionSnapshot{
+				Name:        projector.Name(),
+				Version:     projector.Version(),
+				LastEventID: storedEvents[1].EventID(),
+				State:       []byte("40"),
+			})).To(Succeed())
+		})
+
+		It("restores the state and resumes after the last processed event", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(projector.applied()).To(BeEmpty())
+			Expect(projector.restoredCount()).To(Equal(40))
+			Expect(runtime.Status(ctx)[0].LastEventID).To(Equal(storedEvents[len(storedEvents)-1].EventID()))
+		})
+
+		It("rebuilds the projection if the snapshot was saved by another version", func() {
+			projector.version++
+			Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(projector.restoredCount()).To(BeZero())
+			Expect(projector.applied()).To(HaveLen(2))
+		})
+	})
+
+	When("the projector persists its read model by itself", func() {
+		var positionProjector projection.Projector
+
+		BeforeEach(func() {
+			// Only the methods of the Projector are promoted, so it is not a Snapshotter.
+			positionProjector = struct{ projection.Projector }{projector}
+		})
+
+		It("saves a checkpoint with the last processed event and no snapshot", func(ctx context.Context) {
+			Expect(runtime.Register(positionProjector, projection.ErrorPolicyHalt)).To(Succeed())
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			checkpoint, err := projectionStore.LoadCheckpoint(ctx, projector.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checkpoint.LastEventID).To(Equal(storedEvents[len(storedEvents)-1].EventID()))
+			Expect(checkpoint.Version).To(Equal(projector.Version()))
+			_, err = projectionStore.LoadSnapshot(ctx, projector.Name())
+			Expect(err).To(MatchError(persistence.ErrProjectionSnapshotNotFound))
+		})
+
+		It("resumes after the last event of the checkpoint", func(ctx context.Context) {
+			Expect(projectionStore.SaveCheckpoint(ctx, persistence.ProjectionCheckpoint{
+				Name:        projector.Name(),
+				Version:     projector.Version(),
+				LastEventID: storedEvents[1].EventID(),
+			})).To(Succeed())
+			Expect(runtime.Register(positionProjector, projection.ErrorPolicyHalt)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(projector.applied()).To(BeEmpty())
+			Expect(runtime.Status(ctx)[0].LastEventID).To(Equal(storedEvents[len(storedEvents)-1].EventID()))
+		})
+	})
+
+	When("the projector fails to apply an event", func() {
+		BeforeEach(func() {
+			projector.failuresLeft[(&account.AmountDeposited{}).EventName()] = 100
+		})
+
+		It("halts the projection at the failing event with the halt policy", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(runtime.Status(ctx)).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"State":       Equal(projection.StateHalted),
+				"LastEventID": Equal(storedEvents[0].EventID()),
+				"LastError":   ContainSubstring("projector failed"),
+				"Lag":         BeEquivalentTo(len(storedEvents) - 1),
+			})))
+			snapshot, err := projectionStore.LoadSnapshot(ctx, projector.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.LastEventID).To(Equal(storedEvents[0].EventID()))
+		})
+
+		It("continues with the next event with the skip policy", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicySkip)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(runtime.Status(ctx)).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"State":         Equal(projection.StateLive),
+				"LastEventID":   Equal(storedEvents[len(storedEvents)-1].EventID()),
+				"SkippedEvents": BeEquivalentTo(1),
+			})))
+		})
+
+		It("restarts the halted projection from the failing event once the projector recovers", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+			projector.stopFailing()
+
+			Expect(runtime.Restart(ctx, projector.Name())).To(Succeed())
+
+			Expect(projector.applied()).To(HaveLen(2))
+			Expect(runtime.Status(ctx)).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"State":       Equal(projection.StateLive),
+				"LastEventID": Equal(storedEvents[len(storedEvents)-1].EventID()),
+				"LastError":   BeEmpty(),
+				"Lag":         BeZero(),
+			})))
+
+			acc, err := account.OpenAccount("other-account")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(eventStore.AppendToStream(ctx, acc)).To(Succeed())
+			Eventually(projector.applied).Should(HaveLen(3))
+		})
+
+		It("halts the projection again if it is restarted before the projector recovers", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			err := runtime.Restart(ctx, projector.Name())
+
+			Expect(err).To(MatchError(ContainSubstring("projector failed")))
+			Expect(runtime.Status(ctx)[0].State).To(Equal(projection.StateHalted))
+		})
+
+		It("halts the projection once the retries are exhausted with the retry policy", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicyRetry)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(projector.attempts((&account.AmountDeposited{}).EventName())).To(Equal(4))
+			Expect(runtime.Status(ctx)[0].State).To(Equal(projection.StateHalted))
+		})
+	})
+
+	When("the projector recovers before the retries are exhausted", func() {
+		BeforeEach(func() {
+			projector.failuresLeft[(&account.AmountDeposited{}).EventName()] = 2
+		})
+
+		It("applies the event with the retry policy", func(ctx context.Context) {
+			Expect(runtime.Register(projector, projection.ErrorPolicyRetry)).To(Succeed())
+
+			Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+			Expect(projector.applied()).To(HaveLen(2))
+			Expect(runtime.Status(ctx)[0].State).To(Equal(projection.StateLive))
+		})
+	})
+
+	It("does not restart a projection that is not halted", func(ctx context.Context) {
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		err := runtime.Restart(ctx, projector.Name())
+
+		Expect(err).To(MatchError(projection.ErrProjectionNotHalted))
+	})
+
+	It("reports as lag the events of the store the projection has not processed yet", func(ctx context.Context) {
+		Expect(runtime.Register(projector, projection.ErrorPolicyHalt)).To(Succeed())
+
+		Expect(runtime.ProjectionStatus(ctx, projector.Name())).To(HaveField("Lag", BeEquivalentTo(len(storedEvents))))
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+		Expect(runtime.ProjectionStatus(ctx, projector.Name())).To(HaveField("Lag", BeZero()))
+	})
+
+	It("serves the status of the projections as JSON", func(ctx context.Context) {
+		Expect(runtime.Register(projector, projection.ErrorPolicySkip)).To(Succeed())
+		Expect(runtime.Start(runtimeCtx)).To(Succeed())
+
+		recorder := httptest.NewRecorder()
+		runtime.StatusHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		var response []map[string]any
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		Expect(response).To(ConsistOf(MatchKeys(IgnoreExtras, Keys{
+			"name":            Equal(projector.Name()),
+			"state":           Equal("live"),
+			"errorPolicy":     Equal("skip"),
+			"lastEventId":     Equal(string(storedEvents[len(storedEvents)-1].EventID())),
+			"processedEvents": BeEquivalentTo(4),
+			"lagEvents":       BeZero(),
+		})))
+	})
+})
+
+// fakeProjector counts the events it applies, and fails the configured number of times for every event name.
+type fakeProjector struct {
+	name           string
+	handledEvents  []string
+	version        uint64
+	events         []domain.Event
+	failuresLeft   map[string]int
+	attemptsByName map[string]int
+	restored       int
+	mutex          sync.Mutex
+}
+
+func newFakeProjector(handledEvents ...string) *fakeProjector {
+	return &fakeProjector{
+		name:           "fake",
+		handledEvents:  handledEvents,
+		version:        1,
+		failuresLeft:   map[string]int{},
+		attemptsByName: map[string]int{},
+	}
+}
+
+func (f *fakeProjector) Name() string            { return f.name }
+func (f *fakeProjector) Version() uint64         { return f.version }
+func (f *fakeProjector) HandledEvents() []string { return f.handledEvents }
+
+func (f *fakeProjector) Apply(_ context.Context, event domain.Event) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.attemptsByName[event.EventName()]++
+	if f.failuresLeft[event.EventName()] > 0 {
+		f.failuresLeft[event.EventName()]--
+		return errors.New("projector failed")
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeProjector) Snapshot() ([]byte, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return []byte(strconv.Itoa(f.restored + len(f.events))), nil
+}
+
+func (f *fakeProjector) Restore(state []byte) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	restored, err := strconv.Atoi(string(state))
+	f.restored = restored
+	return err
+}
+
+func (f *fakeProjector) stopFailing() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	clear(f.failuresLeft)
+}
+
+func (f *fakeProjector) applied() []domain.Event {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]domain.Event(nil), f.events...)
+}
+
+func (f *fakeProjector) restoredCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.restored
+}
+
+func (f *fakeProjector) attempts(eventName string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.attemptsByName[eventName]
+}
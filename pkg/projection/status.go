@@ -0,0 +1,74 @@
+package projection
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+)
+
+type State string
+
+const (
+	StateStopped    State = "stopped"
+	StateCatchingUp State = "catching-up"
+	StateLive       State = "live"
+	StateHalted     State = "halted"
+)
+
+// Status describes how far a projection has processed the event store.
+// Lag is the number of events in the store after the last event processed, that is,
+// how far behind the head of the log the projection is.
+type Status struct {
+	LastProcessedOn time.Time
+	Name            string
+	State           State
+	ErrorPolicy     string
+	LastEventID     domain.EventID
+	LastError       string
+	Version         uint64
+	ProcessedEvents uint64
+	SkippedEvents   uint64
+	Lag             uint64
+}
+
+type statusResponse struct {
+	LastProcessedOn time.Time `json:"lastProcessedOn"`
+	Name            string    `json:"name"`
+	State           State     `json:"state"`
+	ErrorPolicy     string    `json:"errorPolicy"`
+	LastEventID     string    `json:"lastEventId"`
+	LastError       string    `json:"lastError,omitempty"`
+	Version         uint64    `json:"version"`
+	ProcessedEvents uint64    `json:"processedEvents"`
+	SkippedEvents   uint64    `json:"skippedEvents"`
+	LagEvents       uint64    `json:"lagEvents"`
+}
+
+// StatusHandler serves the status of every projection of the runtime as JSON.
+func (r *Runtime) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		statuses := r.Status(request.Context())
+		response := make([]statusResponse, 0, len(statuses))
+		for _, status := range statuses {
+			response = append(response, statusResponse{
+				Name:            status.Name,
+				Version:         status.Version,
+				State:           status.State,
+				ErrorPolicy:     status.ErrorPolicy,
+				LastEventID:     string(status.LastEventID),
+				LastProcessedOn: status.LastProcessedOn,
+				LagEvents:       status.Lag,
+				ProcessedEvents: status.ProcessedEvents,
+				SkippedEvents:   status.SkippedEvents,
+				LastError:       status.LastError,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -8,7 +8,22 @@ import (
 )
 
 func init() {
-	serializer.RegisterSerializableEvent(&TransferRequested{})
+	for _, event := range serializableEvents() {
+		serializer.RegisterSerializableEvent(event)
+	}
+}
+
+// RegisterEvents registers the events of the transfer aggregate in the registry, so a store using it can deserialize them.
+func RegisterEvents(registry *serializer.EventRegistry) {
+	registry.Register(serializableEvents()...)
+}
+
+func serializableEvents() []domain.Event {
+	return []domain.Event{
+		&TransferRequested{},
+		&TransferDelivered{},
+		&TransferCancelled{},
+	}
 }
 
 // nolint:revive
@@ -41,3 +56,62 @@ func (t *TransferRequested) HappenedOn() time.Time {
 func (t *TransferRequested) Version() uint64 {
 	return t.TransferVersion
 }
+
+// TransferDelivered is stored when the destination account receives the money of the transfer.
+type TransferDelivered struct {
+	Timestamp       time.Time
+	ID              domain.EventID
+	TransferID      string
+	TransferVersion uint64
+}
+
+func (t *TransferDelivered) AggregateID() string {
+	return t.TransferID
+}
+
+func (t *TransferDelivered) EventID() domain.EventID {
+	return t.ID
+}
+
+func (t *TransferDelivered) EventName() string {
+	return "TransferDelivered"
+}
+
+func (t *TransferDelivered) HappenedOn() time.Time {
+	return t.Timestamp
+}
+
+func (t *TransferDelivered) Version() uint64 {
+	return t.TransferVersion
+}
+
+// TransferCancelled is stored when the money of the transfer is returned to the origin account before it is delivered.
+// The reason is written by the holder of the origin account, so it is personal data of that account.
+type TransferCancelled struct {
+	Timestamp       time.Time
+	ID              domain.EventID
+	TransferID      string
+	FromAccount     string
+	Reason          string `personal:"FromAccount"`
+	TransferVersion uint64
+}
+
+func (t *TransferCancelled) AggregateID() string {
+	return t.TransferID
+}
+
+func (t *TransferCancelled) EventID() domain.EventID {
+	return t.ID
+}
+
+func (t *TransferCancelled) EventName() string {
+	return "TransferCancelled"
+}
+
+func (t *TransferCancelled) HappenedOn() time.Time {
+	return t.Timestamp
+}
+
+func (t *TransferCancelled) Version() uint64 {
+	return t.TransferVersion
+}
@@ -9,7 +9,7 @@ import (
 
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 	"github.com/tembleking/myBankSourcing/pkg/persistence"
-	"github.com/tembleking/myBankSourcing/pkg/persistence/sqlite"
+	"github.com/tembleking/myBankSourcing/pkg/persistence/inmemory"
 	"github.com/tembleking/myBankSourcing/pkg/transfer"
 	"github.com/tembleking/myBankSourcing/test/matchers"
 )
@@ -19,7 +19,7 @@ var _ = Describe("Repository", func() {
 
 	BeforeEach(func() {
 		log.SetOutput(GinkgoWriter)
-		repository = transfer.NewRepository(persistence.NewEventStoreBuilder(sqlite.InMemory()).Build())
+		repository = transfer.NewRepository(persistence.NewEventStoreBuilder(inmemory.NewAppendOnlyStore()).Build())
 	})
 
 	It("saves an transfer and retrieves it", func(ctx context.Context) {
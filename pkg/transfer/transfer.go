@@ -1,15 +1,27 @@
 package transfer
 
 import (
+	"errors"
+
 	"github.com/tembleking/myBankSourcing/pkg/domain"
 )
 
+var (
+	ErrTransferCancelled        = errors.New("transfer was cancelled")
+	ErrTransferAlreadyDelivered = errors.New("transfer was already delivered")
+)
+
+// Transfer is the state machine of a transfer: once requested, it is either delivered to the destination account
+// or cancelled, returning the money to the origin account. Every command resolving a transfer stores an event
+// in its stream, so the concurrent commands resolving the same transfer conflict and only one of them succeeds.
 type Transfer struct {
 	transferID  string
 	fromAccount string
 	toAccount   string
 	domain.BaseAggregate
-	amount int
+	amount    int
+	delivered bool
+	cancelled bool
 }
 
 func (t *Transfer) FromAccount() string {
@@ -28,6 +40,14 @@ func (t *Transfer) ID() string {
 	return t.transferID
 }
 
+func (t *Transfer) IsDelivered() bool {
+	return t.delivered
+}
+
+func (t *Transfer) IsCancelled() bool {
+	return t.cancelled
+}
+
 func NewTransfer() *Transfer {
 	t := &Transfer{}
 	t.OnEventFunc = t.onEvent
@@ -48,6 +68,44 @@ func RequestTransfer(fromAccount string, toAccount string, amount int) *Transfer
 	return transfer
 }
 
+// Deliver records that the destination account received the money of the transfer.
+func (t *Transfer) Deliver() error {
+	if t.cancelled {
+		return ErrTransferCancelled
+	}
+	if t.delivered {
+		return nil // idempotent
+	}
+
+	t.Apply(&TransferDelivered{
+		ID:              domain.NewEventID(),
+		TransferID:      t.ID(),
+		Timestamp:       t.Now(),
+		TransferVersion: t.NextVersion(),
+	})
+	return nil
+}
+
+// Cancel records that the money of the transfer is returned to the origin account, for the given reason.
+func (t *Transfer) Cancel(reason string) error {
+	if t.delivered {
+		return ErrTransferAlreadyDelivered
+	}
+	if t.cancelled {
+		return nil // idempotent
+	}
+
+	t.Apply(&TransferCancelled{
+		ID:              domain.NewEventID(),
+		TransferID:      t.ID(),
+		FromAccount:     t.fromAccount,
+		Reason:          reason,
+		Timestamp:       t.Now(),
+		TransferVersion: t.NextVersion(),
+	})
+	return nil
+}
+
 func (t *Transfer) SameEntityAs(other domain.Entity) bool {
 	if t == nil && other == nil {
 		return true
@@ -62,10 +120,15 @@ func (t *Transfer) SameEntityAs(other domain.Entity) bool {
 }
 
 func (t *Transfer) onEvent(event domain.Event) {
-	if e, ok := event.(*TransferRequested); ok {
+	switch e := event.(type) {
+	case *TransferRequested:
 		t.transferID = e.TransferID
 		t.fromAccount = e.FromAccount
 		t.toAccount = e.ToAccount
 		t.amount = e.Amount
+	case *TransferDelivered:
+		t.delivered = true
+	case *TransferCancelled:
+		t.cancelled = true
 	}
 }
@@ -23,4 +23,38 @@ var _ = Describe("Transfer", func() {
 		Expect(transfer.ToAccount()).To(Equal("toAccount"))
 		Expect(transfer.Amount()).To(Equal(100))
 	})
+
+	It("is delivered once", func() {
+		transfer := RequestTransfer("fromAccount", "toAccount", 100)
+
+		Expect(transfer.Deliver()).To(Succeed())
+		Expect(transfer.Deliver()).To(Succeed())
+
+		Expect(transfer.IsDelivered()).To(BeTrue())
+		Expect(transfer.UncommittedEvents()).To(HaveExactElements(BeAssignableToTypeOf(&TransferRequested{}), BeAssignableToTypeOf(&TransferDelivered{})))
+	})
+
+	It("is cancelled once", func() {
+		transfer := RequestTransfer("fromAccount", "toAccount", 100)
+
+		Expect(transfer.Cancel("wrong destination")).To(Succeed())
+		Expect(transfer.Cancel("wrong destination")).To(Succeed())
+
+		Expect(transfer.IsCancelled()).To(BeTrue())
+		Expect(transfer.UncommittedEvents()).To(HaveExactElements(BeAssignableToTypeOf(&TransferRequested{}), BeAssignableToTypeOf(&TransferCancelled{})))
+	})
+
+	It("cannot be cancelled once delivered", func() {
+		transfer := RequestTransfer("fromAccount", "toAccount", 100)
+		Expect(transfer.Deliver()).To(Succeed())
+
+		Expect(transfer.Cancel("wrong destination")).To(MatchError(ErrTransferAlreadyDelivered))
+	})
+
+	It("cannot be delivered once cancelled", func() {
+		transfer := RequestTransfer("fromAccount", "toAccount", 100)
+		Expect(transfer.Cancel("wrong destination")).To(Succeed())
+
+		Expect(transfer.Deliver()).To(MatchError(ErrTransferCancelled))
+	})
 })
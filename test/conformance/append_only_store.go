@@ -0,0 +1,402 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/tembleking/myBankSourcing/pkg/domain"
+	"github.com/tembleking/myBankSourcing/pkg/persistence"
+)
+
+// AppendOnlyStore defines the specs every persistence.AppendOnlyStore must pass.
+// It must be called from a container node, and newStore is called before every spec to return an empty store.
+// The store can be released with DeferCleanup from newStore.
+func AppendOnlyStore(newStore func(ctx context.Context) persistence.AppendOnlyStore) {
+	var store persistence.AppendOnlyStore
+
+	BeforeEach(func(ctx context.Context) {
+		store = newStore(ctx)
+	})
+
+	Describe("appending and reading", func() {
+		It("returns nothing from an empty store", func(ctx context.Context) {
+			Expect(store.ReadAllRecords(ctx)).To(BeEmpty())
+			Expect(store.ReadRecords(ctx, "aggregate-0")).To(BeEmpty())
+		})
+
+		It("does nothing when appending no events", func(ctx context.Context) {
+			Expect(store.Append(ctx)).To(Succeed())
+
+			Expect(store.ReadAllRecords(ctx)).To(BeEmpty())
+		})
+
+		It("returns the events as they were appended", func(ctx context.Context) {
+			happenedOn := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+			Expect(store.Append(ctx, persistence.StoredStreamEvent{
+				ID:          persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 3},
+				EventID:     "event0",
+				EventName:   "eventName",
+				EventData:   []byte("data"),
+				HappenedOn:  happenedOn,
+				ContentType: "some-content-type",
+			})).To(Succeed())
+
+			records, err := store.ReadRecords(ctx, "aggregate-0")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].ID).To(Equal(persistence.StreamID{StreamName: "aggregate-0", StreamVersion: 3}))
+			Expect(records[0].EventID).To(Equal(domain.EventID("event0")))
+			Expect(records[0].EventName).To(Equal("eventName"))
+			Expect(records[0].EventData).To(Equal([]byte("data")))
+			Expect(records[0].HappenedOn).To(BeTemporally("==", happenedOn))
+			Expect(records[0].ContentType).To(Equal("some-content-type"))
+		})
+
+		It("returns only the events of the stream", func(ctx context.Context) {
+			appendEvents(ctx, store, "aggregate-0", 0, 2)
+			appendEvents(ctx, store, "aggregate-1", 0, 1)
+
+			Expect(store.ReadRecords(ctx, "aggregate-0")).To(HaveExactElements(
+				haveEventID("aggregate-0-0"),
+				haveEventID("aggregate-0-1"),
+			))
+			Expect(store.ReadRecords(ctx, "aggregate-2")).To(BeEmpty())
+		})
+
+		It("returns all the events in the order they were appended", func(ctx context.Context) {
+			appendEvents(ctx, store, "aggregate-1", 0, 1)
+			appendEvents(ctx, store, "aggregate-0", 0, 2)
+			appendEvents(ctx, store, "aggregate-1", 1, 1)
+
+			Expect(store.ReadAllRecords(ctx)).To(HaveExactElements(
+				haveEventID("aggregate-1-0"),
+				haveEventID("aggregate-0-0"),
+				haveEventID("aggregate-0-1"),
+				haveEventID("aggregate-1-1"),
+			))
+		})
+
+		It("returns the events of a stream in the order they were appended", func(ctx context.Context) {
+			appendEvents(ctx, store, "aggregate-0", 0, 1)
+			appendEvents(ctx, store, "aggregate-1", 0, 1)
+			appendEvents(ctx, store, "aggregate-0", 1, 2)
+
+			Expect(store.ReadRecords(ctx, "aggregate-0")).To(HaveExactElements(
+				haveEventID("aggregate-0-0"),
+				haveEventID("aggregate-0-1"),
+				haveEventID("aggregate-0-2"),
+			))
+		})
+	})
+
+	Describe("version conflicts", func() {
+		BeforeEach(func(ctx context.Context) {
+			appendEvents(ctx, store, "aggregate-0", 0, 2)
+		})
+
+		It("rejects an event with a version that already exists in the stream", func(ctx context.Context) {
+			err := store.Append(ctx, newEvent("aggregate-0", 1, "other-event"))
+
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+
+		It("rejects an event with an ID that already exists", func(ctx context.Context) {
+			err := store.Append(ctx, newEvent("aggregate-1", 0, "aggregate-0-0"))
+
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+
+		It("rejects a batch with the same version twice", func(ctx context.Context) {
+			err := store.Append(ctx, newEvent("aggregate-1", 0, "event-a"), newEvent("aggregate-1", 0, "event-b"))
+
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+
+		It("appends nothing from a batch with a conflicting event", func(ctx context.Context) {
+			err := store.Append(ctx,
+				newEvent("aggregate-1", 0, "aggregate-1-0"),
+				newEvent("aggregate-0", 2, "aggregate-0-2"),
+				newEvent("aggregate-0", 1, "conflicting-event"),
+			)
+
+			Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(2))
+		})
+
+		It("accepts the same version in different streams", func(ctx context.Context) {
+			Expect(store.Append(ctx, newEvent("aggregate-1", 0, "aggregate-1-0"))).To(Succeed())
+		})
+
+		It("accepts the next version after a conflict", func(ctx context.Context) {
+			Expect(store.Append(ctx, newEvent("aggregate-0", 1, "other-event"))).ToNot(Succeed())
+
+			Expect(store.Append(ctx, newEvent("aggregate-0", 2, "other-event"))).To(Succeed())
+		})
+	})
+
+	Describe("filtering", func() {
+		BeforeEach(func(ctx context.Context) {
+			appendEvents(ctx, store, "aggregate-0", 0, 1)
+			appendEvents(ctx, store, "aggregate-1", 0, 1)
+			appendEvents(ctx, store, "aggregate-2", 0, 3)
+		})
+
+		AfterEach(func(ctx context.Context) {
+			// The filters return new stores, so the original one must not be modified.
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(5))
+		})
+
+		It("returns the events after an event ID", func(ctx context.Context) {
+			Expect(store.AfterEventID("aggregate-2-0").ReadAllRecords(ctx)).To(HaveExactElements(
+				haveEventID("aggregate-2-1"),
+				haveEventID("aggregate-2-2"),
+			))
+		})
+
+		It("returns nothing after the last event", func(ctx context.Context) {
+			Expect(store.AfterEventID("aggregate-2-2").ReadAllRecords(ctx)).To(BeEmpty())
+		})
+
+		It("returns nothing after an event ID that does not exist", func(ctx context.Context) {
+			Expect(store.AfterEventID("nonexistent").ReadAllRecords(ctx)).To(BeEmpty())
+		})
+
+		It("returns the first events up to the limit", func(ctx context.Context) {
+			Expect(store.Limit(2).ReadAllRecords(ctx)).To(HaveExactElements(
+				haveEventID("aggregate-0-0"),
+				haveEventID("aggregate-1-0"),
+			))
+			Expect(store.Limit(10).ReadAllRecords(ctx)).To(HaveLen(5))
+		})
+
+		It("composes the filters in any order", func(ctx context.Context) {
+			expected := HaveExactElements(
+				haveEventID("aggregate-2-0"),
+				haveEventID("aggregate-2-1"),
+			)
+
+			Expect(store.AfterEventID("aggregate-1-0").Limit(2).ReadAllRecords(ctx)).To(expected)
+			Expect(store.Limit(2).AfterEventID("aggregate-1-0").ReadAllRecords(ctx)).To(expected)
+		})
+
+		It("uses the most restrictive filters when they are applied twice", func(ctx context.Context) {
+			Expect(store.AfterEventID("aggregate-0-0").AfterEventID("aggregate-2-0").Limit(3).Limit(1).ReadAllRecords(ctx)).To(HaveExactElements(
+				haveEventID("aggregate-2-1"),
+			))
+		})
+
+		It("counts the events passing the filters", func(ctx context.Context) {
+			Expect(store.CountRecords(ctx)).To(Equal(5))
+			Expect(store.AfterEventID("aggregate-1-0").CountRecords(ctx)).To(Equal(3))
+			Expect(store.AfterEventID("aggregate-1-0").Limit(2).CountRecords(ctx)).To(Equal(2))
+			Expect(store.AfterEventID("nonexistent").CountRecords(ctx)).To(BeZero())
+		})
+
+		It("applies the filters to the events of a stream", func(ctx context.Context) {
+			Expect(store.AfterEventID("aggregate-0-0").ReadRecords(ctx, "aggregate-2")).To(HaveLen(3))
+			Expect(store.AfterEventID("aggregate-2-0").Limit(1).ReadRecords(ctx, "aggregate-2")).To(HaveExactElements(
+				haveEventID("aggregate-2-1"),
+			))
+		})
+	})
+
+	Describe("concurrency", func() {
+		It("accepts only one of the concurrent appends of the same version", func(ctx context.Context) {
+			const writers = 10
+			errs := make(chan error, writers)
+			var wg sync.WaitGroup
+			for i := range writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					errs <- store.Append(ctx, newEvent("aggregate-0", 0, domain.EventID(fmt.Sprintf("event-%d", i))))
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			succeeded := 0
+			for err := range errs {
+				if err == nil {
+					succeeded++
+					continue
+				}
+				Expect(err).To(MatchError(persistence.ErrUnexpectedVersion))
+			}
+			Expect(succeeded).To(Equal(1))
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(1))
+		})
+
+		It("keeps all the concurrent appends to different streams", func(ctx context.Context) {
+			const writers, eventsPerWriter = 10, 20
+			var wg sync.WaitGroup
+			for i := range writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					appendEvents(ctx, store, fmt.Sprintf("aggregate-%d", i), 0, eventsPerWriter)
+				}()
+			}
+			wg.Wait()
+
+			Expect(store.ReadAllRecords(ctx)).To(HaveLen(writers * eventsPerWriter))
+			for i := range writers {
+				records, err := store.ReadRecords(ctx, fmt.Sprintf("aggregate-%d", i))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(eventsPerWriter))
+				for version, record := range records {
+					Expect(record.ID.StreamVersion).To(BeEquivalentTo(version))
+				}
+			}
+		})
+	})
+
+	Describe("tenants", func() {
+		const (
+			tenantA domain.TenantID = "tenant-a"
+			tenantB domain.TenantID = "tenant-b"
+		)
+
+		BeforeEach(func(ctx context.Context) {
+			appendEvents(domain.WithTenant(ctx, tenantA), store, "aggregate-0", 0, 2)
+			Expect(store.Append(domain.WithTenant(ctx, tenantB), newEvent("aggregate-0", 0, "tenant-b-event"))).To(Succeed())
+		})
+
+		It("returns only the events of the tenant of the context", func(ctx context.Context) {
+			Expect(store.ReadAllRecords(domain.WithTenant(ctx, tenantA))).To(HaveExactElements(
+				haveEventID("aggregate-0-0"),
+				haveEventID("aggregate-0-1"),
+			))
+			Expect(store.ReadRecords(domain.WithTenant(ctx, tenantB), "aggregate-0")).To(HaveExactElements(haveEventID("tenant-b-event")))
+			Expect(store.ReadAllRecords(domain.WithTenant(ctx, "tenant-c"))).To(BeEmpty())
+		})
+
+		It("keeps the streams of every tenant apart", func(ctx context.Context) {
+			Expect(store.Append(domain.WithTenant(ctx, tenantB), newEvent("aggregate-0", 1, "other-event"))).To(Succeed())
+
+			Expect(store.Append(domain.WithTenant(ctx, tenantB), newEvent("aggregate-0", 1, "conflicting-event"))).To(MatchError(persistence.ErrUnexpectedVersion))
+		})
+
+		It("appends to the tenant of the context whatever tenant the events carry", func(ctx context.Context) {
+			event := newEvent("aggregate-1", 0, "aggregate-1-0")
+			event.TenantID = tenantA
+
+			Expect(store.Append(domain.WithTenant(ctx, tenantB), event)).To(Succeed())
+
+			Expect(store.ReadRecords(domain.WithTenant(ctx, tenantA), "aggregate-1")).To(BeEmpty())
+			Expect(store.ReadRecords(domain.WithTenant(ctx, tenantB), "aggregate-1")).To(HaveExactElements(HaveField("TenantID", tenantB)))
+		})
+
+		It("counts only the events of the tenant of the context", func(ctx context.Context) {
+			Expect(store.CountRecords(domain.WithTenant(ctx, tenantA))).To(Equal(2))
+			Expect(store.CountRecords(domain.WithTenant(ctx, "tenant-c"))).To(BeZero())
+			Expect(store.CountRecords(domain.WithAllTenants(ctx))).To(Equal(3))
+		})
+
+		It("applies the filters to the events of the tenant", func(ctx context.Context) {
+			Expect(store.AfterEventID("aggregate-0-0").ReadAllRecords(domain.WithTenant(ctx, tenantA))).To(HaveExactElements(haveEventID("aggregate-0-1")))
+			Expect(store.Limit(1).ReadAllRecords(domain.WithTenant(ctx, tenantB))).To(HaveExactElements(haveEventID("tenant-b-event")))
+		})
+
+		It("returns the events of every tenant to a context for all the tenants", func(ctx context.Context) {
+			Expect(store.ReadAllRecords(domain.WithAllTenants(ctx))).To(HaveExactElements(
+				SatisfyAll(haveEventID("aggregate-0-0"), HaveField("TenantID", tenantA)),
+				SatisfyAll(haveEventID("aggregate-0-1"), HaveField("TenantID", tenantA)),
+				SatisfyAll(haveEventID("tenant-b-event"), HaveField("TenantID", tenantB)),
+			))
+			Expect(store.ReadRecords(domain.WithAllTenants(ctx), "aggregate-0")).To(HaveLen(3))
+		})
+
+		It("appends the events to the tenant they carry from a context for all the tenants", func(ctx context.Context) {
+			event := newEvent("aggregate-1", 0, "aggregate-1-0")
+			event.TenantID = tenantB
+
+			Expect(store.Append(domain.WithAllTenants(ctx), event)).To(Succeed())
+
+			Expect(store.ReadRecords(domain.WithTenant(ctx, tenantB), "aggregate-1")).To(HaveExactElements(haveEventID("aggregate-1-0")))
+		})
+
+		It("keeps the events without tenant in the default tenant", func(ctx context.Context) {
+			appendEvents(ctx, store, "aggregate-1", 0, 1)
+
+			Expect(store.ReadAllRecords(ctx)).To(HaveExactElements(HaveField("TenantID", domain.DefaultTenant)))
+		})
+	})
+
+	Describe("large batches", func() {
+		const batchSize = 5000
+
+		BeforeEach(func(ctx context.Context) {
+			events := make([]persistence.StoredStreamEvent, 0, batchSize)
+			for version := range batchSize {
+				events = append(events, newEvent("aggregate-0", uint64(version), domain.EventID(fmt.Sprintf("event-%d", version))))
+			}
+
+			Expect(store.Append(ctx, events...)).To(Succeed())
+		})
+
+		It("keeps all the events of the batch in order", func(ctx context.Context) {
+			records, err := store.ReadRecords(ctx, "aggregate-0")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(batchSize))
+			for version, record := range records {
+				Expect(record.ID.StreamVersion).To(BeEquivalentTo(version))
+			}
+		})
+
+		It("pages through the batch", func(ctx context.Context) {
+			Expect(store.AfterEventID("event-2999").Limit(1000).ReadAllRecords(ctx)).To(SatisfyAll(
+				HaveLen(1000),
+				HaveEach(HaveField("ID.StreamName", "aggregate-0")),
+				ContainElement(haveEventID("event-3000")),
+				ContainElement(haveEventID("event-3999")),
+			))
+		})
+
+		It("rejects a batch conflicting with its last event", func(ctx context.Context) {
+			events := make([]persistence.StoredStreamEvent, 0, batchSize)
+			for version := range batchSize {
+				events = append(events, newEvent("aggregate-1", uint64(version), domain.EventID(fmt.Sprintf("other-event-%d", version))))
+			}
+			events[batchSize-1].EventID = "event-0"
+
+			Expect(store.Append(ctx, events...)).To(MatchError(persistence.ErrUnexpectedVersion))
+			Expect(store.ReadRecords(ctx, "aggregate-1")).To(BeEmpty())
+		})
+	})
+}
+
+// appendEvents appends count events to the stream starting at the given version, one append per event.
+// The ID of every event is the stream name followed by its version.
+func appendEvents(ctx context.Context, store persistence.AppendOnlyStore, streamName string, fromVersion uint64, count int) {
+	GinkgoHelper()
+
+	for version := fromVersion; version < fromVersion+uint64(count); version++ {
+		eventID := domain.EventID(fmt.Sprintf("%s-%d", streamName, version))
+		Expect(store.Append(ctx, newEvent(streamName, version, eventID))).To(Succeed())
+	}
+}
+
+func newEvent(streamName string, version uint64, eventID domain.EventID) persistence.StoredStreamEvent {
+	return persistence.StoredStreamEvent{
+		ID:          persistence.StreamID{StreamName: streamName, StreamVersion: version},
+		EventID:     eventID,
+		EventName:   "eventName",
+		EventData:   []byte("data"),
+		HappenedOn:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ContentType: "application/json",
+	}
+}
+
+func haveEventID(eventID domain.EventID) OmegaMatcher {
+	return HaveField("EventID", eventID)
+}